@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// initCapabilities exposes the backend's capability descriptor, modes and
+// pointer devices through the control handler.
+func (a *agent) initCapabilities(backend control.RobotAPI) {
+	if p, ok := backend.(control.CapabilityProvider); ok {
+		a.handler.SetCapabilityProvider(p)
+	}
+	if mc, ok := backend.(control.ModeController); ok {
+		a.handler.SetModeController(mc)
+		a.handler.SetModeChangeCallback(a.onModeChange)
+		a.logger.Info("robot modes available", zap.Strings("modes", mc.Modes()))
+	}
+	if p, ok := backend.(control.AbsolutePointer); ok {
+		a.handler.SetAbsolutePointer(p)
+	}
+}
+
+// onModeChange audits a mode switch as a privileged action.
+func (a *agent) onModeChange(from, to string) {
+	a.logger.Info("robot mode switched", zap.String("from", from), zap.String("to", to))
+
+	if a.audit == nil {
+		return
+	}
+	event := audit.Event{
+		EventType: audit.EventPrivilegedAction,
+		SessionID: a.currentSessionID(),
+		Timestamp: time.Now().UTC(),
+		Metadata: map[string]string{
+			"action":        "MODE_SWITCH",
+			"source":        "operator",
+			"mode":          to,
+			"previous_mode": from,
+		},
+	}
+	if info := a.sessionMgr.Info(); info != nil {
+		event.OperatorDID = info.OperatorDID
+	}
+	a.audit.Publish(event)
+}
+
+// handleAuth validates a console auth message and replies with auth_ok,
+// including the robot capabilities, or auth_err.
+func (a *agent) handleAuth(data []byte) {
+	var reply any
+	var msg protocol.AuthMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		reply = protocol.AuthErrMessage{Type: protocol.TypeAuthErr, Code: protocol.ErrInvalidToken, Reason: "malformed auth message"}
+	} else if info, err := a.authenticate(&msg); err != nil {
+		a.logger.Warn("console authentication failed",
+			zap.String("session_id", msg.SessionID),
+			zap.Error(err))
+		reply = protocol.AuthErrMessage{Type: protocol.TypeAuthErr, Code: authErrorCode(err), Reason: err.Error()}
+	} else {
+		caps := a.handler.Capabilities()
+		reply = protocol.AuthOKMessage{
+			Type:         protocol.TypeAuthOK,
+			SessionID:    info.SessionID,
+			RobotID:      info.RobotID,
+			Scope:        info.Scope,
+			ExpiresAt:    info.ExpiresAt.UnixMilli(),
+			Capabilities: &caps,
+		}
+	}
+
+	out, err := json.Marshal(reply)
+	if err != nil {
+		a.logger.Error("failed to marshal auth reply", zap.Error(err))
+		return
+	}
+	if err := a.transport.SendData(out); err != nil {
+		a.logger.Warn("failed to send auth reply", zap.Error(err))
+	}
+}
+
+// authenticate validates the token against the active session negotiated
+// over signaling; there is nothing to authenticate against without one.
+func (a *agent) authenticate(msg *protocol.AuthMessage) (*session.Info, error) {
+	if !a.sessionMgr.IsActive() {
+		return nil, session.ErrNoActiveSession
+	}
+	if a.currentSessionID() != msg.SessionID {
+		return nil, session.ErrSessionMismatch
+	}
+	return a.sessionMgr.ValidateToken(msg.SessionID, msg.Token)
+}
+
+// authErrorCode maps token validation errors to auth_err codes.
+func authErrorCode(err error) string {
+	switch {
+	case errors.Is(err, session.ErrTokenExpired):
+		return protocol.ErrTokenExpired
+	case errors.Is(err, session.ErrInvalidAudience):
+		return protocol.ErrWrongAudience
+	case errors.Is(err, session.ErrSessionMismatch), errors.Is(err, session.ErrNoActiveSession):
+		return protocol.ErrSessionMismatch
+	default:
+		return protocol.ErrInvalidToken
+	}
+}
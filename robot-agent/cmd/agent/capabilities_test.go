@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+func TestAuthErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{session.ErrTokenExpired, protocol.ErrTokenExpired},
+		{session.ErrInvalidAudience, protocol.ErrWrongAudience},
+		{session.ErrSessionMismatch, protocol.ErrSessionMismatch},
+		{session.ErrNoActiveSession, protocol.ErrSessionMismatch},
+		{fmt.Errorf("%w: bad claims", session.ErrInvalidToken), protocol.ErrInvalidToken},
+		{errors.New("unexpected"), protocol.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		if got := authErrorCode(tt.err); got != tt.want {
+			t.Errorf("authErrorCode(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestAuthenticate_RejectsOtherSession(t *testing.T) {
+	mgr := session.NewManager("test-robot", nil)
+	if err := mgr.Activate(&session.Info{SessionID: "ses-1"}); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	a := &agent{logger: zap.NewNop(), sessionMgr: mgr}
+
+	_, err := a.authenticate(&protocol.AuthMessage{Type: protocol.TypeAuth, SessionID: "ses-2", Token: "x"})
+	if !errors.Is(err, session.ErrSessionMismatch) {
+		t.Errorf("expected ErrSessionMismatch, got %v", err)
+	}
+}
+
+func TestAuthenticate_RequiresActiveSession(t *testing.T) {
+	a := &agent{logger: zap.NewNop(), sessionMgr: session.NewManager("test-robot", nil)}
+
+	_, err := a.authenticate(&protocol.AuthMessage{Type: protocol.TypeAuth, SessionID: "ses-1", Token: "x"})
+	if !errors.Is(err, session.ErrNoActiveSession) {
+		t.Errorf("expected ErrNoActiveSession, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/config"
+)
+
+// Gateway client timeouts, matching the clients' defaults.
+const (
+	jwksHTTPTimeout  = 10 * time.Second
+	auditHTTPTimeout = 5 * time.Second
+)
+
+// initGatewayTLS loads the gateway TLS settings. Without any, the gateway
+// clients keep their defaults and system roots.
+func (a *agent) initGatewayTLS() error {
+	if !a.cfg.GatewayTLS.Enabled() {
+		return nil
+	}
+
+	provider, err := config.NewTLSProvider(a.cfg.GatewayTLS)
+	if err != nil {
+		return err
+	}
+	provider.SetReloadErrorCallback(func(err error) {
+		a.logger.Error("gateway TLS reload failed, keeping previous certificates", zap.Error(err))
+	})
+	a.gatewayTLS = provider
+
+	a.logger.Info("gateway TLS configured",
+		zap.Bool("custom_ca", a.cfg.GatewayTLS.CAFile != ""),
+		zap.Int("spki_pins", len(a.cfg.GatewayTLS.SPKIPins)),
+		zap.Bool("client_cert", a.cfg.GatewayTLS.CertFile != ""))
+	return nil
+}
+
+// gatewayHTTPClient returns an HTTP client for the gateway, or nil to keep
+// the client's default.
+func (a *agent) gatewayHTTPClient(timeout time.Duration) *http.Client {
+	if a.gatewayTLS == nil {
+		return nil
+	}
+	return a.gatewayTLS.HTTPClient(timeout)
+}
+
+// gatewayDialer returns a WebSocket dialer for the gateway, or nil to keep
+// the client's default. The TLS settings also apply through a proxy.
+func (a *agent) gatewayDialer() *websocket.Dialer {
+	if a.gatewayTLS == nil {
+		return nil
+	}
+	var host string
+	if u, err := url.Parse(a.cfg.GatewayWSURL); err == nil {
+		host = u.Hostname()
+	}
+	return &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+		TLSClientConfig:  a.gatewayTLS.ClientConfig(host),
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+	"github.com/datapilot/chainkvm/robot-agent/internal/geofence"
+)
+
+// odometryRobot feeds commanded velocities into dead-reckoning odometry
+// for robot backends that cannot report their own pose.
+type odometryRobot struct {
+	control.RobotAPI
+	odom *geofence.Odometry
+}
+
+func (r *odometryRobot) Drive(v, w float64) error {
+	if err := r.RobotAPI.Drive(v, w); err != nil {
+		return err
+	}
+	r.odom.Record(v, w)
+	return nil
+}
+
+func (r *odometryRobot) EStop() error {
+	err := r.RobotAPI.EStop()
+	r.odom.Stop()
+	return err
+}
+
+// initGeofence loads the geofence if configured and returns the robot API to
+// use, wrapped with odometry when the backend does not provide a pose.
+func (a *agent) initGeofence(robotAPI control.RobotAPI) control.RobotAPI {
+	if a.cfg.GeofenceFile == "" {
+		return robotAPI
+	}
+
+	cfg, err := geofence.LoadConfig(a.cfg.GeofenceFile)
+	if err != nil {
+		a.logger.Fatal("failed to load geofence", zap.String("file", a.cfg.GeofenceFile), zap.Error(err))
+	}
+
+	source, ok := robotAPI.(geofence.PoseSource)
+	if !ok {
+		odom := geofence.NewOdometry(geofence.Pose{}, cfg.MaxLinearMps, cfg.MaxAngularRps)
+		robotAPI = &odometryRobot{RobotAPI: robotAPI, odom: odom}
+		source = odom
+		a.logger.Warn("robot backend has no pose source, using dead-reckoning odometry for geofence")
+	}
+
+	fence, err := geofence.New(cfg, source)
+	if err != nil {
+		a.logger.Fatal("invalid geofence", zap.Error(err))
+	}
+	fence.SetViolationCallback(a.onGeofenceViolation)
+	a.geofence = fence
+
+	a.logger.Info("geofence enabled",
+		zap.String("file", a.cfg.GeofenceFile),
+		zap.String("mode", string(cfg.Mode)),
+		zap.Int("keep_out_zones", len(cfg.KeepOut)))
+	return robotAPI
+}
+
+// checkGeofence triggers safe-stop if the robot is outside the fence.
+func (a *agent) checkGeofence() {
+	if a.geofence == nil {
+		return
+	}
+
+	clearance, inside, err := a.geofence.Check()
+	if err != nil {
+		a.logger.Warn("geofence check failed", zap.Error(err))
+		return
+	}
+	if !inside {
+		a.logger.Error("robot outside geofence", zap.Float64("clearance_m", clearance))
+		a.safety.OnGeofenceBreach()
+	}
+}
+
+// onGeofenceViolation audits the start of a clamped or rejected drive run.
+func (a *agent) onGeofenceViolation(v geofence.Violation) {
+	a.logger.Warn("geofence limited drive command",
+		zap.String("action", v.Action),
+		zap.Float64("clearance_m", v.Clearance))
+
+	if a.audit == nil {
+		return
+	}
+	a.audit.Publish(audit.Event{
+		EventType: audit.EventGeofenceViolation,
+		SessionID: a.currentSessionID(),
+		Timestamp: time.Now().UTC(),
+		Metadata: map[string]string{
+			"action":      v.Action,
+			"x":           strconv.FormatFloat(v.Pose.X, 'f', 3, 64),
+			"y":           strconv.FormatFloat(v.Pose.Y, 'f', 3, 64),
+			"clearance_m": strconv.FormatFloat(v.Clearance, 'f', 3, 64),
+		},
+	})
+}
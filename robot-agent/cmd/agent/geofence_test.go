@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/geofence"
+	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
+)
+
+// TestCheckGeofence_OutsideTriggersSafeStop verifies a breach raises the
+// geofence safety trigger.
+func TestCheckGeofence_OutsideTriggersSafeStop(t *testing.T) {
+	odom := geofence.NewOdometry(geofence.Pose{X: 20, Y: 20}, 1, 1)
+	fence, err := geofence.New(geofence.Config{
+		Allowed: [][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+	}, odom)
+	if err != nil {
+		t.Fatalf("geofence.New: %v", err)
+	}
+
+	var triggered safety.Trigger
+	a := &agent{logger: zap.NewNop(), geofence: fence}
+	a.safety = safety.NewMonitor(time.Second, 5, 0, func(trigger safety.Trigger) safety.TransitionResult {
+		triggered = trigger
+		return safety.TransitionResult{Trigger: trigger}
+	})
+
+	a.checkGeofence()
+
+	if triggered != safety.TriggerGeofence {
+		t.Errorf("expected geofence trigger, got %q", triggered)
+	}
+}
+
+// TestCheckGeofence_Disabled verifies the check is a no-op without a fence.
+func TestCheckGeofence_Disabled(t *testing.T) {
+	a := &agent{logger: zap.NewNop()}
+	a.checkGeofence() // Should not panic
+}
@@ -10,6 +10,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
 	"github.com/datapilot/chainkvm/robot-agent/internal/metrics"
 	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
 	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
@@ -18,18 +19,30 @@ import (
 // errHardwareUnavailable indicates the hardware stop could not be executed.
 var errHardwareUnavailable = errors.New("hardware stop unavailable: handler not initialized")
 
+// defaultByeReason is audited when a bye carries no reason.
+const defaultByeReason = "hangup"
+
 // OnOffer handles incoming SDP offer from console.
 func (a *agent) OnOffer(sessionID, token string, sdpData []byte) {
+	offerReceived := time.Now()
+	a.logger.Info("received offer", zap.String("session_id", sessionID))
+
+	// A second offer must never replace the live peer connection
+	if owner := a.sessionOwner(); owner == sessionID {
+		a.renegotiate(sessionID, token, sdpData)
+		return
+	} else if owner != "" && !a.resolveConcurrentOffer(owner, sessionID, token) {
+		return
+	}
+
 	// Start session setup timing
 	a.currentSessionSetup = &metrics.SessionSetupTimestamps{
 		SessionID:     sessionID,
-		OfferReceived: time.Now(),
+		OfferReceived: offerReceived,
 	}
 
-	a.logger.Info("received offer", zap.String("session_id", sessionID))
-
 	// Validate capability token before establishing connection
-	info, err := a.sessionMgr.ValidateToken(sessionID, token)
+	info, err := a.beginSession(sessionID, token)
 	if err != nil {
 		a.logger.Error("token validation failed",
 			zap.String("session_id", sessionID),
@@ -37,9 +50,12 @@ func (a *agent) OnOffer(sessionID, token string, sdpData []byte) {
 		return
 	}
 	a.recordSessionSetupTimestamp(func(ts *metrics.SessionSetupTimestamps) { ts.TokenValidated = time.Now() })
+	a.setSessionOwner(sessionID)
+	a.signaling.ExpectAnswer(sessionID)
 
 	if err := a.transport.CreatePeerConnection(); err != nil {
 		a.logger.Error("failed to create peer connection", zap.Error(err))
+		a.abandonOffer(sessionID)
 		return
 	}
 	a.recordSessionSetupTimestamp(func(ts *metrics.SessionSetupTimestamps) { ts.PeerConnectionCreated = time.Now() })
@@ -55,35 +71,24 @@ func (a *agent) OnOffer(sessionID, token string, sdpData []byte) {
 	a.transport.SetStateCallback(func(state webrtc.PeerConnectionState) {
 		switch state {
 		case webrtc.PeerConnectionStateConnected:
-			a.recordSessionSetupTimestamp(func(ts *metrics.SessionSetupTimestamps) {
-				ts.ConnectionEstablished = time.Now()
-			})
-			if err := a.sessionMgr.Activate(info); err != nil {
-				a.logger.Error("session activation failed",
-					zap.String("session_id", info.SessionID),
-					zap.Error(err))
-				return
-			}
-			a.recordSessionSetupTimestamp(func(ts *metrics.SessionSetupTimestamps) {
-				ts.SessionActivated = time.Now()
-				ts.DataChannelReady = time.Now()
-			})
-			a.completeSessionSetupMeasurement()
-			a.safety.Reset()
-			a.startControlRTTMeasurement()
-		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed:
-			a.sessionMgr.Terminate()
+			a.onSessionConnected(info)
+		case webrtc.PeerConnectionStateDisconnected, webrtc.PeerConnectionStateFailed:
+			a.onTransportLost(info)
+		case webrtc.PeerConnectionStateClosed:
+			a.onSessionDisconnected(info)
 		}
 	})
 
 	answer, err := a.transport.HandleOffer(sdpData)
 	if err != nil {
 		a.logger.Error("failed to handle offer", zap.Error(err))
+		a.abandonOffer(sessionID)
 		return
 	}
 
 	if err := a.signaling.SendAnswer(sessionID, answer); err != nil {
 		a.logger.Error("failed to send answer", zap.Error(err))
+		a.abandonOffer(sessionID)
 		return
 	}
 	a.recordSessionSetupTimestamp(func(ts *metrics.SessionSetupTimestamps) { ts.AnswerSent = time.Now() })
@@ -96,15 +101,60 @@ func (a *agent) OnAnswer(sessionID string, sdp []byte) {
 
 // OnICE handles incoming ICE candidate.
 func (a *agent) OnICE(sessionID string, candidate []byte) {
+	if owner := a.sessionOwner(); owner != sessionID {
+		a.logger.Warn("ignoring ICE candidate for another session",
+			zap.String("session_id", sessionID),
+			zap.String("active_session_id", owner))
+		return
+	}
 	if err := a.transport.AddICECandidate(candidate); err != nil {
 		a.logger.Warn("failed to add ICE candidate", zap.Error(err))
 	}
 }
 
-// OnBye handles session termination from gateway.
-func (a *agent) OnBye(sessionID string) {
-	a.logger.Info("received bye", zap.String("session_id", sessionID))
-	a.safety.OnRevoked()
+// OnBye handles a normal session end from the console, relayed by the
+// gateway. It tears the session down like OnRevoked but audits it as
+// SESSION_ENDED; the next offer starts a fresh session.
+func (a *agent) OnBye(sessionID, reason string) {
+	if current := a.currentSessionID(); current != "" && current != sessionID {
+		a.logger.Warn("ignoring bye for inactive session",
+			zap.String("session_id", sessionID),
+			zap.String("active_session_id", current))
+		return
+	}
+	if reason == "" {
+		reason = defaultByeReason
+	}
+
+	a.logger.Info("session ended by peer",
+		zap.String("session_id", sessionID),
+		zap.String("reason", reason))
+	a.endSession(sessionID, reason)
+}
+
+// endSession tears a session down without treating it as a security
+// event, and audits it as SESSION_ENDED with reason.
+func (a *agent) endSession(sessionID, reason string) {
+	a.cancelICEGrace()
+	a.stopControlRTTMeasurement()
+
+	// Close WebRTC transport to stop media and control
+	if a.transport != nil {
+		a.transport.Close()
+	}
+	a.releaseSessionOwner(sessionID)
+
+	a.sessionMgr.Terminate()
+	a.safety.OnSessionEnded()
+
+	if a.audit != nil {
+		a.audit.Publish(audit.Event{
+			EventType: audit.EventSessionEnded,
+			SessionID: sessionID,
+			Timestamp: time.Now().UTC(),
+			Metadata:  map[string]string{"reason": reason},
+		})
+	}
 }
 
 // OnRevoked handles session revocation from gateway.
@@ -121,11 +171,13 @@ func (a *agent) OnRevoked(sessionID, reason string) {
 		zap.String("reason", reason))
 
 	ts.HandlerStarted = time.Now()
+	a.cancelICEGrace()
 
 	// Close WebRTC transport to stop media and control
 	if a.transport != nil {
 		a.transport.Close()
 	}
+	a.releaseSessionOwner(sessionID)
 	ts.TransportClosed = time.Now()
 
 	// Terminate session (invalidates token cache)
@@ -151,6 +203,10 @@ func (a *agent) onDataMessage(data []byte) {
 	// Check if this is a pong message for RTT measurement
 	var base protocol.BaseMessage
 	if err := json.Unmarshal(data, &base); err == nil {
+		if base.Type == protocol.TypeAuth {
+			a.handleAuth(data)
+			return
+		}
 		if base.Type == protocol.TypePong {
 			var pong protocol.PongMessage
 			if err := json.Unmarshal(data, &pong); err == nil {
@@ -174,6 +230,9 @@ func (a *agent) onDataMessage(data []byte) {
 	ack, err := a.handler.HandleMessage(data)
 	if err != nil {
 		a.logger.Debug("message handling error", zap.Error(err))
+		if msg := controlErrorMessage(err, base.Type); msg != nil {
+			a.sendControlError(msg)
+		}
 		return
 	}
 
@@ -189,15 +248,49 @@ func (a *agent) onDataMessage(data []byte) {
 	}
 }
 
+// controlErrorMessage returns the error reply for a refused control
+// command the console must hear about, or nil if none is sent.
+func controlErrorMessage(err error, refType protocol.MessageType) *protocol.ErrorMessage {
+	switch {
+	case errors.Is(err, control.ErrRateLimited):
+		return &protocol.ErrorMessage{
+			Type:    protocol.TypeError,
+			Code:    protocol.ErrRateLimited,
+			Reason:  err.Error(),
+			RefType: refType,
+		}
+	default:
+		return nil
+	}
+}
+
+// sendControlError sends an error reply to the console.
+func (a *agent) sendControlError(msg *protocol.ErrorMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		a.logger.Error("failed to marshal error reply", zap.Error(err))
+		return
+	}
+	if err := a.transport.SendData(data); err != nil {
+		a.logger.Warn("failed to send error reply", zap.String("code", msg.Code), zap.Error(err))
+	}
+}
+
 func (a *agent) onSafeStop(trigger safety.Trigger) safety.TransitionResult {
 	start := time.Now()
 
+	if trigger == safety.TriggerShutdown {
+		// shutdown stops the hardware itself and reports the outcome once
+		return safety.TransitionResult{Trigger: trigger, Timestamp: time.Now()}
+	}
+
 	a.logger.Warn("safe-stop triggered",
 		zap.String("trigger", string(trigger)),
 		zap.Int("priority", int(trigger.Priority())),
 		zap.Bool("recoverable", trigger.IsRecoverable()))
 
 	haltErr := a.executeHardwareStop(trigger)
+	a.releaseInputs(string(trigger))
 	a.recordRevocationTimestamp(func(ts *metrics.RevocationTimestamps) { ts.HardwareStopIssued = time.Now() })
 
 	a.publishAuditEvent(trigger)
@@ -225,6 +318,12 @@ func (a *agent) executeHardwareStop(trigger safety.Trigger) error {
 		return errHardwareUnavailable
 	}
 
+	// Control loss is recoverable: decelerate smoothly instead of slamming to a halt
+	if trigger == safety.TriggerControlLoss && a.handler.RampToStop() {
+		return nil
+	}
+
+	a.handler.HaltMotion()
 	if err := a.handler.RobotAPI().EStop(); err != nil {
 		a.logger.Error("CRITICAL: hardware stop failed - robot may still be moving",
 			zap.Error(err),
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+)
+
+func drainAuditForTest(t *testing.T, a *agent) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := a.audit.Drain(ctx); err != nil {
+		t.Fatalf("drain audit: %v", err)
+	}
+}
+
+func TestOnBye_EndsSessionCleanly(t *testing.T) {
+	handler := control.NewHandler(control.NewStubRobotAPI(zap.NewNop()), nil, nil, nil, time.Second)
+	a, client := newSessionAgent(t, handler)
+
+	a.OnBye("ses_1", "operator_hangup")
+	drainAuditForTest(t, a)
+
+	if got := a.safety.LastTransition().Trigger; got != safety.TriggerSessionEnded {
+		t.Errorf("expected session_ended trigger, got %s", got)
+	}
+
+	if a.sessionMgr.State() != session.StateTerminated {
+		t.Errorf("expected terminated session, got %s", a.sessionMgr.State())
+	}
+
+	events := client.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(events))
+	}
+	e := events[0]
+	if e.EventType != audit.EventSessionEnded || e.SessionID != "ses_1" || e.Metadata["reason"] != "operator_hangup" {
+		t.Errorf("unexpected audit event: %+v", e)
+	}
+}
+
+func TestOnBye_DefaultReason(t *testing.T) {
+	a, client := newSessionAgent(t, nil)
+
+	a.OnBye("ses_1", "")
+	drainAuditForTest(t, a)
+
+	events := client.Events()
+	if len(events) != 1 || events[0].Metadata["reason"] != defaultByeReason {
+		t.Errorf("expected default reason, got %+v", events)
+	}
+}
+
+func TestOnBye_IgnoresOtherSession(t *testing.T) {
+	a, client := newSessionAgent(t, nil)
+
+	a.OnBye("ses_stale", "operator_hangup")
+	drainAuditForTest(t, a)
+
+	if !a.sessionMgr.IsActive() {
+		t.Error("bye for another session must not end the active one")
+	}
+	if len(client.Events()) != 0 {
+		t.Errorf("expected no audit events, got %+v", client.Events())
+	}
+}
@@ -9,15 +9,28 @@ import (
 	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
 	"github.com/datapilot/chainkvm/robot-agent/internal/metrics"
 	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+	"github.com/datapilot/chainkvm/robot-agent/internal/transport"
 	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
 )
 
 func (a *agent) publishAuditEvent(trigger safety.Trigger) {
-	if trigger != safety.TriggerInvalidCmds || a.audit == nil {
+	if a.audit == nil {
 		return
 	}
+
+	var eventType audit.EventType
+	switch trigger {
+	case safety.TriggerInvalidCmds:
+		eventType = audit.EventInvalidCommandThreshold
+	case safety.TriggerGeofence:
+		eventType = audit.EventGeofenceViolation
+	default:
+		return
+	}
+
 	a.audit.Publish(audit.Event{
-		EventType: audit.EventInvalidCommandThreshold,
+		EventType: eventType,
 		SessionID: a.currentSessionID(),
 		Timestamp: time.Now().UTC(),
 		Metadata:  map[string]string{"trigger": string(trigger)},
@@ -25,17 +38,21 @@ func (a *agent) publishAuditEvent(trigger safety.Trigger) {
 }
 
 func (a *agent) sendStateNotification(haltErr error) {
-	if a.transport == nil {
-		a.logger.Warn("cannot send state notification: transport is nil")
-		return
-	}
-
 	robotState := protocol.RobotStateSafeStop
 	sessionState := "safe_stop"
 	if haltErr != nil {
 		robotState = protocol.RobotStateSafeStopFailed
 		sessionState = "safe_stop_failed"
 	}
+	a.sendState(robotState, sessionState)
+}
+
+// sendState sends a state message to the console.
+func (a *agent) sendState(robotState, sessionState string) {
+	if a.transport == nil {
+		a.logger.Warn("cannot send state notification: transport is nil")
+		return
+	}
 
 	stateMsg := protocol.StateMessage{
 		Type:         protocol.TypeState,
@@ -49,8 +66,30 @@ func (a *agent) sendStateNotification(haltErr error) {
 		return
 	}
 	if err := a.transport.SendData(data); err != nil {
-		a.logger.Warn("failed to send safe-stop state", zap.Error(err))
+		a.logger.Warn("failed to send state", zap.String("session_state", sessionState), zap.Error(err))
+	}
+}
+
+// sessionSpeedLimit returns the most restrictive drive speed fraction from
+// the configured default, scope-based limits and the token's max_speed claim.
+func (a *agent) sessionSpeedLimit(info *session.Info) float64 {
+	limit := 1.0
+	if a.cfg != nil {
+		if a.cfg.DriveMaxSpeed > 0 {
+			limit = min(limit, a.cfg.DriveMaxSpeed)
+		}
+		if info != nil {
+			for _, scope := range info.Scope {
+				if l, ok := a.cfg.DriveScopeSpeedLimits[scope]; ok && l > 0 {
+					limit = min(limit, l)
+				}
+			}
+		}
+	}
+	if info != nil && info.MaxSpeed > 0 {
+		limit = min(limit, info.MaxSpeed)
 	}
+	return limit
 }
 
 func (a *agent) currentSessionID() string {
@@ -107,63 +146,139 @@ func (a *agent) ControlRTTMetrics() *metrics.ControlRTTCollector {
 	return a.controlRTTMetrics
 }
 
+// controlSequenceStats adapts handler ordering stats for the RTT report.
+func (a *agent) controlSequenceStats() metrics.ControlSequenceStats {
+	if a.handler == nil {
+		return metrics.ControlSequenceStats{}
+	}
+	s := a.handler.SequenceStats()
+	return metrics.ControlSequenceStats{
+		Applied:      s.Applied,
+		Duplicates:   s.Duplicates,
+		Reordered:    s.Reordered,
+		Gaps:         s.Gaps,
+		LateReleases: s.LateReleases,
+	}
+}
+
+// WebRTCStatsMetrics returns the WebRTC stats collector.
+func (a *agent) WebRTCStatsMetrics() *metrics.WebRTCStatsCollector {
+	return a.webrtcStatsMetrics
+}
+
+// webrtcStatsSnapshot adapts transport stats for the WebRTC stats collector.
+func (a *agent) webrtcStatsSnapshot() (metrics.WebRTCStatsSnapshot, error) {
+	if a.transport == nil {
+		return metrics.WebRTCStatsSnapshot{}, transport.ErrNoPeerConnection
+	}
+	s, err := a.transport.Stats()
+	if err != nil {
+		return metrics.WebRTCStatsSnapshot{}, err
+	}
+	return metrics.WebRTCStatsSnapshot{
+		Timestamp:          s.Timestamp,
+		BytesSent:          s.BytesSent,
+		PacketsSent:        s.PacketsSent,
+		FramesSent:         s.FramesSent,
+		FractionLost:       s.FractionLost,
+		Jitter:             s.Jitter,
+		NACKCount:          s.NACKCount,
+		PLICount:           s.PLICount,
+		RoundTripTime:      s.RoundTripTime,
+		SCTPBufferedAmount: s.SCTPBufferedAmount,
+	}, nil
+}
+
 func (a *agent) startControlRTTMeasurement() {
 	if a.controlRTTMetrics == nil {
 		return
 	}
 
-	a.pingTicker = time.NewTicker(a.pingInterval)
-	go func() {
-		consecutiveMarshalErrors := 0
-		consecutiveSendErrors := 0
-		const maxConsecutiveErrors = 3
+	a.pingMu.Lock()
+	a.stopPingsLocked()
+	ticker := time.NewTicker(a.pingInterval)
+	stop := make(chan struct{})
+	a.pingTicker, a.pingStop = ticker, stop
+	a.pingMu.Unlock()
 
-		for range a.pingTicker.C {
-			if a.transport == nil || a.sessionMgr.State() != "active" {
-				continue
-			}
+	go a.runControlRTTPings(ticker, stop)
+
+	a.logger.Debug("control RTT measurement started", zap.Duration("interval", a.pingInterval))
+}
+
+// runControlRTTPings sends pings until stop is closed or too many
+// consecutive errors occur.
+func (a *agent) runControlRTTPings(ticker *time.Ticker, stop chan struct{}) {
+	consecutiveMarshalErrors := 0
+	consecutiveSendErrors := 0
+	const maxConsecutiveErrors = 3
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
 
-			ping := a.controlRTTMetrics.GeneratePing()
-			data, err := json.Marshal(ping)
-			if err != nil {
-				consecutiveMarshalErrors++
-				a.logger.Error("failed to marshal ping",
-					zap.Error(err),
+		if a.transport == nil || a.sessionMgr.State() != "active" {
+			continue
+		}
+
+		ping := a.controlRTTMetrics.GeneratePing()
+		data, err := json.Marshal(ping)
+		if err != nil {
+			consecutiveMarshalErrors++
+			a.logger.Error("failed to marshal ping",
+				zap.Error(err),
+				zap.Int("consecutive_errors", consecutiveMarshalErrors))
+			if consecutiveMarshalErrors >= maxConsecutiveErrors {
+				a.logger.Error("RTT measurement circuit breaker triggered: marshal failures",
 					zap.Int("consecutive_errors", consecutiveMarshalErrors))
-				if consecutiveMarshalErrors >= maxConsecutiveErrors {
-					a.logger.Error("RTT measurement circuit breaker triggered: marshal failures",
-						zap.Int("consecutive_errors", consecutiveMarshalErrors))
-					a.stopControlRTTMeasurement()
-					return
-				}
-				continue
+				a.stopPings(stop)
+				return
 			}
-			consecutiveMarshalErrors = 0
+			continue
+		}
+		consecutiveMarshalErrors = 0
 
-			if err := a.transport.SendData(data); err != nil {
-				consecutiveSendErrors++
-				a.logger.Warn("failed to send ping",
-					zap.Error(err),
+		if err := a.transport.SendData(data); err != nil {
+			consecutiveSendErrors++
+			a.logger.Warn("failed to send ping",
+				zap.Error(err),
+				zap.Int("consecutive_errors", consecutiveSendErrors))
+			if consecutiveSendErrors >= maxConsecutiveErrors {
+				a.logger.Error("RTT measurement circuit breaker triggered: send failures",
 					zap.Int("consecutive_errors", consecutiveSendErrors))
-				if consecutiveSendErrors >= maxConsecutiveErrors {
-					a.logger.Error("RTT measurement circuit breaker triggered: send failures",
-						zap.Int("consecutive_errors", consecutiveSendErrors))
-					a.stopControlRTTMeasurement()
-					return
-				}
-			} else {
-				consecutiveSendErrors = 0
+				a.stopPings(stop)
+				return
 			}
+		} else {
+			consecutiveSendErrors = 0
 		}
-	}()
-
-	a.logger.Debug("control RTT measurement started", zap.Duration("interval", a.pingInterval))
+	}
 }
 
 func (a *agent) stopControlRTTMeasurement() {
-	if a.pingTicker != nil {
-		a.pingTicker.Stop()
-		a.pingTicker = nil
-		a.logger.Debug("control RTT measurement stopped")
+	a.pingMu.Lock()
+	defer a.pingMu.Unlock()
+	a.stopPingsLocked()
+}
+
+// stopPings stops the pinger owning stop, leaving a newer session's alone.
+func (a *agent) stopPings(stop chan struct{}) {
+	a.pingMu.Lock()
+	defer a.pingMu.Unlock()
+	if a.pingStop == stop {
+		a.stopPingsLocked()
+	}
+}
+
+func (a *agent) stopPingsLocked() {
+	if a.pingTicker == nil {
+		return
 	}
+	a.pingTicker.Stop()
+	close(a.pingStop)
+	a.pingTicker, a.pingStop = nil, nil
+	a.logger.Debug("control RTT measurement stopped")
 }
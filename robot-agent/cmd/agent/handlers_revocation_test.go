@@ -6,6 +6,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
 	"github.com/datapilot/chainkvm/robot-agent/internal/metrics"
 	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
 	"github.com/datapilot/chainkvm/robot-agent/internal/session"
@@ -156,3 +157,36 @@ func TestRevocationMeasurement_MetricsAccessor(t *testing.T) {
 		t.Error("RevocationMetrics() should return the collector")
 	}
 }
+
+func TestRevocation_EscalatesFromControlLossRamp(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+	l.revocationMetrics = metrics.NewRevocationCollector(10)
+	l.handler.SetMotionShaper(control.NewMotionShaper(control.ShaperConfig{
+		MaxLinearAccel:  1,
+		MaxAngularAccel: 1,
+		TickInterval:    10 * time.Millisecond,
+	}))
+
+	l.connect(t, "ses-a")
+	if err := l.drive(1); err != nil {
+		t.Fatalf("drive: %v", err)
+	}
+
+	// Control loss only ramps down
+	l.safety.OnTransportLost()
+	if n := l.robot.estops.Load(); n != 0 {
+		t.Fatalf("expected no e-stop during control-loss ramp, got %d", n)
+	}
+
+	l.OnRevoked("ses-a", "admin")
+	if n := l.robot.estops.Load(); n != 1 {
+		t.Errorf("expected revocation to e-stop during the ramp, got %d e-stops", n)
+	}
+	if got := l.safety.LastTransition().Trigger; got != safety.TriggerRevoked {
+		t.Errorf("expected revoked transition, got %s", got)
+	}
+	if l.revocationMetrics.Count() != 1 {
+		t.Errorf("expected revocation measurement completed, got %d", l.revocationMetrics.Count())
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+)
+
+// initKVMSafety configures the chord deny list, held-input release and
+// text typing limits.
+func (a *agent) initKVMSafety() {
+	policy, err := control.NewKeyPolicy(a.cfg.KVMDeniedChords)
+	if err != nil {
+		a.logger.Fatal("invalid KVM chord deny list", zap.Error(err))
+	}
+	a.handler.SetKeyPolicy(policy)
+	a.handler.SetChordBlockedCallback(a.onChordBlocked)
+	a.handler.SetInputIdleTimeout(time.Duration(a.cfg.KVMInputIdleTimeoutMS) * time.Millisecond)
+	a.handler.SetTypingRate(a.cfg.KVMTypeRateHz)
+	a.handler.SetMaxTypeLength(a.cfg.KVMTypeMaxLength)
+	a.handler.SetTextTypedCallback(a.onTextTyped)
+}
+
+// onTextTyped audits a kvm_type command by hash only; the text itself is
+// never logged.
+func (a *agent) onTextTyped(sha256Hex string, length int, layout string) {
+	if a.audit == nil {
+		return
+	}
+	a.audit.Publish(audit.Event{
+		EventType: audit.EventKVMTextInput,
+		SessionID: a.currentSessionID(),
+		Timestamp: time.Now().UTC(),
+		Metadata: map[string]string{
+			"sha256": sha256Hex,
+			"length": strconv.Itoa(length),
+			"layout": layout,
+		},
+	})
+}
+
+// onChordBlocked audits a blocked key chord.
+func (a *agent) onChordBlocked(chord control.Chord) {
+	a.logger.Warn("blocked denied key chord", zap.String("chord", chord.String()))
+
+	if a.audit == nil {
+		return
+	}
+	a.audit.Publish(audit.Event{
+		EventType: audit.EventKVMChordBlocked,
+		SessionID: a.currentSessionID(),
+		Timestamp: time.Now().UTC(),
+		Metadata:  map[string]string{"chord": chord.String()},
+	})
+}
+
+// releaseInputs releases any keys or buttons held on the target host.
+func (a *agent) releaseInputs(reason string) {
+	if a.handler == nil {
+		return
+	}
+
+	keys, buttons := a.handler.HeldInputs()
+	if len(keys) == 0 && buttons == 0 {
+		return
+	}
+	if err := a.handler.ReleaseInputs(); err != nil {
+		a.logger.Error("failed to release held KVM inputs", zap.String("reason", reason), zap.Error(err))
+		return
+	}
+	a.logger.Info("released held KVM inputs",
+		zap.String("reason", reason),
+		zap.Strings("keys", keys),
+		zap.Int("buttons", buttons))
+}
+
+// releaseIdleInputs releases held inputs after the idle timeout.
+func (a *agent) releaseIdleInputs() {
+	if a.handler == nil {
+		return
+	}
+
+	keys, buttons := a.handler.HeldInputs()
+	released, err := a.handler.ReleaseIdleInputs()
+	if err != nil {
+		a.logger.Error("failed to release idle KVM inputs", zap.Error(err))
+		return
+	}
+	if released {
+		a.logger.Warn("released KVM inputs held without activity",
+			zap.Strings("keys", keys),
+			zap.Int("buttons", buttons))
+	}
+}
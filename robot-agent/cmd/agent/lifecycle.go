@@ -0,0 +1,141 @@
+package main
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/metrics"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+)
+
+// beginSession validates an offer's token, then clears whatever the
+// previous session left behind so the agent can serve sessions back to
+// back without a restart.
+func (a *agent) beginSession(sessionID, token string) (*session.Info, error) {
+	// Validate first: an offer with a bad token must not clear the
+	// safe-stop latch or tear down the live transport
+	info, err := a.sessionMgr.ValidateNewSession(sessionID, token)
+	if err != nil {
+		return nil, err
+	}
+	if !a.sessionMgr.IsActive() {
+		a.resetSessionState()
+	}
+	return info, nil
+}
+
+// resetSessionState returns every per-session component to its initial
+// state and drops any leftover peer connection.
+func (a *agent) resetSessionState() {
+	a.cancelICEGrace()
+	a.stopControlRTTMeasurement()
+	if a.transport != nil {
+		if err := a.transport.Close(); err != nil {
+			a.logger.Warn("error closing previous peer connection", zap.Error(err))
+		}
+	}
+
+	a.sessionMgr.Reset()
+	a.safety.Reset()
+	if a.handler != nil {
+		a.handler.ResetSession()
+	}
+	if a.controlRTTMetrics != nil {
+		a.controlRTTMetrics.Reset()
+	}
+	if a.webrtcStatsMetrics != nil {
+		a.webrtcStatsMetrics.Reset()
+	}
+	if a.telemetry != nil {
+		a.telemetry.Reset()
+	}
+}
+
+// onSessionConnected activates the session once the peer connection is up.
+func (a *agent) onSessionConnected(info *session.Info) {
+	if a.sessionMgr.IsActive() && a.currentSessionID() == info.SessionID {
+		a.onTransportRecovered(info)
+		return
+	}
+
+	a.recordSessionSetupTimestamp(func(ts *metrics.SessionSetupTimestamps) {
+		ts.ConnectionEstablished = time.Now()
+	})
+	if err := a.sessionMgr.Activate(info); err != nil {
+		a.logger.Error("session activation failed",
+			zap.String("session_id", info.SessionID),
+			zap.Error(err))
+		return
+	}
+	pairType := a.selectedCandidatePairType(info.SessionID)
+	a.recordSessionSetupTimestamp(func(ts *metrics.SessionSetupTimestamps) {
+		ts.SessionActivated = time.Now()
+		ts.DataChannelReady = time.Now()
+		ts.CandidatePairType = pairType
+	})
+	a.completeSessionSetupMeasurement()
+	a.publishSessionStarted(info, pairType)
+	a.safety.Reset()
+	a.handler.ResetSession()
+	a.handler.SetSpeedLimit(a.sessionSpeedLimit(info))
+	if a.telemetry != nil {
+		a.telemetry.Reset()
+	}
+	a.startControlRTTMeasurement()
+}
+
+// selectedCandidatePairType reports which ICE path the session is using,
+// or "" if it cannot be determined.
+func (a *agent) selectedCandidatePairType(sessionID string) string {
+	if a.transport == nil {
+		return ""
+	}
+	pair, err := a.transport.SelectedCandidatePair()
+	if err != nil {
+		a.logger.Warn("selected candidate pair unavailable",
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+		return ""
+	}
+	a.logger.Info("ICE candidate pair selected",
+		zap.String("session_id", sessionID),
+		zap.String("type", pair.Type()),
+		zap.String("local", pair.Local.String()),
+		zap.String("remote", pair.Remote.String()),
+		zap.String("protocol", pair.Protocol.String()))
+	return pair.Type()
+}
+
+// publishSessionStarted audits an activated session with the ICE path it
+// selected.
+func (a *agent) publishSessionStarted(info *session.Info, pairType string) {
+	if a.audit == nil {
+		return
+	}
+	metadata := map[string]string{}
+	if pairType != "" {
+		metadata["candidate_pair_type"] = pairType
+	}
+	a.audit.Publish(audit.Event{
+		EventType:   audit.EventSessionStarted,
+		SessionID:   info.SessionID,
+		OperatorDID: info.OperatorDID,
+		Timestamp:   time.Now().UTC(),
+		Metadata:    metadata,
+	})
+}
+
+// onSessionDisconnected ends the session when its peer connection closes.
+func (a *agent) onSessionDisconnected(info *session.Info) {
+	a.releaseInputs("session_ended")
+	a.releaseSessionOwner(info.SessionID)
+	// A connection closed by bye or revocation may report after the
+	// session is already torn down; leave the next session alone
+	if a.currentSessionID() == info.SessionID {
+		a.cancelICEGrace()
+		a.stopControlRTTMeasurement()
+		a.sessionMgr.Terminate()
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+	"github.com/datapilot/chainkvm/robot-agent/internal/metrics"
+	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// lifecycleAgent is an agent with real token validation and no transport.
+type lifecycleAgent struct {
+	*agent
+	priv  ed25519.PrivateKey
+	robot *recordingRobot
+}
+
+// recordingRobot counts hardware e-stops.
+type recordingRobot struct {
+	*control.StubRobotAPI
+	estops atomic.Int32
+}
+
+func (r *recordingRobot) EStop() error {
+	r.estops.Add(1)
+	return r.StubRobotAPI.EStop()
+}
+
+func newLifecycleAgent(t *testing.T) *lifecycleAgent {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	validator := session.NewTokenValidator(pub, "test-robot", 30*time.Second)
+	a := &agent{
+		logger:            zap.NewNop(),
+		sessionMgr:        session.NewManager("test-robot", validator),
+		controlRTTMetrics: metrics.NewControlRTTCollector(100),
+		pingInterval:      time.Hour,
+	}
+	a.safety = safety.NewMonitor(time.Second, 3, 0, a.onSafeStop)
+	robot := &recordingRobot{StubRobotAPI: control.NewStubRobotAPI(zap.NewNop())}
+	a.handler = control.NewHandler(robot, a.safety, a.sessionMgr, a.sessionMgr, time.Second)
+	a.handler.SetRateLimiter(control.NewRateLimiterWithConfig(control.RateLimiterConfig{DriveHz: 1, KVMHz: 1, BurstSize: 1}))
+	return &lifecycleAgent{agent: a, priv: priv, robot: robot}
+}
+
+func (l *lifecycleAgent) token(t *testing.T, sessionID string) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(&jwt.SigningMethodEd25519{}, jwt.MapClaims{
+		"sub":   "did:key:operator",
+		"aud":   "test-robot",
+		"sid":   sessionID,
+		"scope": []any{"teleop:view", "teleop:control", "teleop:estop"},
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	signed, err := tok.SignedString(l.priv)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+// connect runs the offer and connected steps of a session.
+func (l *lifecycleAgent) connect(t *testing.T, sessionID string) {
+	t.Helper()
+	info, err := l.beginSession(sessionID, l.token(t, sessionID))
+	if err != nil {
+		t.Fatalf("%s: begin session: %v", sessionID, err)
+	}
+	l.onSessionConnected(info)
+	if !l.sessionMgr.IsActive() {
+		t.Fatalf("%s: expected active session", sessionID)
+	}
+}
+
+func (l *lifecycleAgent) drive(seq uint64) error {
+	_, err := l.handler.HandleMessage([]byte(fmt.Sprintf(
+		`{"type":"drive","v":0.1,"w":0,"t":%d,"seq":%d}`, time.Now().UnixMilli(), seq)))
+	return err
+}
+
+func TestSessions_BackToBack(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+
+	end := []func(sid string){
+		func(sid string) { l.OnBye(sid, "") },
+		func(sid string) { l.OnRevoked(sid, "admin") },
+		func(sid string) { l.onSessionDisconnected(l.sessionMgr.Info()) },
+	}
+
+	for i, endSession := range end {
+		sid := fmt.Sprintf("ses-%d", i)
+		l.connect(t, sid)
+
+		// Per-session state starts fresh: sequence 1 and a full rate bucket
+		if err := l.drive(1); err != nil {
+			t.Fatalf("%s: first drive rejected: %v", sid, err)
+		}
+		if err := l.drive(2); err != control.ErrRateLimited {
+			t.Errorf("%s: expected rate limit on second drive, got %v", sid, err)
+		}
+
+		l.controlRTTMetrics.RecordPong(&protocol.PongMessage{Seq: l.controlRTTMetrics.GeneratePing().Seq})
+		if l.controlRTTMetrics.Count() != 1 {
+			t.Errorf("%s: expected RTT samples from this session only, got %d", sid, l.controlRTTMetrics.Count())
+		}
+
+		endSession(sid)
+		if l.sessionMgr.IsActive() {
+			t.Fatalf("%s: session still active after end", sid)
+		}
+	}
+}
+
+func TestSessions_SafetyResetAfterEStop(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+
+	l.connect(t, "ses-a")
+	l.safety.OnEStop()
+	l.OnBye("ses-a", "")
+
+	// The e-stop latch belongs to the old session
+	l.connect(t, "ses-b")
+	l.safety.OnInvalidCommand()
+	l.safety.OnInvalidCommand()
+	l.safety.OnInvalidCommand()
+	if got := l.safety.LastTransition().Trigger; got != safety.TriggerInvalidCmds {
+		t.Errorf("expected new session's safety monitor to trigger, got %s", got)
+	}
+}
+
+func TestSessions_StopsPreviousPinger(t *testing.T) {
+	l := newLifecycleAgent(t)
+
+	l.connect(t, "ses-a")
+	first := l.pingStop
+	l.onSessionDisconnected(l.sessionMgr.Info())
+
+	select {
+	case <-first:
+	default:
+		t.Error("expected previous session's pinger stopped")
+	}
+
+	l.connect(t, "ses-b")
+	if l.pingStop == nil || l.pingStop == first {
+		t.Error("expected a new pinger for the next session")
+	}
+	l.stopControlRTTMeasurement()
+}
+
+func TestSessions_AuditsSessionStarted(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+	client := &recordingAuditClient{}
+	l.audit = audit.NewPublisher("http://gateway:8080", "test-robot")
+	l.audit.SetHTTPClient(client)
+
+	l.connect(t, "ses-a")
+	drainAuditForTest(t, l.agent)
+
+	events := client.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 audit event, got %+v", events)
+	}
+	e := events[0]
+	if e.EventType != audit.EventSessionStarted || e.SessionID != "ses-a" || e.OperatorDID != "did:key:operator" {
+		t.Errorf("unexpected session start event: %+v", e)
+	}
+	// No transport, so the ICE path is unknown
+	if _, ok := e.Metadata["candidate_pair_type"]; ok {
+		t.Errorf("expected no candidate pair type, got %+v", e.Metadata)
+	}
+}
+
+func TestSessions_ForgedOfferKeepsSafeStop(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+
+	l.connect(t, "ses-a")
+	l.OnRevoked("ses-a", "admin")
+
+	if _, err := l.beginSession("ses-b", "forged"); err == nil {
+		t.Fatal("expected forged token rejected")
+	}
+	if !l.safety.IsStopped() {
+		t.Error("forged offer must not clear the safe-stop latch")
+	}
+	if got := l.sessionMgr.State(); got != session.StateTerminated {
+		t.Errorf("forged offer must not reset the session, got %s", got)
+	}
+}
+
+func TestControlErrorMessage(t *testing.T) {
+	msg := controlErrorMessage(control.ErrRateLimited, protocol.TypeDrive)
+	if msg == nil || msg.Code != protocol.ErrRateLimited || msg.RefType != protocol.TypeDrive {
+		t.Errorf("expected RATE_LIMITED reply for drive, got %+v", msg)
+	}
+	if msg := controlErrorMessage(control.ErrOutOfOrder, protocol.TypeDrive); msg != nil {
+		t.Errorf("expected no reply for superseded command, got %+v", msg)
+	}
+}
@@ -3,8 +3,10 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -13,9 +15,12 @@ import (
 	"github.com/datapilot/chainkvm/robot-agent/config"
 	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
 	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+	"github.com/datapilot/chainkvm/robot-agent/internal/geofence"
+	"github.com/datapilot/chainkvm/robot-agent/internal/identity"
 	"github.com/datapilot/chainkvm/robot-agent/internal/metrics"
 	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
 	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+	"github.com/datapilot/chainkvm/robot-agent/internal/telemetry"
 	"github.com/datapilot/chainkvm/robot-agent/internal/transport"
 )
 
@@ -31,9 +36,21 @@ func main() {
 		logger.Fatal("failed to load config", zap.Error(err))
 	}
 
-	logger.Info("starting robot agent", zap.String("robot_id", cfg.RobotID))
+	id, created, err := identity.LoadOrCreate(cfg.RobotKeyFile)
+	if err != nil {
+		logger.Fatal("failed to load robot identity", zap.Error(err))
+	}
+	if created {
+		logger.Warn("generated new robot identity; record this DID for gateway enrollment",
+			zap.String("did", id.DID()),
+			zap.String("key_file", cfg.RobotKeyFile))
+	}
 
-	agent := newAgent(cfg, logger)
+	logger.Info("starting robot agent",
+		zap.String("robot_id", cfg.RobotID),
+		zap.String("did", id.DID()))
+
+	agent := newAgent(cfg, id, logger)
 	if err := agent.run(ctx); err != nil {
 		logger.Fatal("agent failed", zap.Error(err))
 	}
@@ -41,33 +58,69 @@ func main() {
 
 // agent coordinates all Robot Agent components.
 type agent struct {
-	cfg    *config.Config
-	logger *zap.Logger
-
-	sessionMgr         *session.Manager
-	signaling          *session.SignalingClient
-	transport          *transport.WebRTC
-	safety             *safety.Monitor
-	handler            *control.Handler
+	cfg         *config.Config
+	identity    *identity.Identity
+	gatewayTLS  *config.TLSProvider
+	gatewayTURN *transport.GatewayTURN
+	logger      *zap.Logger
+
+	sessionMgr          *session.Manager
+	signaling           *session.SignalingClient
+	transport           *transport.WebRTC
+	safety              *safety.Monitor
+	handler             *control.Handler
+	rateLimiter         *control.RateLimiter
+	jwks                *session.JWKSFetcher
+	metricsServer       *http.Server
+	geofence            *geofence.Fence
+	telemetry           *telemetry.Publisher
 	audit               *audit.Publisher
 	revocationMetrics   *metrics.RevocationCollector
 	currentRevocation   *metrics.RevocationTimestamps
 	sessionSetupMetrics *metrics.SessionSetupCollector
 	currentSessionSetup *metrics.SessionSetupTimestamps
 	controlRTTMetrics   *metrics.ControlRTTCollector
+	webrtcStatsMetrics  *metrics.WebRTCStatsCollector
+	offerMu             sync.Mutex
+	offerSID            string // Session whose offer holds the transport
+	iceMu               sync.Mutex
+	iceGrace            *time.Timer // Pending session end while awaiting an ICE restart
+	iceGraceGen         uint64
+	sigLossMu           sync.Mutex
+	sigLoss             *time.Timer // Pending safe-stop while signaling is down
+	sigLossGen          uint64
+	pingMu              sync.Mutex
 	pingTicker          *time.Ticker
+	pingStop            chan struct{}
 	pingInterval        time.Duration
 }
 
-func newAgent(cfg *config.Config, logger *zap.Logger) *agent {
+// rateLimitBurst lets a few commands bunched by network jitter through
+// without exceeding the sustained rate.
+const rateLimitBurst = 5
+
+// jwksRefreshInterval is how often the gateway signing keys are refetched.
+const jwksRefreshInterval = 5 * time.Minute
+
+// gatewaySigningKeyID is the JWKS key ID capability tokens are signed with.
+const gatewaySigningKeyID = "gateway-signing-key"
+
+func newAgent(cfg *config.Config, id *identity.Identity, logger *zap.Logger) *agent {
 	return &agent{
-		cfg:    cfg,
-		logger: logger,
+		cfg:      cfg,
+		identity: id,
+		logger:   logger,
 	}
 }
 
 func (a *agent) run(ctx context.Context) error {
+	if err := a.initGatewayTLS(); err != nil {
+		return err
+	}
 	a.initComponents()
+	if err := a.startMetricsServer(); err != nil {
+		return err
+	}
 
 	go func() {
 		if err := a.signaling.Connect(ctx); err != nil {
@@ -76,6 +129,12 @@ func (a *agent) run(ctx context.Context) error {
 	}()
 
 	go a.runSafetyMonitor(ctx)
+	go a.telemetry.Run(ctx)
+	go a.runTURNRefresh(ctx)
+	go a.runJWKSRefresh(ctx)
+	if interval := time.Duration(a.cfg.WebRTCStatsIntervalMS) * time.Millisecond; interval > 0 {
+		go a.webrtcStatsMetrics.Run(ctx, interval)
+	}
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -86,7 +145,7 @@ func (a *agent) run(ctx context.Context) error {
 	case <-ctx.Done():
 		a.logger.Info("context cancelled, shutting down")
 	case <-a.signaling.Done():
-		a.logger.Info("signaling connection closed")
+		a.logger.Info("signaling client stopped")
 	}
 
 	return a.shutdown()
@@ -104,37 +163,76 @@ func (a *agent) initComponents() {
 	timeWindow := time.Duration(a.cfg.InvalidCmdTimeWindowMS) * time.Millisecond
 	a.safety = safety.NewMonitor(timeout, a.cfg.InvalidCmdThreshold, timeWindow, a.onSafeStop)
 
-	robotAPI := control.NewStubRobotAPI(a.logger)
+	backend := control.NewStubRobotAPI(a.logger)
+	robotAPI := a.initGeofence(backend)
 	staleThreshold := 200 * time.Millisecond
 	a.handler = control.NewHandler(robotAPI, a.safety, a.sessionMgr, a.sessionMgr, staleThreshold)
-
-	iceConfig := transport.ICEConfig{
-		STUNServers: a.cfg.STUNServers,
-		TURNServers: a.cfg.TURNServers,
+	a.handler.SetMotionShaper(control.NewMotionShaper(control.ShaperConfig{
+		MaxLinearAccel:  a.cfg.DriveMaxLinearAccel,
+		MaxAngularAccel: a.cfg.DriveMaxAngularAccel,
+		MaxLinearJerk:   a.cfg.DriveMaxLinearJerk,
+		MaxAngularJerk:  a.cfg.DriveMaxAngularJerk,
+		Deadband:        a.cfg.DriveDeadband,
+		MaxSpeed:        a.cfg.DriveMaxSpeed,
+		TickInterval:    time.Duration(a.cfg.DriveShaperTickMS) * time.Millisecond,
+	}))
+	a.handler.SetDeadmanWindow(time.Duration(a.cfg.DriveDeadmanWindowMS) * time.Millisecond)
+	a.rateLimiter = control.NewRateLimiterWithConfig(control.RateLimiterConfig{
+		DriveHz:   a.cfg.RateLimitDriveHz,
+		KVMHz:     a.cfg.RateLimitKVMHz,
+		BurstSize: rateLimitBurst,
+	})
+	a.handler.SetRateLimiter(a.rateLimiter)
+	if a.geofence != nil {
+		a.handler.AddDriveFilter(a.geofence)
 	}
-	a.transport = transport.NewWebRTC(iceConfig, a.logger)
+	a.initProximity(backend)
+	a.initCapabilities(backend)
+	a.initKVMSafety()
+
+	a.transport = transport.NewWebRTC(a.iceConfig(), a.logger)
 	a.signaling = session.NewSignalingClient(a.cfg.GatewayWSURL, a.cfg.RobotID, a.logger)
 	a.signaling.SetHandler(a)
+	a.signaling.SetIdentity(a.identity)
+	if d := a.gatewayDialer(); d != nil {
+		a.signaling.SetDialer(d)
+	}
+	a.signaling.SetActiveSessionFunc(a.sessionOwner)
+	a.signaling.SetConnectionCallback(a.onSignalingConnection)
+	a.signaling.SetKeepaliveInterval(time.Duration(a.cfg.SignalingKeepaliveMS) * time.Millisecond)
+	a.signaling.SetICEBatching(a.cfg.SignalingICEBatching)
 
 	// Initialize audit publisher
 	a.audit = audit.NewPublisher(a.cfg.GatewayHTTPURL, a.cfg.RobotID)
+	if c := a.gatewayHTTPClient(auditHTTPTimeout); c != nil {
+		a.audit.SetHTTPClient(c)
+	}
 
 	// Initialize metrics collectors
 	a.revocationMetrics = metrics.NewRevocationCollector(100)
 	a.sessionSetupMetrics = metrics.NewSessionSetupCollector(100)
 	a.controlRTTMetrics = metrics.NewControlRTTCollector(1000)
+	a.controlRTTMetrics.SetSequenceStatsSource(a.controlSequenceStats)
+	a.handler.SetClockOffsetSource(a.controlRTTMetrics.ClockOffset())
+	a.webrtcStatsMetrics = metrics.NewWebRTCStatsCollector(1000)
+	a.webrtcStatsMetrics.SetSource(a.webrtcStatsSnapshot)
 	a.pingInterval = 1 * time.Second
 
+	a.initTelemetry(backend)
+
 	a.logger.Info("components initialized")
 }
 
 func (a *agent) initTokenValidator() *session.TokenValidator {
-	jwksFetcher := session.NewJWKSFetcher(a.cfg.GatewayJWKSURL, 5*time.Minute)
-	if err := jwksFetcher.Refresh(); err != nil {
+	a.jwks = session.NewJWKSFetcher(a.cfg.GatewayJWKSURL, jwksRefreshInterval)
+	if c := a.gatewayHTTPClient(jwksHTTPTimeout); c != nil {
+		a.jwks.SetHTTPClient(c)
+	}
+	if err := a.jwks.Refresh(); err != nil {
 		a.logger.Warn("initial JWKS fetch failed", zap.Error(err))
 	}
 
-	pub, err := jwksFetcher.GetPublicKey("gateway-signing-key")
+	pub, err := a.jwks.GetPublicKey(gatewaySigningKeyID)
 	if err != nil {
 		a.logger.Warn("token validator not initialized", zap.Error(err))
 		return nil
@@ -143,8 +241,14 @@ func (a *agent) initTokenValidator() *session.TokenValidator {
 	return session.NewTokenValidator(pub, a.cfg.RobotID, 30*time.Second)
 }
 
-func (a *agent) runSafetyMonitor(ctx context.Context) {
-	ticker := time.NewTicker(100 * time.Millisecond)
+// runJWKSRefresh refetches the gateway signing keys periodically, so a key
+// missing at startup or rotated since is picked up, and readiness reflects
+// whether the gateway's keys are still reachable.
+func (a *agent) runJWKSRefresh(ctx context.Context) {
+	if a.jwks == nil {
+		return
+	}
+	ticker := time.NewTicker(jwksRefreshInterval)
 	defer ticker.Stop()
 
 	for {
@@ -152,27 +256,44 @@ func (a *agent) runSafetyMonitor(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if a.sessionMgr.State() == session.StateActive {
-				a.safety.CheckControlLoss()
+			if err := a.jwks.Refresh(); err != nil {
+				a.logger.Warn("JWKS refresh failed", zap.Error(err))
+				continue
 			}
+			a.syncTokenValidator()
 		}
 	}
 }
 
-func (a *agent) shutdown() error {
-	a.logger.Info("initiating graceful shutdown")
-
-	a.stopControlRTTMeasurement()
-	a.safety.OnRevoked()
-
-	if err := a.transport.Close(); err != nil {
-		a.logger.Warn("error closing transport", zap.Error(err))
+// syncTokenValidator switches token validation to the current gateway
+// signing key if it differs from the one in use.
+func (a *agent) syncTokenValidator() {
+	pub, ok := a.jwks.CachedPublicKey(gatewaySigningKeyID)
+	if !ok {
+		a.logger.Warn("gateway signing key missing from JWKS", zap.String("kid", gatewaySigningKeyID))
+		return
 	}
-
-	if err := a.signaling.Close(); err != nil {
-		a.logger.Warn("error closing signaling", zap.Error(err))
+	if v := a.sessionMgr.Validator(); v != nil && v.PublicKey().Equal(pub) {
+		return
 	}
+	a.sessionMgr.SetValidator(session.NewTokenValidator(pub, a.cfg.RobotID, 30*time.Second))
+	a.logger.Info("token validator updated to the current gateway signing key")
+}
+
+func (a *agent) runSafetyMonitor(ctx context.Context) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
 
-	a.logger.Info("shutdown complete")
-	return nil
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if a.sessionMgr.State() == session.StateActive {
+				a.safety.CheckControlLoss()
+				a.checkGeofence()
+				a.releaseIdleInputs()
+			}
+		}
+	}
 }
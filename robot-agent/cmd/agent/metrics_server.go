@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/metrics"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+)
+
+const (
+	// jwksMaxAge is how old the last successful JWKS fetch may be before
+	// the agent reports not ready; one missed refresh is tolerated.
+	jwksMaxAge = 2 * jwksRefreshInterval
+
+	// metricsShutdownTimeout bounds in-flight scrapes at shutdown.
+	metricsShutdownTimeout = time.Second
+)
+
+// Readiness check failures.
+var (
+	errSignalingDown    = errors.New("not connected to gateway")
+	errJWKSNeverFetched = errors.New("gateway signing keys never fetched")
+	errNoTokenValidator = errors.New("token validator not initialized")
+	errValidatorStale   = errors.New("token validator not using the current gateway signing key")
+)
+
+// readyCheck is the outcome of one readiness check.
+type readyCheck struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// readyStatus is the /readyz response body.
+type readyStatus struct {
+	Ready  bool                  `json:"ready"`
+	Checks map[string]readyCheck `json:"checks"`
+}
+
+// startMetricsServer serves /metrics, /healthz and /readyz on the
+// configured local address. It is off unless METRICS_ADDR is set.
+func (a *agent) startMetricsServer() error {
+	if a.cfg.MetricsAddr == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", a.cfg.MetricsAddr)
+	if err != nil {
+		return fmt.Errorf("metrics listener: %w", err)
+	}
+
+	a.metricsServer = &http.Server{
+		Handler:           a.metricsHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := a.metricsServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.logger.Error("metrics server failed", zap.Error(err))
+		}
+	}()
+	a.logger.Info("metrics server listening", zap.String("addr", ln.Addr().String()))
+	return nil
+}
+
+// stopMetricsServer stops the metrics listener, if running.
+func (a *agent) stopMetricsServer() {
+	if a.metricsServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+	defer cancel()
+	if err := a.metricsServer.Shutdown(ctx); err != nil {
+		a.logger.Warn("error stopping metrics server", zap.Error(err))
+	}
+}
+
+func (a *agent) metricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", a.serveMetrics)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("GET /readyz", a.serveReady)
+	return mux
+}
+
+func (a *agent) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p := metrics.NewPromWriter(w)
+	a.writeMetrics(p)
+	if err := p.Err(); err != nil {
+		a.logger.Debug("metrics scrape aborted", zap.Error(err))
+	}
+}
+
+// writeMetrics writes every exported metric. Components that are not
+// initialized are skipped.
+func (a *agent) writeMetrics(p *metrics.PromWriter) {
+	if a.sessionMgr != nil {
+		current := a.sessionMgr.State()
+		p.Family("robot_agent_session_state", "Current session state (1 for the current state).", metrics.PromGauge)
+		for _, state := range []session.State{session.StatePending, session.StateActive, session.StateTerminated} {
+			p.Sample("robot_agent_session_state", boolValue(state == current), "state", string(state))
+		}
+	}
+
+	if a.safety != nil {
+		p.Family("robot_agent_safety_stopped", "Whether a safe-stop is in effect.", metrics.PromGauge)
+		p.Sample("robot_agent_safety_stopped", boolValue(a.safety.IsStopped()))
+		p.Family("robot_agent_safety_control_loss", "Whether the recoverable control-loss stop is in effect.", metrics.PromGauge)
+		p.Sample("robot_agent_safety_control_loss", boolValue(a.safety.InControlLoss()))
+
+		counts := a.safety.TriggerCounts()
+		p.Family("robot_agent_safe_stops_total", "Safe-stops by trigger.", metrics.PromCounter)
+		for _, t := range slices.Sorted(maps.Keys(counts)) {
+			p.Sample("robot_agent_safe_stops_total", float64(counts[t]), "trigger", string(t))
+		}
+	}
+
+	if a.signaling != nil {
+		p.Family("robot_agent_signaling_connected", "Whether the gateway signaling link is up.", metrics.PromGauge)
+		p.Sample("robot_agent_signaling_connected", boolValue(a.signaling.Connected()))
+	}
+
+	if a.controlRTTMetrics != nil {
+		p.Histogram("robot_agent_control_rtt_seconds", "Control channel ping round-trip time.",
+			a.controlRTTMetrics.Histogram().Snapshot())
+	}
+
+	if a.sessionSetupMetrics != nil {
+		hists := a.sessionSetupMetrics.Histograms()
+		p.Family("robot_agent_session_setup_seconds", "Session setup duration by phase.", metrics.PromHistogram)
+		for _, phase := range slices.Sorted(maps.Keys(hists)) {
+			p.HistogramSamples("robot_agent_session_setup_seconds", hists[phase].Snapshot(), "phase", phase)
+		}
+	}
+
+	if a.revocationMetrics != nil {
+		p.Histogram("robot_agent_revocation_seconds", "Revocation propagation time from message to hardware stop.",
+			a.revocationMetrics.Histogram().Snapshot())
+	}
+
+	if a.webrtcStatsMetrics != nil {
+		a.writeWebRTCStats(p)
+	}
+
+	if a.audit != nil {
+		p.Family("robot_agent_audit_queue_depth", "Audit events still being delivered.", metrics.PromGauge)
+		p.Sample("robot_agent_audit_queue_depth", float64(a.audit.Pending()))
+	}
+
+	if a.rateLimiter != nil {
+		denials := a.rateLimiter.Denials()
+		p.Family("robot_agent_rate_limit_denials_total", "Control commands rejected by the rate limiter, by type.", metrics.PromCounter)
+		for _, t := range slices.Sorted(maps.Keys(denials)) {
+			p.Sample("robot_agent_rate_limit_denials_total", float64(denials[t]), "type", string(t))
+		}
+	}
+}
+
+// writeWebRTCStats exports percentiles over the retained peer connection
+// stats samples, which the collector clears at each new session.
+func (a *agent) writeWebRTCStats(p *metrics.PromWriter) {
+	r := a.webrtcStatsMetrics.GenerateReport(metrics.DefaultWebRTCStatsTargets())
+
+	p.Family("robot_agent_webrtc_stats_samples", "Peer connection stats samples in the current window.", metrics.PromGauge)
+	p.Sample("robot_agent_webrtc_stats_samples", float64(r.SampleCount))
+
+	quantiles := []struct {
+		name, help string
+		values     [3]float64
+	}{
+		{"robot_agent_webrtc_rtt_seconds", "Selected candidate pair round-trip time.",
+			[3]float64{r.RoundTripTime.P50.Seconds(), r.RoundTripTime.P95.Seconds(), r.RoundTripTime.P99.Seconds()}},
+		{"robot_agent_webrtc_jitter_seconds", "Remote-reported jitter of the worst outbound stream.",
+			[3]float64{r.Jitter.P50.Seconds(), r.Jitter.P95.Seconds(), r.Jitter.P99.Seconds()}},
+		{"robot_agent_webrtc_packet_loss_ratio", "Remote-reported fraction of outbound packets lost.",
+			[3]float64{r.PacketLoss.P50, r.PacketLoss.P95, r.PacketLoss.P99}},
+		{"robot_agent_webrtc_bitrate_bps", "Outbound RTP bitrate.",
+			[3]float64{r.Bitrate.P50, r.Bitrate.P95, r.Bitrate.P99}},
+	}
+	for _, q := range quantiles {
+		p.Family(q.name, q.help+" Quantiles over the current window.", metrics.PromSummary)
+		for i, quantile := range []string{"0.5", "0.95", "0.99"} {
+			p.Sample(q.name, q.values[i], "quantile", quantile)
+		}
+		p.Sample(q.name+"_count", float64(r.SampleCount))
+	}
+}
+
+func (a *agent) serveReady(w http.ResponseWriter, r *http.Request) {
+	status := a.readiness()
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// readiness checks the signaling link, that tokens are validated with a
+// fresh gateway signing key, and the camera. The camera check is skipped
+// when no camera device is configured.
+func (a *agent) readiness() readyStatus {
+	checks := map[string]error{
+		"signaling": a.checkSignaling(),
+		"jwks":      a.checkJWKS(),
+	}
+	if a.cfg.CameraDevice != "" {
+		checks["camera"] = checkCamera(a.cfg.CameraDevice)
+	}
+
+	status := readyStatus{Ready: true, Checks: make(map[string]readyCheck, len(checks))}
+	for name, err := range checks {
+		c := readyCheck{OK: err == nil}
+		if err != nil {
+			c.Error = err.Error()
+			status.Ready = false
+		}
+		status.Checks[name] = c
+	}
+	return status
+}
+
+func (a *agent) checkSignaling() error {
+	if a.signaling == nil || !a.signaling.Connected() {
+		return errSignalingDown
+	}
+	return nil
+}
+
+func (a *agent) checkJWKS() error {
+	if a.jwks == nil || a.jwks.LastFetch().IsZero() {
+		return errJWKSNeverFetched
+	}
+	if age := time.Since(a.jwks.LastFetch()); age > jwksMaxAge {
+		return fmt.Errorf("gateway signing keys last fetched %s ago", age.Round(time.Second))
+	}
+
+	// Fresh keys are no use if offers are still checked against none or
+	// an old one
+	var validator *session.TokenValidator
+	if a.sessionMgr != nil {
+		validator = a.sessionMgr.Validator()
+	}
+	if validator == nil {
+		return errNoTokenValidator
+	}
+	if pub, ok := a.jwks.CachedPublicKey(gatewaySigningKeyID); !ok || !validator.PublicKey().Equal(pub) {
+		return errValidatorStale
+	}
+	return nil
+}
+
+func checkCamera(device string) error {
+	if _, err := os.Stat(device); err != nil {
+		return fmt.Errorf("camera device unavailable: %w", err)
+	}
+	return nil
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/config"
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+	"github.com/datapilot/chainkvm/robot-agent/internal/metrics"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+func get(t *testing.T, srv *httptest.Server, path string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(srv.URL + path)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestMetricsServer_Metrics(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+	l.cfg = &config.Config{}
+	l.sessionSetupMetrics = metrics.NewSessionSetupCollector(10)
+	l.webrtcStatsMetrics = metrics.NewWebRTCStatsCollector(10)
+	l.rateLimiter = control.NewRateLimiter(1)
+	l.rateLimiter.Allow(protocol.TypeDrive)
+	l.rateLimiter.Allow(protocol.TypeDrive)
+
+	l.connect(t, "ses-a")
+	l.safety.OnEStop()
+
+	// Two snapshots make one sample
+	now := time.Now()
+	l.webrtcStatsMetrics.Record(metrics.WebRTCStatsSnapshot{Timestamp: now})
+	l.webrtcStatsMetrics.Record(metrics.WebRTCStatsSnapshot{Timestamp: now.Add(time.Second), RoundTripTime: 40 * time.Millisecond})
+
+	srv := httptest.NewServer(l.metricsHandler())
+	defer srv.Close()
+
+	code, body := get(t, srv, "/metrics")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	for _, want := range []string{
+		`robot_agent_session_state{state="active"} 1`,
+		`robot_agent_session_state{state="pending"} 0`,
+		"robot_agent_safety_stopped 1",
+		`robot_agent_safe_stops_total{trigger="e_stop"} 1`,
+		`robot_agent_rate_limit_denials_total{type="drive"} 1`,
+		"# TYPE robot_agent_control_rtt_seconds histogram",
+		`robot_agent_session_setup_seconds_count{phase="total"} 0`,
+		"# TYPE robot_agent_webrtc_rtt_seconds summary",
+		`robot_agent_webrtc_rtt_seconds{quantile="0.95"} 0.04`,
+		"robot_agent_webrtc_rtt_seconds_count 1",
+		"robot_agent_webrtc_stats_samples 1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics:\n%s", want, body)
+		}
+	}
+	// Components the agent was built without are left out
+	if strings.Contains(body, "robot_agent_signaling_connected") {
+		t.Error("expected no signaling metric without a signaling client")
+	}
+}
+
+func TestMetricsServer_Health(t *testing.T) {
+	a := &agent{cfg: &config.Config{}, logger: zap.NewNop()}
+	srv := httptest.NewServer(a.metricsHandler())
+	defer srv.Close()
+
+	if code, body := get(t, srv, "/healthz"); code != http.StatusOK || body != "ok\n" {
+		t.Errorf("expected healthy, got %d %q", code, body)
+	}
+}
+
+// jwksBody returns a JWKS document holding pub as the gateway signing key.
+func jwksBody(pub ed25519.PublicKey) string {
+	return fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":%q,"x":%q}]}`,
+		gatewaySigningKeyID, base64.RawURLEncoding.EncodeToString(pub))
+}
+
+func TestMetricsServer_Ready(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	var keys atomic.Value
+	keys.Store(jwksBody(pub))
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(keys.Load().(string)))
+	}))
+	defer gateway.Close()
+
+	camera := filepath.Join(t.TempDir(), "video0")
+	a := &agent{
+		cfg:        &config.Config{RobotID: "test-robot", CameraDevice: camera},
+		logger:     zap.NewNop(),
+		jwks:       session.NewJWKSFetcher(gateway.URL, time.Minute),
+		sessionMgr: session.NewManager("test-robot", nil),
+		signaling:  session.NewSignalingClient("ws://127.0.0.1:1", "test-robot", zap.NewNop()),
+	}
+	srv := httptest.NewServer(a.metricsHandler())
+	defer srv.Close()
+
+	readyz := func() (int, readyStatus) {
+		t.Helper()
+		code, body := get(t, srv, "/readyz")
+		var status readyStatus
+		if err := json.Unmarshal([]byte(body), &status); err != nil {
+			t.Fatalf("decode readyz: %v", err)
+		}
+		return code, status
+	}
+
+	code, status := readyz()
+	if code != http.StatusServiceUnavailable || status.Ready {
+		t.Fatalf("expected not ready, got %d %+v", code, status)
+	}
+	for _, name := range []string{"signaling", "jwks", "camera"} {
+		if status.Checks[name].OK {
+			t.Errorf("expected %s check to fail: %+v", name, status.Checks[name])
+		}
+	}
+
+	// Keys fetched but the validator was never built from them
+	if err := a.jwks.Refresh(); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if _, status = readyz(); status.Checks["jwks"].Error != errNoTokenValidator.Error() {
+		t.Errorf("expected missing validator reported, got %+v", status.Checks["jwks"])
+	}
+	a.syncTokenValidator()
+
+	if err := os.WriteFile(camera, nil, 0o600); err != nil {
+		t.Fatalf("create camera: %v", err)
+	}
+	_, status = readyz()
+	if !status.Checks["jwks"].OK || !status.Checks["camera"].OK {
+		t.Errorf("expected jwks and camera ready: %+v", status.Checks)
+	}
+	if status.Ready {
+		t.Error("expected not ready while signaling is down")
+	}
+
+	// A rotated key is not ready until validation uses it
+	rotated, _, _ := ed25519.GenerateKey(rand.Reader)
+	keys.Store(jwksBody(rotated))
+	if err := a.jwks.Refresh(); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if _, status = readyz(); status.Checks["jwks"].Error != errValidatorStale.Error() {
+		t.Errorf("expected stale validator reported, got %+v", status.Checks["jwks"])
+	}
+	a.syncTokenValidator()
+	if _, status = readyz(); !status.Checks["jwks"].OK {
+		t.Errorf("expected jwks ready after the validator switched keys: %+v", status.Checks["jwks"])
+	}
+
+	// No camera configured: the check is skipped
+	a.cfg.CameraDevice = ""
+	if _, status = readyz(); len(status.Checks) != 2 {
+		t.Errorf("expected camera check skipped: %+v", status.Checks)
+	}
+}
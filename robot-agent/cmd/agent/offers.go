@@ -0,0 +1,129 @@
+package main
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/config"
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+)
+
+// supersededReason is audited when a takeover ends the active session.
+const supersededReason = "superseded"
+
+// sessionOwner returns the session holding the transport: the active
+// session, or one whose offer was accepted but is still connecting.
+func (a *agent) sessionOwner() string {
+	a.offerMu.Lock()
+	owner := a.offerSID
+	a.offerMu.Unlock()
+
+	if owner == "" {
+		owner = a.currentSessionID()
+	}
+	return owner
+}
+
+func (a *agent) setSessionOwner(sessionID string) {
+	a.offerMu.Lock()
+	defer a.offerMu.Unlock()
+	a.offerSID = sessionID
+}
+
+// releaseSessionOwner frees the transport if sessionID still holds it.
+func (a *agent) releaseSessionOwner(sessionID string) {
+	a.offerMu.Lock()
+	defer a.offerMu.Unlock()
+	if a.offerSID == sessionID {
+		a.offerSID = ""
+	}
+}
+
+// abandonOffer drops a peer connection whose setup failed.
+func (a *agent) abandonOffer(sessionID string) {
+	if a.transport != nil {
+		a.transport.Close()
+	}
+	a.releaseSessionOwner(sessionID)
+}
+
+// renegotiate answers a new offer for the session already holding the
+// transport, such as an ICE restart or a console adding tracks.
+func (a *agent) renegotiate(sessionID, token string, sdpData []byte) {
+	if _, err := a.sessionMgr.ValidateToken(sessionID, token); err != nil {
+		a.logger.Warn("renegotiation token validation failed",
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+		return
+	}
+	a.signaling.ExpectAnswer(sessionID)
+
+	answer, err := a.transport.HandleOffer(sdpData)
+	if err != nil {
+		a.logger.Error("failed to handle renegotiation offer",
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+		return
+	}
+	if err := a.signaling.SendAnswer(sessionID, answer); err != nil {
+		a.logger.Error("failed to send renegotiation answer", zap.Error(err))
+		return
+	}
+	a.logger.Info("session renegotiated",
+		zap.String("session_id", sessionID),
+		zap.Bool("awaiting_ice_restart", a.iceGracePending()))
+}
+
+// resolveConcurrentOffer applies the concurrent offer policy to an offer
+// for sessionID while owner holds the transport. It reports whether the
+// new offer may proceed.
+func (a *agent) resolveConcurrentOffer(owner, sessionID, token string) bool {
+	policy := config.OfferPolicyReject
+	if a.cfg != nil && a.cfg.ConcurrentOfferPolicy != "" {
+		policy = a.cfg.ConcurrentOfferPolicy
+	}
+
+	if policy != config.OfferPolicyTakeover {
+		a.rejectOffer(sessionID, owner, "session_active")
+		return false
+	}
+
+	// Only a validly authorized operator may take over
+	if _, err := a.sessionMgr.ValidateToken(sessionID, token); err != nil {
+		a.rejectOffer(sessionID, owner, "invalid_token")
+		return false
+	}
+
+	a.logger.Warn("new session taking over active session",
+		zap.String("session_id", sessionID),
+		zap.String("active_session_id", owner))
+	a.endSession(owner, supersededReason)
+	return true
+}
+
+// rejectOffer refuses an offer while another session holds the transport.
+func (a *agent) rejectOffer(sessionID, owner, reason string) {
+	a.logger.Warn("rejecting offer: another session is active",
+		zap.String("session_id", sessionID),
+		zap.String("active_session_id", owner),
+		zap.String("reason", reason))
+
+	if a.signaling != nil {
+		if err := a.signaling.SendError(sessionID, "robot busy: another session is active"); err != nil {
+			a.logger.Warn("failed to send offer rejection", zap.Error(err))
+		}
+	}
+
+	if a.audit != nil {
+		a.audit.Publish(audit.Event{
+			EventType: audit.EventOfferRejected,
+			SessionID: sessionID,
+			Timestamp: time.Now().UTC(),
+			Metadata: map[string]string{
+				"reason":            reason,
+				"active_session_id": owner,
+			},
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/config"
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+)
+
+func TestOnOffer_RejectsSecondSession(t *testing.T) {
+	a, client := newSessionAgent(t, nil)
+	a.signaling = session.NewSignalingClient("ws://gateway:8080", "test-robot", zap.NewNop())
+
+	a.OnOffer("ses_2", "token", []byte(`{"type":"offer","sdp":""}`))
+	drainAuditForTest(t, a)
+
+	if got := a.currentSessionID(); got != "ses_1" {
+		t.Errorf("expected ses_1 to stay active, got %q", got)
+	}
+	if a.currentSessionSetup != nil {
+		t.Error("rejected offer must not start setup timing")
+	}
+
+	events := client.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(events))
+	}
+	e := events[0]
+	if e.EventType != audit.EventOfferRejected || e.SessionID != "ses_2" || e.Metadata["active_session_id"] != "ses_1" {
+		t.Errorf("unexpected audit event: %+v", e)
+	}
+}
+
+func TestOnOffer_RejectsWhileConnecting(t *testing.T) {
+	a, client := newSessionAgent(t, nil)
+	a.sessionMgr.Terminate()
+	a.setSessionOwner("ses_1")
+
+	a.OnOffer("ses_2", "token", nil)
+	drainAuditForTest(t, a)
+
+	events := client.Events()
+	if len(events) != 1 || events[0].EventType != audit.EventOfferRejected {
+		t.Errorf("expected offer rejection, got %+v", events)
+	}
+}
+
+func TestOnICE_IgnoresOtherSession(t *testing.T) {
+	a, _ := newSessionAgent(t, nil)
+
+	// transport is nil, so a forwarded candidate would panic
+	a.OnICE("ses_2", []byte(`{"candidate":""}`))
+}
+
+func TestResolveConcurrentOffer_Takeover(t *testing.T) {
+	l := newLifecycleAgent(t)
+	client := &recordingAuditClient{}
+	l.audit = audit.NewPublisher("http://gateway:8080", "test-robot")
+	l.audit.SetHTTPClient(client)
+	l.cfg = &config.Config{ConcurrentOfferPolicy: config.OfferPolicyTakeover}
+	l.connect(t, "ses_1")
+	l.setSessionOwner("ses_1")
+	drainAuditForTest(t, l.agent)
+
+	if l.resolveConcurrentOffer("ses_1", "ses_2", "invalid") {
+		t.Fatal("takeover must require a valid token")
+	}
+	if !l.sessionMgr.IsActive() {
+		t.Fatal("rejected takeover must keep the active session")
+	}
+	drainAuditForTest(t, l.agent)
+
+	if !l.resolveConcurrentOffer("ses_1", "ses_2", l.token(t, "ses_2")) {
+		t.Fatal("expected takeover to proceed")
+	}
+	if l.sessionOwner() != "" {
+		t.Errorf("expected superseded session to release the transport, owner %q", l.sessionOwner())
+	}
+	drainAuditForTest(t, l.agent)
+
+	events := client.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 audit events, got %+v", events)
+	}
+	if e := events[0]; e.EventType != audit.EventSessionStarted || e.SessionID != "ses_1" {
+		t.Errorf("unexpected session start event: %+v", e)
+	}
+	if e := events[1]; e.EventType != audit.EventOfferRejected || e.Metadata["reason"] != "invalid_token" {
+		t.Errorf("unexpected rejection event: %+v", e)
+	}
+	if e := events[2]; e.EventType != audit.EventSessionEnded || e.SessionID != "ses_1" || e.Metadata["reason"] != supersededReason {
+		t.Errorf("unexpected session end event: %+v", e)
+	}
+
+	// The next offer starts a fresh session
+	if _, err := l.beginSession("ses_2", l.token(t, "ses_2")); err != nil {
+		t.Fatalf("begin session after takeover: %v", err)
+	}
+}
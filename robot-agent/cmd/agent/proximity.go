@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+	"github.com/datapilot/chainkvm/robot-agent/internal/proximity"
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// initProximity enables obstacle speed limiting if the robot backend
+// streams range data.
+func (a *agent) initProximity(backend control.RobotAPI) {
+	stream, ok := backend.(proximity.RangeStream)
+	if !ok {
+		return
+	}
+
+	limiter := proximity.NewLimiter(proximity.Config{
+		StopDistance: a.cfg.ProximityStopDistanceM,
+		SlowDistance: a.cfg.ProximitySlowDistanceM,
+		HalfArc:      a.cfg.ProximityArcDeg / 2 * math.Pi / 180,
+		MaxScanAge:   time.Duration(a.cfg.ProximityMaxScanAgeMS) * time.Millisecond,
+	})
+	limiter.SetLimitCallback(a.sendLimitState)
+	limiter.SetStopFunc(a.obstacleStop)
+	stream.SubscribeRanges(limiter.Update)
+	a.handler.AddDriveFilter(limiter)
+
+	a.logger.Info("obstacle proximity limiting enabled",
+		zap.Float64("stop_distance_m", a.cfg.ProximityStopDistanceM),
+		zap.Float64("slow_distance_m", a.cfg.ProximitySlowDistanceM))
+}
+
+// obstacleStop halts the robot without ramping when an obstacle blocks the
+// direction of travel.
+func (a *agent) obstacleStop() {
+	a.logger.Warn("obstacle in path, stopping")
+	if err := a.handler.StopNow(); err != nil {
+		a.logger.Error("obstacle stop failed, issuing e-stop", zap.Error(err))
+		if err := a.handler.RobotAPI().EStop(); err != nil {
+			a.logger.Error("CRITICAL: e-stop after obstacle stop failure failed", zap.Error(err))
+		}
+	}
+}
+
+// sendLimitState notifies the console of active speed limits.
+func (a *agent) sendLimitState(reasons []string) {
+	if a.transport == nil {
+		return
+	}
+
+	stateMsg := protocol.StateMessage{
+		Type:         protocol.TypeState,
+		RobotState:   protocol.RobotStateActive,
+		SessionState: "active",
+		LimitReasons: reasons,
+		T:            time.Now().UnixMilli(),
+	}
+	data, err := json.Marshal(stateMsg)
+	if err != nil {
+		a.logger.Error("failed to marshal limit state", zap.Error(err))
+		return
+	}
+	if err := a.transport.SendData(data); err != nil {
+		a.logger.Debug("failed to send limit state", zap.Error(err))
+	}
+}
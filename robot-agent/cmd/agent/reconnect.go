@@ -0,0 +1,111 @@
+package main
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+)
+
+// connectionLostReason is audited when a session's peer connection does
+// not recover within the ICE restart grace period.
+const connectionLostReason = "connection_lost"
+
+// onTransportLost holds an active session in a recoverable control-loss
+// stop while the console attempts an ICE restart, and ends it if the
+// connection has not recovered when the grace period expires.
+func (a *agent) onTransportLost(info *session.Info) {
+	if !a.sessionMgr.IsActive() || a.currentSessionID() != info.SessionID {
+		// Never connected, or a stale connection: nothing to recover
+		a.onSessionDisconnected(info)
+		return
+	}
+
+	grace := a.iceRestartGrace()
+	if grace <= 0 {
+		a.endLostSession(info.SessionID)
+		return
+	}
+
+	a.safety.OnTransportLost()
+
+	a.iceMu.Lock()
+	defer a.iceMu.Unlock()
+	if a.iceGrace != nil {
+		return // Disconnected then Failed: keep the original deadline
+	}
+	a.iceGraceGen++
+	gen := a.iceGraceGen
+	a.iceGrace = time.AfterFunc(grace, func() { a.expireICEGrace(info.SessionID, gen) })
+
+	a.logger.Warn("peer connection lost, awaiting ICE restart",
+		zap.String("session_id", info.SessionID),
+		zap.Duration("grace", grace))
+}
+
+// onTransportRecovered resumes a session whose connection came back.
+// Safety leaves the control-loss stop on the next valid command.
+func (a *agent) onTransportRecovered(info *session.Info) {
+	if !a.cancelICEGrace() {
+		return
+	}
+	a.logger.Info("peer connection recovered", zap.String("session_id", info.SessionID))
+	// An ICE restart may have moved the session to a different path
+	a.selectedCandidatePairType(info.SessionID)
+
+	// Pings may have tripped their circuit breaker while disconnected
+	a.startControlRTTMeasurement()
+}
+
+// expireICEGrace ends the session if grace timer gen is still pending.
+func (a *agent) expireICEGrace(sessionID string, gen uint64) {
+	a.iceMu.Lock()
+	if a.iceGrace == nil || a.iceGraceGen != gen {
+		a.iceMu.Unlock()
+		return
+	}
+	a.iceGrace = nil
+	a.iceMu.Unlock()
+
+	if a.currentSessionID() != sessionID {
+		return
+	}
+	a.logger.Warn("ICE restart grace period expired, ending session",
+		zap.String("session_id", sessionID))
+	a.endLostSession(sessionID)
+}
+
+// endLostSession ends a session whose connection is not coming back. The
+// control-loss stop only ramped the robot down, so escalate to a full stop
+// before the session ends.
+func (a *agent) endLostSession(sessionID string) {
+	a.safety.OnConnectionLost()
+	a.endSession(sessionID, connectionLostReason)
+}
+
+// cancelICEGrace stops a pending grace timer and reports whether one was
+// pending.
+func (a *agent) cancelICEGrace() bool {
+	a.iceMu.Lock()
+	defer a.iceMu.Unlock()
+	if a.iceGrace == nil {
+		return false
+	}
+	a.iceGrace.Stop()
+	a.iceGrace = nil
+	return true
+}
+
+func (a *agent) iceGracePending() bool {
+	a.iceMu.Lock()
+	defer a.iceMu.Unlock()
+	return a.iceGrace != nil
+}
+
+func (a *agent) iceRestartGrace() time.Duration {
+	if a.cfg == nil {
+		return 0
+	}
+	return time.Duration(a.cfg.ICERestartGraceMS) * time.Millisecond
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/config"
+	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
+)
+
+func TestTransportLost_RecoversWithinGrace(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+	l.cfg = &config.Config{ICERestartGraceMS: 60000}
+
+	l.connect(t, "ses-a")
+	info := l.sessionMgr.Info()
+
+	l.onTransportLost(info)
+	if got := l.safety.LastTransition().Trigger; got != safety.TriggerControlLoss {
+		t.Errorf("expected control-loss stop while disconnected, got %s", got)
+	}
+	if !l.sessionMgr.IsActive() || !l.iceGracePending() {
+		t.Fatal("expected session to wait for an ICE restart")
+	}
+
+	// ICE restart completes
+	l.onSessionConnected(info)
+	if l.iceGracePending() {
+		t.Error("expected grace timer to be cancelled on recovery")
+	}
+	if got := l.currentSessionID(); got != "ses-a" {
+		t.Errorf("expected ses-a to stay active, got %q", got)
+	}
+
+	// Safety leaves the control-loss stop once commands resume
+	if err := l.drive(1); err != nil {
+		t.Fatalf("drive after recovery: %v", err)
+	}
+	l.safety.OnEStop()
+	if got := l.safety.LastTransition().Trigger; got != safety.TriggerEStop {
+		t.Errorf("expected safety to have recovered, got %s", got)
+	}
+}
+
+func TestTransportLost_EndsSessionAfterGrace(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+	l.cfg = &config.Config{ICERestartGraceMS: 20}
+
+	l.connect(t, "ses-a")
+	l.onTransportLost(l.sessionMgr.Info())
+	before := l.robot.estops.Load()
+
+	deadline := time.Now().Add(time.Second)
+	for l.sessionMgr.IsActive() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if l.sessionMgr.IsActive() {
+		t.Fatal("expected session to end when the grace period expired")
+	}
+
+	// The control-loss stop escalates to a full stop
+	if got := l.safety.LastTransition().Trigger; got != safety.TriggerConnectionLost {
+		t.Errorf("expected connection-lost stop, got %s", got)
+	}
+	if n := l.robot.estops.Load() - before; n != 1 {
+		t.Errorf("expected one hardware e-stop, got %d", n)
+	}
+
+	// The next session starts fresh
+	l.connect(t, "ses-b")
+}
+
+func TestTransportLost_ZeroGraceEndsImmediately(t *testing.T) {
+	l := newLifecycleAgent(t)
+	l.cfg = &config.Config{}
+
+	l.connect(t, "ses-a")
+	l.onTransportLost(l.sessionMgr.Info())
+
+	if l.sessionMgr.IsActive() || l.iceGracePending() {
+		t.Error("expected session to end without a grace period")
+	}
+	if n := l.robot.estops.Load(); n != 1 {
+		t.Errorf("expected one hardware e-stop, got %d", n)
+	}
+}
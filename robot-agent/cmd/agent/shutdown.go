@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// shutdownReason is reported in the SESSION_ENDED audit event and the
+// signaling bye when the agent stops.
+const shutdownReason = "shutdown"
+
+// shutdown safe-stops the robot, ends any active session and delivers
+// pending audit events. It returns an error if the hardware stop failed so
+// the process exits non-zero.
+func (a *agent) shutdown() error {
+	a.logger.Info("initiating graceful shutdown")
+
+	a.cancelICEGrace()
+	a.cancelSignalingLoss()
+	a.stopControlRTTMeasurement()
+
+	sessionID := a.currentSessionID()
+	// Latch the monitor so nothing resumes motion, then stop the hardware
+	// directly: a monitor already stopped would not act on the trigger
+	a.safety.OnShutdown()
+	haltErr := a.executeHardwareStop(safety.TriggerShutdown)
+	a.releaseInputs(string(safety.TriggerShutdown))
+
+	if sessionID != "" {
+		a.endSessionForShutdown(sessionID, haltErr)
+	}
+
+	if a.transport != nil {
+		if err := a.transport.Close(); err != nil {
+			a.logger.Warn("error closing transport", zap.Error(err))
+		}
+	}
+
+	a.drainAudit()
+	a.stopMetricsServer()
+
+	if a.signaling != nil {
+		if err := a.signaling.Close(); err != nil {
+			a.logger.Warn("error closing signaling", zap.Error(err))
+		}
+	}
+
+	if haltErr != nil {
+		a.logger.Error("shutdown complete, but hardware stop failed", zap.Error(haltErr))
+		return fmt.Errorf("hardware stop failed during shutdown: %w", haltErr)
+	}
+	a.logger.Info("shutdown complete")
+	return nil
+}
+
+// endSessionForShutdown tells the console and gateway the session is over
+// and records why.
+func (a *agent) endSessionForShutdown(sessionID string, haltErr error) {
+	robotState := protocol.RobotStateSafeStop
+	if haltErr != nil {
+		robotState = protocol.RobotStateSafeStopFailed
+	}
+	a.sendState(robotState, string(session.StateTerminated))
+
+	a.sessionMgr.Terminate()
+
+	if a.audit != nil {
+		a.audit.Publish(audit.Event{
+			EventType: audit.EventSessionEnded,
+			SessionID: sessionID,
+			Timestamp: time.Now().UTC(),
+			Metadata:  map[string]string{"reason": shutdownReason},
+		})
+	}
+
+	if a.signaling == nil {
+		return
+	}
+	if err := a.signaling.SendBye(sessionID, shutdownReason); err != nil {
+		a.logger.Warn("failed to send bye", zap.String("session_id", sessionID), zap.Error(err))
+	}
+}
+
+// drainAudit waits up to the configured deadline for queued audit events.
+func (a *agent) drainAudit() {
+	if a.audit == nil {
+		return
+	}
+
+	timeout := 2 * time.Second
+	if a.cfg != nil && a.cfg.AuditDrainTimeoutMS > 0 {
+		timeout = time.Duration(a.cfg.AuditDrainTimeoutMS) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := a.audit.Drain(ctx); err != nil {
+		a.logger.Warn("audit queue not drained before shutdown", zap.Error(err))
+	}
+}
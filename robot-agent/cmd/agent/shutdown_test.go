@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/audit"
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+)
+
+// recordingAuditClient captures audit events posted to the gateway.
+type recordingAuditClient struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (c *recordingAuditClient) Do(req *http.Request) (*http.Response, error) {
+	var event audit.Event
+	if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.events = append(c.events, event)
+	c.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+}
+
+func (c *recordingAuditClient) Events() []audit.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]audit.Event(nil), c.events...)
+}
+
+func newSessionAgent(t *testing.T, handler *control.Handler) (*agent, *recordingAuditClient) {
+	t.Helper()
+	client := &recordingAuditClient{}
+	a := &agent{
+		logger:     zap.NewNop(),
+		sessionMgr: session.NewManager("test-robot", nil),
+		handler:    handler,
+		audit:      audit.NewPublisher("http://gateway:8080", "test-robot"),
+	}
+	a.audit.SetHTTPClient(client)
+	a.safety = safety.NewMonitor(time.Second, 5, 0, a.onSafeStop)
+	if err := a.sessionMgr.Activate(&session.Info{SessionID: "ses_1"}); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	return a, client
+}
+
+func TestShutdown_EndsSessionAndDrainsAudit(t *testing.T) {
+	handler := control.NewHandler(control.NewStubRobotAPI(zap.NewNop()), nil, nil, nil, time.Second)
+	a, client := newSessionAgent(t, handler)
+
+	if err := a.shutdown(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := a.safety.LastTransition().Trigger; got != safety.TriggerShutdown {
+		t.Errorf("expected shutdown trigger, got %s", got)
+	}
+	if a.sessionMgr.State() != session.StateTerminated {
+		t.Errorf("expected terminated session, got %s", a.sessionMgr.State())
+	}
+
+	// Drain returns only after delivery, so the event is already recorded
+	events := client.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(events))
+	}
+	e := events[0]
+	if e.EventType != audit.EventSessionEnded || e.SessionID != "ses_1" || e.Metadata["reason"] != "shutdown" {
+		t.Errorf("unexpected audit event: %+v", e)
+	}
+}
+
+func TestShutdown_ReportsFailedHardwareStop(t *testing.T) {
+	a, _ := newSessionAgent(t, nil)
+
+	err := a.shutdown()
+	if !errors.Is(err, errHardwareUnavailable) {
+		t.Errorf("expected hardware stop failure, got %v", err)
+	}
+}
+
+func TestShutdown_AlwaysStopsHardware(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(l *lifecycleAgent)
+	}{
+		{"during control loss", func(l *lifecycleAgent) { l.safety.OnTransportLost() }},
+		{"after e-stop", func(l *lifecycleAgent) { l.safety.OnEStop() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLifecycleAgent(t)
+			l.connect(t, "ses-a")
+			tt.stop(l)
+			before := l.robot.estops.Load()
+
+			if err := l.shutdown(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n := l.robot.estops.Load() - before; n != 1 {
+				t.Errorf("expected exactly one e-stop at shutdown, got %d", n)
+			}
+		})
+	}
+}
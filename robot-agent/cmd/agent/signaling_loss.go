@@ -0,0 +1,95 @@
+package main
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// signalingLostReason is audited when a session is safe-stopped because
+// the gateway link stayed down past the tolerance.
+const signalingLostReason = "signaling_lost"
+
+// onSignalingConnection keeps an active session running while signaling
+// reconnects. Revocations cannot arrive while the link is down, so the
+// session is safe-stopped if it stays down past the tolerance.
+func (a *agent) onSignalingConnection(connected bool) {
+	if connected {
+		if a.cancelSignalingLoss() {
+			a.logger.Info("signaling restored, session continues",
+				zap.String("session_id", a.currentSessionID()))
+		}
+		return
+	}
+
+	sessionID := a.currentSessionID()
+	if sessionID == "" {
+		return
+	}
+
+	tolerance := a.signalingLossTolerance()
+	if tolerance <= 0 {
+		a.expireSignalingLoss(sessionID)
+		return
+	}
+
+	a.sigLossMu.Lock()
+	defer a.sigLossMu.Unlock()
+	if a.sigLoss != nil {
+		return
+	}
+	a.sigLossGen++
+	gen := a.sigLossGen
+	a.sigLoss = time.AfterFunc(tolerance, func() {
+		a.sigLossMu.Lock()
+		current := a.sigLoss != nil && a.sigLossGen == gen
+		if current {
+			a.sigLoss = nil
+		}
+		a.sigLossMu.Unlock()
+
+		if current {
+			a.expireSignalingLoss(sessionID)
+		}
+	})
+
+	a.logger.Warn("signaling lost, session continues while reconnecting",
+		zap.String("session_id", sessionID),
+		zap.Duration("tolerance", tolerance))
+}
+
+// expireSignalingLoss safe-stops and ends sessionID if it is still active
+// and signaling has not come back.
+func (a *agent) expireSignalingLoss(sessionID string) {
+	if a.signaling != nil && a.signaling.Connected() {
+		return
+	}
+	if a.currentSessionID() != sessionID {
+		return
+	}
+
+	a.logger.Warn("signaling loss tolerance exceeded, ending session",
+		zap.String("session_id", sessionID))
+	a.safety.OnSignalingLost()
+	a.endSession(sessionID, signalingLostReason)
+}
+
+// cancelSignalingLoss stops a pending signaling-loss timer and reports
+// whether one was pending.
+func (a *agent) cancelSignalingLoss() bool {
+	a.sigLossMu.Lock()
+	defer a.sigLossMu.Unlock()
+	if a.sigLoss == nil {
+		return false
+	}
+	a.sigLoss.Stop()
+	a.sigLoss = nil
+	return true
+}
+
+func (a *agent) signalingLossTolerance() time.Duration {
+	if a.cfg == nil {
+		return 0
+	}
+	return time.Duration(a.cfg.SignalingLossToleranceMS) * time.Millisecond
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/config"
+	"github.com/datapilot/chainkvm/robot-agent/internal/safety"
+)
+
+func TestSignalingLoss_SessionSurvivesReconnect(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+	l.cfg = &config.Config{SignalingLossToleranceMS: 60000}
+
+	l.connect(t, "ses-a")
+	l.onSignalingConnection(false)
+	if !l.sessionMgr.IsActive() {
+		t.Fatal("session must keep running while signaling reconnects")
+	}
+
+	l.onSignalingConnection(true)
+	if l.cancelSignalingLoss() {
+		t.Error("expected reconnect to cancel the pending safe-stop")
+	}
+	if got := l.safety.LastTransition().Trigger; got != "" {
+		t.Errorf("expected no safe-stop, got %s", got)
+	}
+}
+
+func TestSignalingLoss_SafeStopsAfterTolerance(t *testing.T) {
+	l := newLifecycleAgent(t)
+	defer l.stopControlRTTMeasurement()
+	l.cfg = &config.Config{SignalingLossToleranceMS: 20}
+
+	l.connect(t, "ses-a")
+	l.onSignalingConnection(false)
+
+	deadline := time.Now().Add(time.Second)
+	for l.sessionMgr.IsActive() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if l.sessionMgr.IsActive() {
+		t.Fatal("expected session to end after the signaling loss tolerance")
+	}
+	if got := l.safety.LastTransition().Trigger; got != safety.TriggerSignalingLost {
+		t.Errorf("expected signaling_lost trigger, got %s", got)
+	}
+}
+
+func TestSignalingLoss_IgnoredWithoutSession(t *testing.T) {
+	l := newLifecycleAgent(t)
+	l.cfg = &config.Config{}
+
+	l.onSignalingConnection(false)
+	if got := l.safety.LastTransition().Trigger; got != "" {
+		t.Errorf("expected no safe-stop without a session, got %s", got)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/control"
+	"github.com/datapilot/chainkvm/robot-agent/internal/session"
+	"github.com/datapilot/chainkvm/robot-agent/internal/telemetry"
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// activeSessionScopes reports scopes only while the session is active, so
+// telemetry stops as soon as a session ends.
+type activeSessionScopes struct {
+	mgr *session.Manager
+}
+
+func (s activeSessionScopes) HasScope(scope string) bool {
+	return s.mgr.State() == session.StateActive && s.mgr.HasScope(scope)
+}
+
+// initTelemetry creates the telemetry publisher and registers the sources
+// the robot backend provides. Link quality always includes control RTT.
+func (a *agent) initTelemetry(backend control.RobotAPI) {
+	ms := func(v int) time.Duration { return time.Duration(v) * time.Millisecond }
+
+	a.telemetry = telemetry.NewPublisher(telemetry.Config{
+		Rates: map[string]time.Duration{
+			protocol.TelemetryBattery:  ms(a.cfg.TelemetryBatteryMS),
+			protocol.TelemetryOdometry: ms(a.cfg.TelemetryOdometryMS),
+			protocol.TelemetryThermal:  ms(a.cfg.TelemetryThermalMS),
+			protocol.TelemetrySystem:   ms(a.cfg.TelemetrySystemMS),
+			protocol.TelemetryLink:     ms(a.cfg.TelemetryLinkMS),
+		},
+		KeepAlive: ms(a.cfg.TelemetryKeepAliveMS),
+	}, a.sendTelemetry, activeSessionScopes{mgr: a.sessionMgr})
+
+	linkSource, _ := backend.(telemetry.LinkSource)
+	a.telemetry.Register(protocol.TelemetryLink, func(msg *protocol.TelemetryMessage) error {
+		link := protocol.LinkTelemetry{}
+		if linkSource != nil {
+			var err error
+			if link, err = linkSource.Link(); err != nil {
+				return err
+			}
+		}
+		if rtt, ok := a.controlRTTMetrics.LastRTT(); ok {
+			link.RTTMs = float64(rtt) / float64(time.Millisecond)
+		}
+		msg.Link = &link
+		return nil
+	})
+
+	kinds := a.telemetry.RegisterBackend(backend)
+	a.logger.Info("telemetry enabled", zap.Strings("backend_kinds", kinds))
+}
+
+// sendTelemetry sends an encoded telemetry message over the DataChannel.
+func (a *agent) sendTelemetry(data []byte) error {
+	if a.transport == nil {
+		return nil
+	}
+	return a.transport.SendData(data)
+}
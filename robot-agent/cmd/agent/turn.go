@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/transport"
+)
+
+// turnHTTPTimeout bounds a TURN credential fetch from the gateway.
+const turnHTTPTimeout = 10 * time.Second
+
+// iceConfig builds the transport ICE configuration and candidate policy,
+// choosing the TURN credential source: gateway-issued, derived from the
+// coturn shared secret, or long-term from config.
+func (a *agent) iceConfig() transport.ICEConfig {
+	cfg := transport.ICEConfig{
+		STUNServers:     a.cfg.STUNServers,
+		TURNServers:     a.cfg.TURNServers,
+		TURNUsername:    a.cfg.TURNUsername,
+		TURNPassword:    a.cfg.TURNPassword,
+		TransportPolicy: webrtc.NewICETransportPolicy(a.cfg.ICETransportPolicy),
+		NetworkTypes:    a.cfg.ICENetworkTypes,
+		Interfaces:      a.cfg.ICEInterfaces,
+		UDPPortMin:      uint16(a.cfg.ICEUDPPortMin),
+		UDPPortMax:      uint16(a.cfg.ICEUDPPortMax),
+		NAT1To1IPs:      a.cfg.ICENAT1To1IPs,
+
+		LANOnly:            a.cfg.ICELANOnly,
+		MDNSHostCandidates: a.cfg.ICEMDNSHostCandidates,
+	}
+
+	switch {
+	case a.cfg.TURNCredentialsURL != "":
+		a.gatewayTURN = transport.NewGatewayTURN(a.cfg.TURNCredentialsURL,
+			a.gatewayHTTPClient(turnHTTPTimeout), a.turnAuthToken)
+		cfg.TURNCredentials = a.gatewayTURN
+	case a.cfg.TURNSharedSecret != "":
+		ttl := time.Duration(a.cfg.TURNCredentialTTLS) * time.Second
+		cfg.TURNCredentials = transport.NewSharedSecretTURN(a.cfg.TURNSharedSecret, a.cfg.RobotID, ttl)
+	case len(a.cfg.TURNServers) > 0 && a.cfg.TURNUsername == "":
+		// coturn rejects unauthenticated allocations, so the transport
+		// leaves these servers out of every peer connection
+		a.logger.Warn("TURN_SERVERS set without credentials, TURN will not be used",
+			zap.Strings("turn_servers", a.cfg.TURNServers))
+	}
+	return cfg
+}
+
+// turnAuthToken authenticates TURN credential requests with the robot's
+// identity, like the signaling join.
+func (a *agent) turnAuthToken() (string, error) {
+	if a.identity == nil {
+		return "", nil
+	}
+	return a.identity.JoinToken(a.cfg.RobotID, a.cfg.TURNCredentialsURL, time.Minute)
+}
+
+// runTURNRefresh keeps gateway-issued TURN credentials fresh so new
+// sessions do not wait on a fetch.
+func (a *agent) runTURNRefresh(ctx context.Context) {
+	if a.gatewayTURN == nil {
+		return
+	}
+	a.gatewayTURN.Run(ctx, func(err error) {
+		a.logger.Warn("TURN credential refresh failed", zap.Error(err))
+	})
+}
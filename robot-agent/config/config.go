@@ -3,6 +3,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -11,15 +12,17 @@ import (
 // Config holds all Robot Agent configuration.
 type Config struct {
 	// Identity
-	RobotID string
+	RobotID      string
+	RobotKeyFile string // Ed25519 did:key private key (PEM); generated on first boot
 
 	// Gateway
 	GatewayWSURL   string
 	GatewayHTTPURL string
 	GatewayJWKSURL string
+	GatewayTLS     TLSSettings
 
 	// Video
-	CameraDevice string
+	CameraDevice string // Empty when CAMERA_DEVICE=none: no camera readiness check
 	VideoCodec   string
 	VideoBitrate int
 	VideoFPS     int
@@ -31,24 +34,134 @@ type Config struct {
 	InvalidCmdThreshold    int
 	InvalidCmdTimeWindowMS int
 
+	// Drive shaping
+	DriveMaxLinearAccel   float64
+	DriveMaxAngularAccel  float64
+	DriveMaxLinearJerk    float64
+	DriveMaxAngularJerk   float64
+	DriveDeadband         float64
+	DriveMaxSpeed         float64
+	DriveScopeSpeedLimits map[string]float64 // scope -> max speed fraction
+	DriveShaperTickMS     int
+	DriveDeadmanWindowMS  int // 0 = deadman only for commands with hold/valid_ms
+
+	// KVM input safety
+	KVMDeniedChords       []string // e.g. "ctrl+alt+Delete"; bypassed by teleop:kvm_privileged
+	KVMInputIdleTimeoutMS int      // Release held keys/buttons after this long without input (0 = off)
+	KVMTypeRateHz         int      // Characters per second for kvm_type
+	KVMTypeMaxLength      int      // Maximum kvm_type text length (characters)
+
+	// Geofence (disabled if empty)
+	GeofenceFile string
+
+	// Obstacle proximity limiting (active when the robot backend streams ranges)
+	ProximityStopDistanceM float64
+	ProximitySlowDistanceM float64
+	ProximityArcDeg        float64
+	ProximityMaxScanAgeMS  int
+
+	// Telemetry sample intervals (0 disables a kind)
+	TelemetryBatteryMS   int
+	TelemetryOdometryMS  int
+	TelemetryThermalMS   int
+	TelemetrySystemMS    int
+	TelemetryLinkMS      int
+	TelemetryKeepAliveMS int
+
+	// Sessions
+	ConcurrentOfferPolicy string // What to do with an offer for another session: "reject" or "takeover"
+	ICERestartGraceMS     int    // How long a disconnected session waits for an ICE restart (0 = end immediately)
+
+	// Signaling link
+	SignalingKeepaliveMS     int  // Gateway ping interval; the link is dropped after 3 silent intervals (0 = off)
+	SignalingLossToleranceMS int  // How long an active session runs without signaling before safe-stop (0 = stop immediately)
+	SignalingICEBatching     bool // Send local candidates in batched "candidates" messages; the gateway and console must support them
+
+	// Metrics
+	WebRTCStatsIntervalMS int    // Peer connection stats polling interval (0 = off)
+	MetricsAddr           string // Local listener for /metrics, /healthz and /readyz (empty = off)
+
+	// Shutdown
+	AuditDrainTimeoutMS int // Deadline for delivering queued audit events at shutdown
+
 	// ICE
 	STUNServers []string
 	TURNServers []string
+
+	// TURN credentials: long-term, or time-limited (coturn REST API) from a
+	// shared secret or the gateway
+	TURNUsername       string
+	TURNPassword       string
+	TURNSharedSecret   string // coturn static-auth-secret
+	TURNCredentialsURL string // Gateway endpoint issuing time-limited credentials
+	TURNCredentialTTLS int    // Lifetime of credentials derived from the shared secret
+
+	// ICE candidate policy
+	ICETransportPolicy string   // "all" or "relay" (TURN only, hides robot addresses)
+	ICENetworkTypes    []string // Allowed network types, e.g. udp4,tcp4 (empty = all)
+	ICEInterfaces      []string // Interfaces to gather candidates on (empty = all)
+	ICEUDPPortMin      int      // Ephemeral UDP port range for a firewall (0-0 = any)
+	ICEUDPPortMax      int
+	ICENAT1To1IPs      []string // Public IPs advertised in place of host addresses
+
+	// Offline operation: host candidates only, no STUN or TURN
+	ICELANOnly            bool
+	ICEMDNSHostCandidates bool // Advertise host candidates as .local names instead of IPs
 }
 
+// ICE transport policies.
+const (
+	ICEPolicyAll   = "all"
+	ICEPolicyRelay = "relay"
+)
+
+// Concurrent offer policies.
+const (
+	OfferPolicyReject   = "reject"   // Keep the active session, refuse the new offer
+	OfferPolicyTakeover = "takeover" // End the active session for a validly authorized new one
+)
+
 // Load reads configuration from environment variables.
 func Load() (*Config, error) {
 	cfg := &Config{
 		// Defaults
-		CameraDevice:         "/dev/video0",
-		VideoCodec:           "h264",
-		VideoBitrate:         2000000,
-		VideoFPS:             30,
-		ControlLossTimeoutMS:   500,
-		RateLimitDriveHz:       50,
-		RateLimitKVMHz:         100,
-		InvalidCmdThreshold:    10,
-		InvalidCmdTimeWindowMS: 30000,
+		RobotKeyFile:             "/var/lib/robot-agent/identity.pem",
+		CameraDevice:             "/dev/video0",
+		VideoCodec:               "h264",
+		VideoBitrate:             2000000,
+		VideoFPS:                 30,
+		ControlLossTimeoutMS:     500,
+		RateLimitDriveHz:         50,
+		RateLimitKVMHz:           100,
+		InvalidCmdThreshold:      10,
+		InvalidCmdTimeWindowMS:   30000,
+		DriveMaxLinearAccel:      2.0,
+		DriveMaxAngularAccel:     4.0,
+		DriveDeadband:            0.05,
+		DriveMaxSpeed:            1.0,
+		DriveShaperTickMS:        20,
+		KVMDeniedChords:          []string{"ctrl+alt+Delete", "alt+PrintScreen"},
+		KVMInputIdleTimeoutMS:    10000,
+		KVMTypeRateHz:            30,
+		KVMTypeMaxLength:         1024,
+		ProximityStopDistanceM:   0.3,
+		ProximitySlowDistanceM:   1.0,
+		ProximityArcDeg:          60,
+		ProximityMaxScanAgeMS:    500,
+		TelemetryBatteryMS:       1000,
+		TelemetryOdometryMS:      100,
+		TelemetryThermalMS:       2000,
+		TelemetrySystemMS:        1000,
+		TelemetryLinkMS:          1000,
+		TelemetryKeepAliveMS:     5000,
+		AuditDrainTimeoutMS:      2000,
+		ConcurrentOfferPolicy:    OfferPolicyReject,
+		ICERestartGraceMS:        10000,
+		SignalingKeepaliveMS:     10000,
+		SignalingLossToleranceMS: 30000,
+		TURNCredentialTTLS:       3600,
+		WebRTCStatsIntervalMS:    1000,
+		ICETransportPolicy:       ICEPolicyAll,
 	}
 
 	// Required
@@ -57,6 +170,10 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("ROBOT_ID is required")
 	}
 
+	if v := os.Getenv("ROBOT_KEY_FILE"); v != "" {
+		cfg.RobotKeyFile = v
+	}
+
 	cfg.GatewayWSURL = os.Getenv("GATEWAY_WS_URL")
 	if cfg.GatewayWSURL == "" {
 		return nil, fmt.Errorf("GATEWAY_WS_URL is required")
@@ -67,6 +184,15 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("GATEWAY_JWKS_URL is required")
 	}
 
+	cfg.GatewayTLS = TLSSettings{
+		CAFile:   os.Getenv("GATEWAY_TLS_CA_FILE"),
+		CertFile: os.Getenv("GATEWAY_TLS_CERT_FILE"),
+		KeyFile:  os.Getenv("GATEWAY_TLS_KEY_FILE"),
+	}
+	if v := os.Getenv("GATEWAY_TLS_SPKI_PINS"); v != "" {
+		cfg.GatewayTLS.SPKIPins = strings.Split(v, ",")
+	}
+
 	// Gateway HTTP URL for audit events (optional, derived from WS URL)
 	cfg.GatewayHTTPURL = os.Getenv("GATEWAY_HTTP_URL")
 	if cfg.GatewayHTTPURL == "" {
@@ -77,6 +203,9 @@ func Load() (*Config, error) {
 	if v := os.Getenv("CAMERA_DEVICE"); v != "" {
 		cfg.CameraDevice = v
 	}
+	if cfg.CameraDevice == "none" {
+		cfg.CameraDevice = "" // Headless robot: no camera to check
+	}
 	if v := os.Getenv("VIDEO_CODEC"); v != "" {
 		cfg.VideoCodec = v
 	}
@@ -90,6 +219,59 @@ func Load() (*Config, error) {
 	cfg.InvalidCmdThreshold = envInt("INVALID_CMD_THRESHOLD", cfg.InvalidCmdThreshold)
 	cfg.InvalidCmdTimeWindowMS = envInt("INVALID_CMD_TIME_WINDOW_MS", cfg.InvalidCmdTimeWindowMS)
 
+	// Drive shaping overrides
+	cfg.DriveMaxLinearAccel = envFloat("DRIVE_MAX_LINEAR_ACCEL", cfg.DriveMaxLinearAccel)
+	cfg.DriveMaxAngularAccel = envFloat("DRIVE_MAX_ANGULAR_ACCEL", cfg.DriveMaxAngularAccel)
+	cfg.DriveMaxLinearJerk = envFloat("DRIVE_MAX_LINEAR_JERK", cfg.DriveMaxLinearJerk)
+	cfg.DriveMaxAngularJerk = envFloat("DRIVE_MAX_ANGULAR_JERK", cfg.DriveMaxAngularJerk)
+	cfg.DriveDeadband = envFloat("DRIVE_DEADBAND", cfg.DriveDeadband)
+	cfg.DriveMaxSpeed = envFloat("DRIVE_MAX_SPEED", cfg.DriveMaxSpeed)
+	cfg.DriveShaperTickMS = envInt("DRIVE_SHAPER_TICK_MS", cfg.DriveShaperTickMS)
+	cfg.DriveDeadmanWindowMS = envInt("DRIVE_DEADMAN_WINDOW_MS", cfg.DriveDeadmanWindowMS)
+	if v := os.Getenv("DRIVE_SCOPE_SPEED_LIMITS"); v != "" {
+		limits, err := parseFloatMap(v)
+		if err != nil {
+			return nil, fmt.Errorf("DRIVE_SCOPE_SPEED_LIMITS: %w", err)
+		}
+		cfg.DriveScopeSpeedLimits = limits
+	}
+
+	// An empty KVM_DENIED_CHORDS disables chord filtering
+	if v, ok := os.LookupEnv("KVM_DENIED_CHORDS"); ok {
+		cfg.KVMDeniedChords = strings.Split(v, ",")
+	}
+	cfg.KVMInputIdleTimeoutMS = envInt("KVM_INPUT_IDLE_TIMEOUT_MS", cfg.KVMInputIdleTimeoutMS)
+	cfg.KVMTypeRateHz = envInt("KVM_TYPE_RATE_HZ", cfg.KVMTypeRateHz)
+	cfg.KVMTypeMaxLength = envInt("KVM_TYPE_MAX_LENGTH", cfg.KVMTypeMaxLength)
+
+	cfg.GeofenceFile = os.Getenv("GEOFENCE_FILE")
+
+	cfg.ProximityStopDistanceM = envFloat("PROXIMITY_STOP_DISTANCE_M", cfg.ProximityStopDistanceM)
+	cfg.ProximitySlowDistanceM = envFloat("PROXIMITY_SLOW_DISTANCE_M", cfg.ProximitySlowDistanceM)
+	cfg.ProximityArcDeg = envFloat("PROXIMITY_ARC_DEG", cfg.ProximityArcDeg)
+	cfg.ProximityMaxScanAgeMS = envInt("PROXIMITY_MAX_SCAN_AGE_MS", cfg.ProximityMaxScanAgeMS)
+
+	cfg.TelemetryBatteryMS = envInt("TELEMETRY_BATTERY_MS", cfg.TelemetryBatteryMS)
+	cfg.TelemetryOdometryMS = envInt("TELEMETRY_ODOMETRY_MS", cfg.TelemetryOdometryMS)
+	cfg.TelemetryThermalMS = envInt("TELEMETRY_THERMAL_MS", cfg.TelemetryThermalMS)
+	cfg.TelemetrySystemMS = envInt("TELEMETRY_SYSTEM_MS", cfg.TelemetrySystemMS)
+	cfg.TelemetryLinkMS = envInt("TELEMETRY_LINK_MS", cfg.TelemetryLinkMS)
+	cfg.TelemetryKeepAliveMS = envInt("TELEMETRY_KEEPALIVE_MS", cfg.TelemetryKeepAliveMS)
+
+	if v := os.Getenv("CONCURRENT_OFFER_POLICY"); v != "" {
+		if v != OfferPolicyReject && v != OfferPolicyTakeover {
+			return nil, fmt.Errorf("CONCURRENT_OFFER_POLICY: unknown policy %q", v)
+		}
+		cfg.ConcurrentOfferPolicy = v
+	}
+	cfg.ICERestartGraceMS = envInt("ICE_RESTART_GRACE_MS", cfg.ICERestartGraceMS)
+	cfg.SignalingKeepaliveMS = envInt("SIGNALING_KEEPALIVE_MS", cfg.SignalingKeepaliveMS)
+	cfg.SignalingLossToleranceMS = envInt("SIGNALING_LOSS_TOLERANCE_MS", cfg.SignalingLossToleranceMS)
+	cfg.SignalingICEBatching = envBool("SIGNALING_ICE_BATCHING", cfg.SignalingICEBatching)
+	cfg.AuditDrainTimeoutMS = envInt("AUDIT_DRAIN_TIMEOUT_MS", cfg.AuditDrainTimeoutMS)
+	cfg.WebRTCStatsIntervalMS = envInt("WEBRTC_STATS_INTERVAL_MS", cfg.WebRTCStatsIntervalMS)
+	cfg.MetricsAddr = os.Getenv("METRICS_ADDR")
+
 	// ICE servers
 	if v := os.Getenv("STUN_SERVERS"); v != "" {
 		cfg.STUNServers = strings.Split(v, ",")
@@ -97,10 +279,89 @@ func Load() (*Config, error) {
 	if v := os.Getenv("TURN_SERVERS"); v != "" {
 		cfg.TURNServers = strings.Split(v, ",")
 	}
+	cfg.TURNUsername = os.Getenv("TURN_USERNAME")
+	cfg.TURNPassword = os.Getenv("TURN_PASSWORD")
+	cfg.TURNSharedSecret = os.Getenv("TURN_SHARED_SECRET")
+	cfg.TURNCredentialsURL = os.Getenv("TURN_CREDENTIALS_URL")
+	cfg.TURNCredentialTTLS = envInt("TURN_CREDENTIAL_TTL_S", cfg.TURNCredentialTTLS)
+	if (cfg.TURNUsername == "") != (cfg.TURNPassword == "") {
+		return nil, fmt.Errorf("TURN_USERNAME and TURN_PASSWORD must be set together")
+	}
+	if cfg.TURNSharedSecret != "" && cfg.TURNCredentialsURL != "" {
+		return nil, fmt.Errorf("set only one of TURN_SHARED_SECRET and TURN_CREDENTIALS_URL")
+	}
+	if cfg.TURNCredentialTTLS <= 0 {
+		return nil, fmt.Errorf("TURN_CREDENTIAL_TTL_S: must be positive, got %d", cfg.TURNCredentialTTLS)
+	}
+	if err := loadICEPolicy(cfg); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
 
+// loadICEPolicy reads the candidate gathering restrictions.
+func loadICEPolicy(cfg *Config) error {
+	if v := os.Getenv("ICE_TRANSPORT_POLICY"); v != "" {
+		if v != ICEPolicyAll && v != ICEPolicyRelay {
+			return fmt.Errorf("ICE_TRANSPORT_POLICY: unknown policy %q", v)
+		}
+		cfg.ICETransportPolicy = v
+	}
+	// Gateway-issued credentials may carry the TURN URIs themselves
+	if cfg.ICETransportPolicy == ICEPolicyRelay && len(cfg.TURNServers) == 0 && cfg.TURNCredentialsURL == "" {
+		return fmt.Errorf("ICE_TRANSPORT_POLICY=relay requires TURN_SERVERS or TURN_CREDENTIALS_URL")
+	}
+
+	cfg.ICELANOnly = envBool("ICE_LAN_ONLY", cfg.ICELANOnly)
+	hasServers := len(cfg.STUNServers) > 0 || len(cfg.TURNServers) > 0 || cfg.TURNCredentialsURL != ""
+	switch {
+	case cfg.ICELANOnly && hasServers:
+		return fmt.Errorf("ICE_LAN_ONLY cannot be combined with STUN or TURN servers")
+	case cfg.ICELANOnly && cfg.ICETransportPolicy == ICEPolicyRelay:
+		return fmt.Errorf("ICE_LAN_ONLY cannot be combined with ICE_TRANSPORT_POLICY=relay")
+	case !cfg.ICELANOnly && !hasServers:
+		return fmt.Errorf("no ICE servers configured: set STUN_SERVERS or TURN_SERVERS, or ICE_LAN_ONLY=true for offline use")
+	}
+
+	if v := os.Getenv("ICE_NETWORK_TYPES"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			switch t {
+			case "udp4", "udp6", "tcp4", "tcp6":
+			default:
+				return fmt.Errorf("ICE_NETWORK_TYPES: unknown network type %q", t)
+			}
+		}
+		cfg.ICENetworkTypes = strings.Split(v, ",")
+	}
+	if v := os.Getenv("ICE_INTERFACES"); v != "" {
+		cfg.ICEInterfaces = strings.Split(v, ",")
+	}
+
+	cfg.ICEUDPPortMin = envInt("ICE_UDP_PORT_MIN", cfg.ICEUDPPortMin)
+	cfg.ICEUDPPortMax = envInt("ICE_UDP_PORT_MAX", cfg.ICEUDPPortMax)
+	if cfg.ICEUDPPortMin != 0 || cfg.ICEUDPPortMax != 0 {
+		if cfg.ICEUDPPortMin < 1 || cfg.ICEUDPPortMax > 65535 || cfg.ICEUDPPortMin > cfg.ICEUDPPortMax {
+			return fmt.Errorf("ICE_UDP_PORT_MIN/MAX: invalid range %d-%d", cfg.ICEUDPPortMin, cfg.ICEUDPPortMax)
+		}
+	}
+
+	if v := os.Getenv("ICE_NAT_1TO1_IPS"); v != "" {
+		for _, ip := range strings.Split(v, ",") {
+			if net.ParseIP(ip) == nil {
+				return fmt.Errorf("ICE_NAT_1TO1_IPS: invalid IP %q", ip)
+			}
+		}
+		cfg.ICENAT1To1IPs = strings.Split(v, ",")
+	}
+
+	cfg.ICEMDNSHostCandidates = envBool("ICE_MDNS_HOST_CANDIDATES", cfg.ICEMDNSHostCandidates)
+	if cfg.ICEMDNSHostCandidates && len(cfg.ICENAT1To1IPs) > 0 {
+		return fmt.Errorf("ICE_MDNS_HOST_CANDIDATES cannot be combined with ICE_NAT_1TO1_IPS")
+	}
+	return nil
+}
+
 // envInt returns the env var as int, or the default if unset or invalid.
 func envInt(key string, defaultVal int) int {
 	if v := os.Getenv(key); v != "" {
@@ -111,6 +372,43 @@ func envInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+// envBool returns the env var as bool, or the default if unset or invalid.
+func envBool(key string, defaultVal bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
+// envFloat returns the env var as float64, or the default if unset or invalid.
+func envFloat(key string, defaultVal float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return defaultVal
+}
+
+// parseFloatMap parses "key=value,key=value" into a map.
+func parseFloatMap(v string) (map[string]float64, error) {
+	result := make(map[string]float64)
+	for _, pair := range strings.Split(v, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid entry %q (want key=value)", pair)
+		}
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %w", key, err)
+		}
+		result[key] = f
+	}
+	return result, nil
+}
+
 // deriveHTTPURL converts ws://host:port/path to http://host:port.
 func deriveHTTPURL(wsURL string) string {
 	httpURL := strings.Replace(wsURL, "wss://", "https://", 1)
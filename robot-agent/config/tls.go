@@ -0,0 +1,297 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLS errors.
+var (
+	ErrNoCACerts    = errors.New("no certificates found in CA bundle")
+	ErrSPKIMismatch = errors.New("gateway certificate does not match any pinned SPKI hash")
+	ErrNoPeerCert   = errors.New("gateway presented no certificate")
+	ErrNoServerName = errors.New("gateway host is required to verify its certificate")
+)
+
+// TLSSettings configures TLS for connections to the gateway. The zero value
+// uses system roots and no client certificate.
+type TLSSettings struct {
+	CAFile   string   // PEM bundle trusted instead of system roots
+	SPKIPins []string // Base64 SHA-256 of an accepted SubjectPublicKeyInfo in the chain
+	CertFile string   // Client certificate for mTLS (PEM)
+	KeyFile  string   // Client private key for mTLS (PEM)
+}
+
+// Enabled reports whether any TLS option is set.
+func (s TLSSettings) Enabled() bool {
+	return s.CAFile != "" || len(s.SPKIPins) > 0 || s.CertFile != "" || s.KeyFile != ""
+}
+
+// TLSProvider builds gateway TLS configurations, reloading the CA bundle
+// and client certificate when their files change so rotated certificates
+// apply to new connections without a restart.
+type TLSProvider struct {
+	settings TLSSettings
+	pins     map[string]bool
+
+	mu       sync.Mutex
+	config   *tls.Config
+	modTimes map[string]time.Time
+	onError  func(error)
+}
+
+// NewTLSProvider loads the configured files. It fails if they are missing
+// or invalid, so misconfiguration is caught at startup.
+func NewTLSProvider(settings TLSSettings) (*TLSProvider, error) {
+	if (settings.CertFile == "") != (settings.KeyFile == "") {
+		return nil, fmt.Errorf("TLS client certificate and key must be set together")
+	}
+
+	p := &TLSProvider{settings: settings, pins: make(map[string]bool)}
+	for _, pin := range settings.SPKIPins {
+		if raw, err := base64.StdEncoding.DecodeString(pin); err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("invalid SPKI pin %q: want base64 SHA-256", pin)
+		}
+		p.pins[pin] = true
+	}
+
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetReloadErrorCallback sets a callback for failed reloads. The previous
+// configuration stays in use until the files are valid again.
+func (p *TLSProvider) SetReloadErrorCallback(fn func(error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onError = fn
+}
+
+// Reload rebuilds the TLS configuration from the configured files.
+func (p *TLSProvider) Reload() error {
+	cfg, modTimes, err := p.build()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.config, p.modTimes = cfg, modTimes
+	p.mu.Unlock()
+	return nil
+}
+
+// Config returns the current TLS configuration, reloading it first if a
+// configured file has changed.
+func (p *TLSProvider) Config() *tls.Config {
+	if p.changed() {
+		if err := p.Reload(); err != nil {
+			p.mu.Lock()
+			onError := p.onError
+			p.mu.Unlock()
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.config.Clone()
+}
+
+// ClientConfig returns a TLS configuration for gateway clients dialing
+// serverName, the host from the gateway URL. The configuration itself never
+// changes: each handshake verifies the gateway against, and presents the
+// client certificate from, the current files. It therefore applies wherever
+// the client runs its own handshake, including through an HTTP proxy.
+func (p *TLSProvider) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// The chain is verified by verifyConnection against the current
+		// CA bundle instead of a pool fixed here
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return p.verifyConnection(cs, serverName)
+		},
+		GetClientCertificate: p.clientCertificate,
+	}
+}
+
+// HTTPClient returns an HTTP client whose connections use the current
+// configuration, verified against the host of each request.
+func (p *TLSProvider) HTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &hostTransport{provider: p, proxy: http.ProxyFromEnvironment},
+	}
+}
+
+// hostTransport keeps one transport per host so that each connection is
+// verified against the host it was dialed for.
+type hostTransport struct {
+	provider *TLSProvider
+	proxy    func(*http.Request) (*url.URL, error)
+
+	mu         sync.Mutex
+	transports map[string]*http.Transport
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.transport(req.URL.Hostname()).RoundTrip(req)
+}
+
+func (t *hostTransport) transport(host string) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tr, ok := t.transports[host]; ok {
+		return tr
+	}
+	if t.transports == nil {
+		t.transports = make(map[string]*http.Transport)
+	}
+	tr := &http.Transport{
+		Proxy:           t.proxy,
+		TLSClientConfig: t.provider.ClientConfig(host),
+		IdleConnTimeout: 90 * time.Second,
+	}
+	t.transports[host] = tr
+	return tr
+}
+
+// CloseIdleConnections closes idle connections of every host's transport.
+func (t *hostTransport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tr := range t.transports {
+		tr.CloseIdleConnections()
+	}
+}
+
+func (p *TLSProvider) files() []string {
+	var files []string
+	for _, f := range []string{p.settings.CAFile, p.settings.CertFile, p.settings.KeyFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+func (p *TLSProvider) changed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, f := range p.files() {
+		info, err := os.Stat(f)
+		if err != nil || !info.ModTime().Equal(p.modTimes[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *TLSProvider) build() (*tls.Config, map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+	for _, f := range p.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		modTimes[f] = info.ModTime()
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if p.settings.CAFile != "" {
+		data, err := os.ReadFile(p.settings.CAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, nil, fmt.Errorf("%s: %w", p.settings.CAFile, ErrNoCACerts)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if p.settings.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(p.settings.CertFile, p.settings.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(p.pins) > 0 {
+		// Runs after standard chain verification, which stays enabled
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return p.verifyPins(cs.VerifiedChains)
+		}
+	}
+
+	return cfg, modTimes, nil
+}
+
+// verifyConnection verifies the gateway's chain against the current CA
+// bundle, or system roots without one, and its name or IP address against
+// the dialed host, then checks the pins. The host is passed in because the
+// connection state leaves the server name empty for IP addresses.
+func (p *TLSProvider) verifyConnection(cs tls.ConnectionState, host string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return ErrNoPeerCert
+	}
+	if host == "" {
+		return ErrNoServerName
+	}
+	opts := x509.VerifyOptions{
+		Roots:         p.Config().RootCAs,
+		DNSName:       host,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	chains, err := cs.PeerCertificates[0].Verify(opts)
+	if err != nil {
+		return err
+	}
+	if len(p.pins) > 0 {
+		return p.verifyPins(chains)
+	}
+	return nil
+}
+
+// clientCertificate returns the current client certificate, or none if
+// mTLS is not configured.
+func (p *TLSProvider) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cfg := p.Config()
+	if len(cfg.Certificates) == 0 {
+		return &tls.Certificate{}, nil
+	}
+	return &cfg.Certificates[0], nil
+}
+
+func (p *TLSProvider) verifyPins(chains [][]*x509.Certificate) error {
+	for _, chain := range chains {
+		for _, cert := range chain {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			if p.pins[base64.StdEncoding.EncodeToString(sum[:])] {
+				return nil
+			}
+		}
+	}
+	return ErrSPKIMismatch
+}
@@ -0,0 +1,366 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates for TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for cn, valid for 127.0.0.1.
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	return ca.issueFor(t, cn, usage, nil, []net.IP{net.ParseIP("127.0.0.1")})
+}
+
+// issueFor returns a PEM certificate and key for cn with the given SANs.
+func (ca *testCA) issueFor(t *testing.T, cn string, usage x509.ExtKeyUsage, dnsNames []string, ips []net.IP) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// newTLSGateway starts a TLS server with a certificate from ca that
+// echoes the client certificate's common name.
+func newTLSGateway(t *testing.T, ca *testCA, clientCAs *x509.CertPool) *httptest.Server {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, "gateway", x509.ExtKeyUsageServerAuth)
+	return newTLSServer(t, certPEM, keyPEM, clientCAs)
+}
+
+// newTLSServer starts a TLS server with the given certificate that echoes
+// the client certificate's common name.
+func newTLSServer(t *testing.T, certPEM, keyPEM []byte, clientCAs *x509.CertPool) *httptest.Server {
+	t.Helper()
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	if clientCAs != nil {
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+		server.TLS.ClientCAs = clientCAs
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Distinct mtimes regardless of filesystem timestamp granularity
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, p *TLSProvider, url string) (string, error) {
+	t.Helper()
+	client := p.HTTPClient(5 * time.Second)
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body := make([]byte, 64)
+	n, _ := resp.Body.Read(body)
+	return string(body[:n]), nil
+}
+
+func spkiPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestTLSProvider_CABundle(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTLSGateway(t, ca, nil)
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	p, err := NewTLSProvider(TLSSettings{CAFile: caFile})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if _, err := get(t, p, server.URL); err != nil {
+		t.Errorf("expected gateway signed by the bundle CA to be trusted: %v", err)
+	}
+
+	other := newTestCA(t)
+	writeFile(t, caFile, other.pem, time.Now().Add(time.Minute))
+	if _, err := get(t, p, server.URL); err == nil {
+		t.Error("expected reloaded bundle without the gateway CA to be rejected")
+	}
+}
+
+func TestTLSProvider_VerifiesDialedHost(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	// Signed by the trusted CA but issued for another name, without the
+	// IP address the gateway is dialed at
+	certPEM, keyPEM := ca.issueFor(t, "evil", x509.ExtKeyUsageServerAuth, []string{"evil.example"}, nil)
+	server := newTLSServer(t, certPEM, keyPEM, nil)
+
+	p, err := NewTLSProvider(TLSSettings{CAFile: caFile, SPKIPins: []string{spkiPin(ca.cert)}})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	_, err = get(t, p, server.URL)
+	var hostErr x509.HostnameError
+	if !errors.As(err, &hostErr) {
+		t.Errorf("expected hostname error for certificate without the IP SAN, got %v", err)
+	}
+}
+
+func TestTLSProvider_SPKIPinning(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTLSGateway(t, ca, nil)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	pinned, err := NewTLSProvider(TLSSettings{CAFile: caFile, SPKIPins: []string{spkiPin(ca.cert)}})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if _, err := get(t, pinned, server.URL); err != nil {
+		t.Errorf("expected pinned CA to be accepted: %v", err)
+	}
+
+	wrong, err := NewTLSProvider(TLSSettings{CAFile: caFile, SPKIPins: []string{spkiPin(newTestCA(t).cert)}})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if _, err := get(t, wrong, server.URL); !errors.Is(err, ErrSPKIMismatch) {
+		t.Errorf("expected ErrSPKIMismatch, got %v", err)
+	}
+}
+
+func TestTLSProvider_MutualTLSWithRotation(t *testing.T) {
+	ca := newTestCA(t)
+	clientCA := newTestCA(t)
+	clientPool := x509.NewCertPool()
+	clientPool.AddCert(clientCA.cert)
+	server := newTLSGateway(t, ca, clientPool)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+	writeFile(t, caFile, ca.pem, time.Now())
+	certPEM, keyPEM := clientCA.issue(t, "robot-1", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+
+	p, err := NewTLSProvider(TLSSettings{CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if cn, err := get(t, p, server.URL); err != nil || cn != "robot-1" {
+		t.Fatalf("expected client cert robot-1, got %q, %v", cn, err)
+	}
+
+	// Rotate the client certificate on disk
+	certPEM, keyPEM = clientCA.issue(t, "robot-1-rotated", x509.ExtKeyUsageClientAuth)
+	later := time.Now().Add(time.Minute)
+	writeFile(t, certFile, certPEM, later)
+	writeFile(t, keyFile, keyPEM, later)
+	if cn, err := get(t, p, server.URL); err != nil || cn != "robot-1-rotated" {
+		t.Errorf("expected rotated client cert, got %q, %v", cn, err)
+	}
+}
+
+func TestTLSProvider_KeepsConfigOnBadReload(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTLSGateway(t, ca, nil)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	p, err := NewTLSProvider(TLSSettings{CAFile: caFile})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	var reloadErr error
+	p.SetReloadErrorCallback(func(err error) { reloadErr = err })
+
+	// A half-written bundle must not break connections
+	writeFile(t, caFile, []byte("-----BEGIN"), time.Now().Add(time.Minute))
+	if _, err := get(t, p, server.URL); err != nil {
+		t.Errorf("expected previous CA to stay in use: %v", err)
+	}
+	if !errors.Is(reloadErr, ErrNoCACerts) {
+		t.Errorf("expected reload error to be reported, got %v", reloadErr)
+	}
+}
+
+// newConnectProxy starts an HTTP CONNECT proxy and returns its URL.
+func newConnectProxy(t *testing.T) *url.URL {
+	t.Helper()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	t.Cleanup(proxy.Close)
+	u, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestTLSProvider_ThroughProxy(t *testing.T) {
+	ca := newTestCA(t)
+	clientCA := newTestCA(t)
+	clientPool := x509.NewCertPool()
+	clientPool.AddCert(clientCA.cert)
+	server := newTLSGateway(t, ca, clientPool)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+	writeFile(t, caFile, ca.pem, time.Now())
+	certPEM, keyPEM := clientCA.issue(t, "robot-1", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+
+	p, err := NewTLSProvider(TLSSettings{
+		CAFile:   caFile,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		SPKIPins: []string{spkiPin(ca.cert)},
+	})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+
+	// The CA, pins and client certificate must apply to the tunnelled
+	// handshake, not only to direct dials
+	client := p.HTTPClient(5 * time.Second)
+	defer client.CloseIdleConnections()
+	client.Transport.(*hostTransport).proxy = http.ProxyURL(newConnectProxy(t))
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("get through proxy: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "robot-1" {
+		t.Errorf("expected client cert robot-1 through proxy, got %q", body)
+	}
+}
+
+func TestNewTLSProvider_InvalidSettings(t *testing.T) {
+	tests := map[string]TLSSettings{
+		"cert without key": {CertFile: "client.pem"},
+		"missing CA file":  {CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"malformed pin":    {SPKIPins: []string{"not-a-hash"}},
+	}
+	for name, settings := range tests {
+		if _, err := NewTLSProvider(settings); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
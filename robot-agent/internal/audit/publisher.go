@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -16,9 +17,15 @@ type EventType string
 
 // Event types.
 const (
+	EventSessionStarted          EventType = "SESSION_STARTED"
 	EventSessionRevoked          EventType = "SESSION_REVOKED"
 	EventSessionEnded            EventType = "SESSION_ENDED"
 	EventInvalidCommandThreshold EventType = "INVALID_COMMAND_THRESHOLD"
+	EventGeofenceViolation       EventType = "GEOFENCE_VIOLATION"
+	EventPrivilegedAction        EventType = "PRIVILEGED_ACTION"
+	EventKVMChordBlocked         EventType = "KVM_CHORD_BLOCKED"
+	EventKVMTextInput            EventType = "KVM_TEXT_INPUT"
+	EventOfferRejected           EventType = "OFFER_REJECTED"
 )
 
 // Event represents an audit event to be published.
@@ -43,6 +50,10 @@ type Publisher struct {
 	gatewayURL string
 	robotID    string
 	client     HTTPClient
+
+	mu      sync.Mutex
+	pending int
+	idle    chan struct{} // closed when pending drops to zero
 }
 
 // NewPublisher creates a new audit event publisher.
@@ -54,7 +65,7 @@ func NewPublisher(gatewayURL, robotID string) *Publisher {
 	}
 }
 
-// SetHTTPClient allows setting a custom HTTP client (for testing).
+// SetHTTPClient sets a custom HTTP client (e.g. for mTLS or testing).
 func (p *Publisher) SetHTTPClient(client HTTPClient) {
 	p.client = client
 }
@@ -62,13 +73,59 @@ func (p *Publisher) SetHTTPClient(client HTTPClient) {
 // Publish sends an audit event to the gateway (async, fire-and-forget).
 // Errors are logged but not returned since this is non-blocking.
 func (p *Publisher) Publish(event Event) {
+	p.begin()
 	go func() {
+		defer p.end()
 		if err := p.publishAsync(event); err != nil {
 			log.Printf("audit: failed to publish event %s: %v", event.EventType, err)
 		}
 	}()
 }
 
+// Pending returns the number of events still being delivered.
+func (p *Publisher) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pending
+}
+
+// Drain waits until all published events have been delivered or ctx is
+// done. Used at shutdown so audit events are not abandoned.
+func (p *Publisher) Drain(ctx context.Context) error {
+	p.mu.Lock()
+	if p.pending == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	idle := p.idle
+	p.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d audit events undelivered: %w", p.Pending(), ctx.Err())
+	}
+}
+
+func (p *Publisher) begin() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending == 0 {
+		p.idle = make(chan struct{})
+	}
+	p.pending++
+}
+
+func (p *Publisher) end() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending--
+	if p.pending == 0 {
+		close(p.idle)
+	}
+}
+
 // PublishSync sends an audit event synchronously (for testing).
 func (p *Publisher) PublishSync(event Event) error {
 	return p.publishAsync(event)
@@ -2,7 +2,9 @@ package audit
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"testing"
@@ -176,3 +178,49 @@ func TestPublisher_NetworkError(t *testing.T) {
 		t.Error("expected error for network failure")
 	}
 }
+
+// blockingHTTPClient holds requests until released.
+type blockingHTTPClient struct {
+	release chan struct{}
+}
+
+func (b *blockingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	<-b.release
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+	}, nil
+}
+
+func TestPublisher_Drain(t *testing.T) {
+	client := &blockingHTTPClient{release: make(chan struct{})}
+	publisher := NewPublisher("http://gateway:8080", "robot_123")
+	publisher.SetHTTPClient(client)
+
+	if err := publisher.Drain(context.Background()); err != nil {
+		t.Fatalf("drain with nothing pending: %v", err)
+	}
+
+	publisher.Publish(Event{EventType: EventSessionEnded, SessionID: "ses_abc"})
+	publisher.Publish(Event{EventType: EventSessionEnded, SessionID: "ses_def"})
+	if got := publisher.Pending(); got != 2 {
+		t.Errorf("expected 2 pending events, got %d", got)
+	}
+
+	// Deadline expires while the gateway is unresponsive
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := publisher.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	close(client.release)
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	if err := publisher.Drain(ctx2); err != nil {
+		t.Errorf("expected drained queue, got %v", err)
+	}
+	if got := publisher.Pending(); got != 0 {
+		t.Errorf("expected no pending events, got %d", got)
+	}
+}
@@ -0,0 +1,64 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"slices"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// defaultCommands are the control messages every RobotAPI accepts.
+var defaultCommands = []protocol.MessageType{
+	protocol.TypeDrive,
+	protocol.TypeKVMKey,
+	protocol.TypeKVMMouse,
+	protocol.TypeEStop,
+}
+
+// SetModeController enables the mode command for robots with selectable modes.
+func (h *Handler) SetModeController(mc ModeController) {
+	h.modes = mc
+}
+
+// SetCapabilityProvider sets the source of robot-specific capabilities.
+// Without one, all base commands are advertised without velocity limits.
+func (h *Handler) SetCapabilityProvider(p CapabilityProvider) {
+	h.capabilities = p
+}
+
+// SetModeChangeCallback sets the callback for successful mode switches.
+func (h *Handler) SetModeChangeCallback(fn ModeChangeCallback) {
+	h.onModeChange = fn
+}
+
+// Capabilities returns the capability descriptor sent to the console.
+func (h *Handler) Capabilities() protocol.Capabilities {
+	var caps protocol.Capabilities
+	if h.capabilities != nil {
+		caps = h.capabilities.Capabilities()
+		caps.Commands = slices.Clone(caps.Commands)
+	} else {
+		caps.Commands = slices.Clone(defaultCommands)
+	}
+
+	if h.modes != nil {
+		caps.Modes = slices.Clone(h.modes.Modes())
+		caps.Mode = h.modes.Mode()
+		if !slices.Contains(caps.Commands, protocol.TypeMode) {
+			caps.Commands = append(caps.Commands, protocol.TypeMode)
+		}
+	}
+
+	caps.MouseModes = h.MouseModes()
+	if len(caps.MouseModes) > 1 && !slices.Contains(caps.Commands, protocol.TypeKVMMouseMode) {
+		caps.Commands = append(caps.Commands, protocol.TypeKVMMouseMode)
+	}
+
+	h.motionMu.Lock()
+	if h.shaper != nil {
+		caps.MaxSpeed = h.shaper.MaxSpeed()
+	}
+	h.motionMu.Unlock()
+
+	return caps
+}
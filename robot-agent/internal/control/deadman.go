@@ -0,0 +1,90 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"log"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// defaultHoldWindow is the deadman window for held commands when no
+// agent-wide window is configured.
+const defaultHoldWindow = 250 * time.Millisecond
+
+// deadmanTimer abstracts time.Timer so deadman expiry can be driven by tests.
+type deadmanTimer interface {
+	Stop() bool
+}
+
+func newRealTimer(d time.Duration, f func()) deadmanTimer {
+	return time.AfterFunc(d, f)
+}
+
+// SetDeadmanWindow enables deadman mode for all drive commands: each command
+// lapses to zero velocity unless refreshed within d. Commands may shorten or
+// extend this with valid_ms. Zero leaves deadman opt-in per command.
+func (h *Handler) SetDeadmanWindow(d time.Duration) {
+	h.motionMu.Lock()
+	defer h.motionMu.Unlock()
+	h.deadmanWindow = d
+}
+
+// driveWindow returns how long a drive command stays in effect without a
+// refresh, or zero if it holds until the next command.
+func (h *Handler) driveWindow(msg *protocol.DriveMessage) time.Duration {
+	if msg.ValidMS > 0 {
+		return time.Duration(msg.ValidMS) * time.Millisecond
+	}
+
+	h.motionMu.Lock()
+	window := h.deadmanWindow
+	h.motionMu.Unlock()
+
+	if window <= 0 && msg.Hold {
+		window = defaultHoldWindow
+	}
+	return window
+}
+
+// armDeadmanLocked schedules a stop after window unless the command is
+// already a stop (caller must hold motionMu).
+func (h *Handler) armDeadmanLocked(window time.Duration, v, w float64) {
+	if window <= 0 || (v == 0 && w == 0) {
+		return
+	}
+	gen := h.deadmanGen
+	h.deadman = h.newTimer(window, func() { h.expireDeadman(gen) })
+}
+
+// disarmDeadmanLocked cancels a pending deadman stop (caller must hold motionMu).
+func (h *Handler) disarmDeadmanLocked() {
+	if h.deadman != nil {
+		h.deadman.Stop()
+		h.deadman = nil
+	}
+	// Invalidate a timer that already fired but is waiting for the lock
+	h.deadmanGen++
+}
+
+// expireDeadman zeroes velocity at once when a drive command lapses,
+// bypassing the shaper. This is independent of the session-level
+// control-loss safe-stop: the session stays active and the next drive
+// command resumes motion.
+func (h *Handler) expireDeadman(gen uint64) {
+	h.motionMu.Lock()
+	defer h.motionMu.Unlock()
+
+	if gen != h.deadmanGen {
+		return
+	}
+	h.deadman = nil
+
+	h.haltLocked()
+	if err := h.robot.Drive(0, 0); err != nil {
+		log.Printf("control: drive failed on deadman release, issuing e-stop: %v", err)
+		if err := h.robot.EStop(); err != nil {
+			log.Printf("control: e-stop after deadman failure failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package control
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// manualTimer records armed deadman timers so tests can fire them.
+type manualTimer struct {
+	mu      sync.Mutex
+	armed   []time.Duration
+	fire    func()
+	stopped int
+}
+
+func (m *manualTimer) newTimer(d time.Duration, f func()) deadmanTimer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.armed = append(m.armed, d)
+	m.fire = f
+	return m
+}
+
+func (m *manualTimer) Stop() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped++
+	return true
+}
+
+// expire fires the most recently armed timer.
+func (m *manualTimer) expire() {
+	m.mu.Lock()
+	f := m.fire
+	m.mu.Unlock()
+	f()
+}
+
+func (m *manualTimer) lastArmed() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.armed) == 0 {
+		return 0
+	}
+	return m.armed[len(m.armed)-1]
+}
+
+func newDeadmanHandler(robot *mockRobotAPI) (*Handler, *manualTimer) {
+	timer := &manualTimer{}
+	h := NewHandler(robot, nil, nil, nil, 500*time.Millisecond)
+	h.newTimer = timer.newTimer
+	return h, timer
+}
+
+func driveMsg(v float64, hold bool, validMS int) *protocol.DriveMessage {
+	return &protocol.DriveMessage{
+		Type:    protocol.TypeDrive,
+		V:       v,
+		T:       time.Now().UnixMilli(),
+		Hold:    hold,
+		ValidMS: validMS,
+	}
+}
+
+func TestDeadman_WindowSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  time.Duration
+		hold    bool
+		validMS int
+		want    time.Duration
+	}{
+		{"no deadman", 0, false, 0, 0},
+		{"hold uses default", 0, true, 0, defaultHoldWindow},
+		{"hold uses configured window", 150 * time.Millisecond, true, 0, 150 * time.Millisecond},
+		{"deadman mode applies to all", 150 * time.Millisecond, false, 0, 150 * time.Millisecond},
+		{"valid_ms overrides", 150 * time.Millisecond, true, 400, 400 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, timer := newDeadmanHandler(&mockRobotAPI{})
+			h.SetDeadmanWindow(tt.window)
+
+			if err := h.HandleDrive(driveMsg(0.5, tt.hold, tt.validMS)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := timer.lastArmed(); got != tt.want {
+				t.Errorf("expected window %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDeadman_ExpiryZeroesVelocity(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h, timer := newDeadmanHandler(robot)
+
+	if err := h.HandleDrive(driveMsg(0.5, true, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	timer.expire()
+
+	if got := robot.lastDrive(); got.V != 0 || got.W != 0 {
+		t.Errorf("expected zero velocity after deadman expiry, got %+v", got)
+	}
+	if robot.estopCalls != 0 {
+		t.Error("deadman expiry must not e-stop")
+	}
+
+	// The session is unaffected: the next command resumes motion
+	if err := h.HandleDrive(driveMsg(0.3, true, 0)); err != nil {
+		t.Fatalf("unexpected error after expiry: %v", err)
+	}
+	if got := robot.lastDrive(); got.V != 0.3 {
+		t.Errorf("expected motion to resume, got %+v", got)
+	}
+}
+
+func TestDeadman_ExpiryBypassesShaper(t *testing.T) {
+	robot := &mockRobotAPI{}
+	clock := newFakeClock()
+	h, tk := newShapedHandler(robot, clock)
+	timer := &manualTimer{}
+	h.newTimer = timer.newTimer
+
+	if err := h.HandleDrive(driveMsg(1.0, true, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tk.tick(clock, 100*time.Millisecond)
+	waitForDriveCalls(t, robot, 2)
+
+	timer.expire()
+	if got := robot.lastDrive(); got.V != 0 || got.W != 0 {
+		t.Errorf("expected velocity zeroed at once on expiry, got %+v", got)
+	}
+	if v, _ := h.shaper.Output(); v != 0 {
+		t.Errorf("expected shaper state reset, got %f", v)
+	}
+}
+
+func TestDeadman_RefreshCancelsPreviousWindow(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h, timer := newDeadmanHandler(robot)
+
+	h.HandleDrive(driveMsg(0.5, true, 0))
+	stale := timer.fire
+	h.HandleDrive(driveMsg(0.6, true, 0))
+
+	// A timer from the superseded command must not stop the robot
+	stale()
+	if got := robot.lastDrive(); got.V != 0.6 {
+		t.Errorf("stale deadman timer stopped the robot: %+v", got)
+	}
+	if timer.stopped == 0 {
+		t.Error("expected previous timer to be stopped")
+	}
+}
+
+func TestDeadman_NotArmedForStopOrEStop(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h, timer := newDeadmanHandler(robot)
+	h.SetDeadmanWindow(100 * time.Millisecond)
+
+	h.HandleDrive(driveMsg(0, true, 0))
+	if len(timer.armed) != 0 {
+		t.Error("zero-velocity command should not arm the deadman")
+	}
+
+	h.HandleDrive(driveMsg(0.5, true, 0))
+	pending := timer.fire
+	h.HandleEStop(&protocol.EStopMessage{Type: protocol.TypeEStop, T: time.Now().UnixMilli()})
+	calls := len(robot.driveCalls)
+
+	pending()
+	if len(robot.driveCalls) != calls {
+		t.Error("deadman should be disarmed by e-stop")
+	}
+}
+
+func TestDeadman_ValidityRange(t *testing.T) {
+	h, _ := newDeadmanHandler(&mockRobotAPI{})
+
+	for _, validMS := range []int{-1, maxDriveValidityMS + 1} {
+		if err := h.HandleDrive(driveMsg(0.5, false, validMS)); err == nil {
+			t.Errorf("expected valid_ms=%d to be rejected", validMS)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package control
 
 import (
 	"encoding/json"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
@@ -15,6 +17,40 @@ type Handler struct {
 	scopes    ScopeChecker
 	session   SessionChecker
 	validator *Validator
+	sequences *SequenceTracker
+	limiter   *RateLimiter
+	filters   []DriveFilter
+
+	inputs         *InputTracker
+	keyPolicy      *KeyPolicy
+	onChordBlocked ChordBlockedCallback
+	inputIdle      time.Duration
+
+	typingMu     sync.Mutex
+	typeStop     chan struct{}
+	typeInterval time.Duration
+	maxTypeLen   int
+	onTextTyped  TextTypedCallback
+
+	mouseMu   sync.Mutex
+	pointer   AbsolutePointer
+	mouseMode string
+	lastX     float64
+	lastY     float64
+
+	modes        ModeController
+	capabilities CapabilityProvider
+	onModeChange ModeChangeCallback
+
+	motionMu  sync.Mutex
+	shaper    *MotionShaper
+	rampStop  chan struct{}
+	newTicker func(time.Duration) ticker
+
+	deadmanWindow time.Duration
+	deadman       deadmanTimer
+	deadmanGen    uint64
+	newTimer      func(time.Duration, func()) deadmanTimer
 }
 
 // NewHandler creates a new control message handler.
@@ -31,6 +67,14 @@ func NewHandler(
 		scopes:    scopes,
 		session:   session,
 		validator: NewValidator(staleThreshold),
+		sequences: NewSequenceTracker(),
+		inputs:    NewInputTracker(),
+		mouseMode: protocol.MouseModeRelative,
+		newTicker: newRealTicker,
+		newTimer:  newRealTimer,
+
+		typeInterval: defaultTypeInterval,
+		maxTypeLen:   defaultMaxTypeLength,
 	}
 }
 
@@ -39,6 +83,43 @@ func (h *Handler) RobotAPI() RobotAPI {
 	return h.robot
 }
 
+// SetClockOffsetSource enables clock-offset correction for staleness checks.
+func (h *Handler) SetClockOffsetSource(src ClockOffsetSource) {
+	h.validator.SetClockOffsetSource(src)
+}
+
+// AddDriveFilter appends a filter applied to drive commands in order.
+// Filters must be added before the handler starts processing messages.
+func (h *Handler) AddDriveFilter(f DriveFilter) {
+	h.filters = append(h.filters, f)
+}
+
+// SequenceStats returns ordering statistics for sequenced control messages.
+func (h *Handler) SequenceStats() SequenceStats {
+	return h.sequences.Stats()
+}
+
+// ResetSequences clears per-stream sequence state (e.g., for new session).
+func (h *Handler) ResetSequences() {
+	h.sequences.Reset()
+}
+
+// SetRateLimiter enables per-type command rate limiting. E-stop is never
+// limited.
+func (h *Handler) SetRateLimiter(rl *RateLimiter) {
+	h.limiter = rl
+}
+
+// ResetSession clears per-session state: sequences, rate limits and the
+// negotiated mouse mode.
+func (h *Handler) ResetSession() {
+	h.ResetSequences()
+	if h.limiter != nil {
+		h.limiter.Reset()
+	}
+	h.resetMouseMode()
+}
+
 // HandleMessage processes a raw JSON message and dispatches to the handler.
 func (h *Handler) HandleMessage(data []byte) (*protocol.AckMessage, error) {
 	var base protocol.BaseMessage
@@ -52,6 +133,10 @@ func (h *Handler) HandleMessage(data []byte) (*protocol.AckMessage, error) {
 		return nil, ErrSessionRevoked
 	}
 
+	if h.limiter != nil && !h.limiter.Allow(base.Type) {
+		return nil, ErrRateLimited
+	}
+
 	var err error
 	var refT int64
 
@@ -83,6 +168,24 @@ func (h *Handler) HandleMessage(data []byte) (*protocol.AckMessage, error) {
 		refT = msg.T
 		err = h.HandleKVMMouse(&msg)
 
+	case protocol.TypeKVMMouseMode:
+		var msg protocol.KVMMouseModeMessage
+		if err = json.Unmarshal(data, &msg); err != nil {
+			h.notifyInvalid()
+			return nil, ErrInvalidJSON
+		}
+		refT = msg.T
+		err = h.HandleKVMMouseMode(&msg)
+
+	case protocol.TypeKVMType:
+		var msg protocol.KVMTypeMessage
+		if err = json.Unmarshal(data, &msg); err != nil {
+			h.notifyInvalid()
+			return nil, ErrInvalidJSON
+		}
+		refT = msg.T
+		err = h.HandleKVMType(&msg)
+
 	case protocol.TypeEStop:
 		var msg protocol.EStopMessage
 		if err = json.Unmarshal(data, &msg); err != nil {
@@ -92,6 +195,15 @@ func (h *Handler) HandleMessage(data []byte) (*protocol.AckMessage, error) {
 		refT = msg.T
 		err = h.HandleEStop(&msg)
 
+	case protocol.TypeMode:
+		var msg protocol.ModeMessage
+		if err = json.Unmarshal(data, &msg); err != nil {
+			h.notifyInvalid()
+			return nil, ErrInvalidJSON
+		}
+		refT = msg.T
+		err = h.HandleMode(&msg)
+
 	case protocol.TypePing:
 		// Ping acts as heartbeat - resets control loss timer
 		h.notifyValid()
@@ -124,7 +236,24 @@ func (h *Handler) HandleDrive(msg *protocol.DriveMessage) error {
 		return err
 	}
 
-	if err := h.robot.Drive(msg.V, msg.W); err != nil {
+	if !h.sequences.Accept(protocol.TypeDrive, msg.Seq) {
+		return ErrOutOfOrder
+	}
+
+	v, w := msg.V, msg.W
+	for _, f := range h.filters {
+		var err error
+		if v, w, err = f.FilterDrive(v, w); err != nil {
+			// A rejected command must not leave the previous velocity
+			// applied, nor count as live control
+			if stopErr := h.StopNow(); stopErr != nil {
+				return stopErr
+			}
+			return err
+		}
+	}
+
+	if err := h.drive(v, w, h.driveWindow(msg)); err != nil {
 		return err
 	}
 
@@ -143,9 +272,26 @@ func (h *Handler) HandleKVMKey(msg *protocol.KVMKeyMessage) error {
 		return err
 	}
 
+	// A late key-up still applies so the key cannot stay held
+	if msg.Action == "up" {
+		h.sequences.AcceptRelease(protocol.TypeKVMKey, msg.Seq)
+	} else if !h.sequences.Accept(protocol.TypeKVMKey, msg.Seq) {
+		return ErrOutOfOrder
+	}
+
+	// Manual input takes over from any text being typed
+	h.CancelTyping()
+
+	if msg.Action == "down" {
+		if err := h.checkKeyPolicy(msg.Key, msg.Modifiers); err != nil {
+			return err
+		}
+	}
+
 	if err := h.robot.SendKey(msg.Key, msg.Action, msg.Modifiers); err != nil {
 		return err
 	}
+	h.inputs.Key(msg.Key, msg.Action)
 
 	h.notifyValid()
 	return nil
@@ -162,9 +308,28 @@ func (h *Handler) HandleKVMMouse(msg *protocol.KVMMouseMessage) error {
 		return err
 	}
 
-	if err := h.robot.SendMouse(msg.DX, msg.DY, msg.Buttons, msg.Scroll); err != nil {
+	// A late message applies only the buttons it releases, so a button
+	// cannot stay held
+	_, held := h.inputs.Held()
+	if held&^msg.Buttons != 0 {
+		if !h.sequences.AcceptRelease(protocol.TypeKVMMouse, msg.Seq) {
+			return h.releaseLateButtons(held & msg.Buttons)
+		}
+	} else if !h.sequences.Accept(protocol.TypeKVMMouse, msg.Seq) {
+		return ErrOutOfOrder
+	}
+
+	if err := h.checkMouseMode(msg); err != nil {
+		h.notifyInvalid()
+		return err
+	}
+
+	h.CancelTyping()
+
+	if err := h.sendMouse(msg); err != nil {
 		return err
 	}
+	h.inputs.Buttons(msg.Buttons)
 
 	h.notifyValid()
 	return nil
@@ -177,6 +342,10 @@ func (h *Handler) HandleEStop(msg *protocol.EStopMessage) error {
 		return err
 	}
 
+	// Bypass motion shaping: cancel any ramp and zero shaped state
+	h.HaltMotion()
+	h.CancelTyping()
+
 	// Notify safety subsystem first
 	if h.safety != nil {
 		h.safety.OnEStop()
@@ -186,6 +355,48 @@ func (h *Handler) HandleEStop(msg *protocol.EStopMessage) error {
 	return h.robot.EStop()
 }
 
+// HandleMode processes a robot mode switch command.
+func (h *Handler) HandleMode(msg *protocol.ModeMessage) error {
+	if !h.hasScope(ScopeMode) {
+		return ErrScopeNotAllowed
+	}
+
+	if err := h.validator.ValidateMode(msg); err != nil {
+		h.notifyInvalid()
+		return err
+	}
+
+	if h.modes == nil {
+		return ErrModeUnsupported
+	}
+
+	// A gait change could move the robot while it is meant to be stopped
+	if h.isSafeStopped() {
+		return ErrSafeStopped
+	}
+
+	if !slices.Contains(h.modes.Modes(), msg.Mode) {
+		h.notifyInvalid()
+		return &ValidationError{
+			Code:    ErrInvalidValue,
+			Message: "mode not supported by robot",
+			Field:   "mode",
+		}
+	}
+
+	from := h.modes.Mode()
+	if err := h.modes.SetMode(msg.Mode); err != nil {
+		return err
+	}
+
+	if h.onModeChange != nil {
+		h.onModeChange(from, msg.Mode)
+	}
+
+	h.notifyValid()
+	return nil
+}
+
 // notifyValid notifies safety of a valid control message.
 func (h *Handler) notifyValid() {
 	if h.safety != nil {
@@ -200,6 +411,12 @@ func (h *Handler) notifyInvalid() {
 	}
 }
 
+// isSafeStopped checks if the safety monitor holds the robot in safe-stop.
+func (h *Handler) isSafeStopped() bool {
+	s, ok := h.safety.(SafetyState)
+	return ok && s.IsStopped()
+}
+
 // hasScope checks if the session allows the given scope.
 func (h *Handler) hasScope(scope string) bool {
 	if h.scopes == nil {
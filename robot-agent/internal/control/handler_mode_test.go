@@ -0,0 +1,199 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// mockModeRobot is a quadruped-style robot with selectable gaits.
+type mockModeRobot struct {
+	mockRobotAPI
+	mode    string
+	failSet bool
+}
+
+func (m *mockModeRobot) Modes() []string {
+	return []string{"stand", "sit", "walk"}
+}
+
+func (m *mockModeRobot) Mode() string {
+	return m.mode
+}
+
+func (m *mockModeRobot) SetMode(mode string) error {
+	if m.failSet {
+		return errors.New("gait controller busy")
+	}
+	m.mode = mode
+	return nil
+}
+
+func (m *mockModeRobot) Capabilities() protocol.Capabilities {
+	return protocol.Capabilities{
+		Commands:      []protocol.MessageType{protocol.TypeDrive, protocol.TypeEStop},
+		MaxLinearMps:  1.5,
+		MaxAngularRps: 2.0,
+	}
+}
+
+// stoppedSafety is a safety monitor holding the robot in safe-stop.
+type stoppedSafety struct {
+	mockSafetyCallback
+}
+
+func (s *stoppedSafety) IsStopped() bool { return true }
+
+func newModeHandler(robot *mockModeRobot, scopes ScopeChecker) *Handler {
+	h := NewHandler(robot, nil, scopes, nil, 500*time.Millisecond)
+	h.SetModeController(robot)
+	return h
+}
+
+func TestHandler_Mode_ScopeRequired(t *testing.T) {
+	robot := &mockModeRobot{mode: "stand"}
+	scopes := &mockScopeChecker{allowedScopes: map[string]bool{ScopeControl: true}}
+	h := newModeHandler(robot, scopes)
+
+	msg := &protocol.ModeMessage{Type: protocol.TypeMode, Mode: "walk", T: time.Now().UnixMilli()}
+	if err := h.HandleMode(msg); err != ErrScopeNotAllowed {
+		t.Errorf("expected ErrScopeNotAllowed with control scope only, got %v", err)
+	}
+
+	scopes.allowedScopes[ScopeMode] = true
+	if err := h.HandleMode(msg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if robot.mode != "walk" {
+		t.Errorf("expected mode walk, got %s", robot.mode)
+	}
+}
+
+func TestHandler_Mode_Callback(t *testing.T) {
+	robot := &mockModeRobot{mode: "stand"}
+	h := newModeHandler(robot, nil)
+
+	var from, to string
+	h.SetModeChangeCallback(func(f, t string) { from, to = f, t })
+
+	msg := &protocol.ModeMessage{Type: protocol.TypeMode, Mode: "sit", T: time.Now().UnixMilli()}
+	if err := h.HandleMode(msg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if from != "stand" || to != "sit" {
+		t.Errorf("expected stand -> sit, got %s -> %s", from, to)
+	}
+}
+
+func TestHandler_Mode_Rejections(t *testing.T) {
+	now := time.Now().UnixMilli()
+
+	t.Run("unknown mode", func(t *testing.T) {
+		robot := &mockModeRobot{mode: "stand"}
+		safety := &mockSafetyCallback{}
+		h := newModeHandler(robot, nil)
+		h.safety = safety
+
+		err := h.HandleMode(&protocol.ModeMessage{Type: protocol.TypeMode, Mode: "fly", T: now})
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) || vErr.Code != ErrInvalidValue {
+			t.Errorf("expected invalid value error, got %v", err)
+		}
+		if safety.invalidCount != 1 {
+			t.Errorf("expected invalid command counted, got %d", safety.invalidCount)
+		}
+		if robot.mode != "stand" {
+			t.Errorf("mode should be unchanged, got %s", robot.mode)
+		}
+	})
+
+	t.Run("safe-stopped", func(t *testing.T) {
+		robot := &mockModeRobot{mode: "stand"}
+		h := newModeHandler(robot, nil)
+		h.safety = &stoppedSafety{}
+
+		if err := h.HandleMode(&protocol.ModeMessage{Type: protocol.TypeMode, Mode: "walk", T: now}); err != ErrSafeStopped {
+			t.Errorf("expected ErrSafeStopped, got %v", err)
+		}
+		if robot.mode != "stand" {
+			t.Errorf("mode should be unchanged while stopped, got %s", robot.mode)
+		}
+	})
+
+	t.Run("unsupported robot", func(t *testing.T) {
+		h := NewHandler(&mockRobotAPI{}, nil, nil, nil, 500*time.Millisecond)
+		err := h.HandleMode(&protocol.ModeMessage{Type: protocol.TypeMode, Mode: "walk", T: now})
+		if err != ErrModeUnsupported {
+			t.Errorf("expected ErrModeUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("backend failure", func(t *testing.T) {
+		robot := &mockModeRobot{mode: "stand", failSet: true}
+		h := newModeHandler(robot, nil)
+		called := false
+		h.SetModeChangeCallback(func(string, string) { called = true })
+
+		if err := h.HandleMode(&protocol.ModeMessage{Type: protocol.TypeMode, Mode: "walk", T: now}); err == nil {
+			t.Error("expected backend error")
+		}
+		if called {
+			t.Error("callback should not fire on failed switch")
+		}
+	})
+}
+
+func TestHandler_Mode_Dispatch(t *testing.T) {
+	robot := &mockModeRobot{mode: "stand"}
+	h := newModeHandler(robot, nil)
+
+	data, _ := json.Marshal(&protocol.ModeMessage{Type: protocol.TypeMode, Mode: "walk", T: time.Now().UnixMilli()})
+	ack, err := h.HandleMessage(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ack.RefType != protocol.TypeMode {
+		t.Errorf("expected ack for mode, got %s", ack.RefType)
+	}
+}
+
+func TestHandler_Capabilities(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		h := NewHandler(&mockRobotAPI{}, nil, nil, nil, 500*time.Millisecond)
+		caps := h.Capabilities()
+
+		if !slices.Equal(caps.Commands, defaultCommands) {
+			t.Errorf("expected default commands, got %v", caps.Commands)
+		}
+		if len(caps.Modes) != 0 {
+			t.Errorf("expected no modes, got %v", caps.Modes)
+		}
+	})
+
+	t.Run("provider with modes", func(t *testing.T) {
+		robot := &mockModeRobot{mode: "stand"}
+		h := newModeHandler(robot, nil)
+		h.SetCapabilityProvider(robot)
+		h.SetMotionShaper(NewMotionShaper(ShaperConfig{}))
+		h.SetSpeedLimit(0.5)
+
+		caps := h.Capabilities()
+		want := []protocol.MessageType{protocol.TypeDrive, protocol.TypeEStop, protocol.TypeMode}
+		if !slices.Equal(caps.Commands, want) {
+			t.Errorf("expected %v, got %v", want, caps.Commands)
+		}
+		if caps.MaxLinearMps != 1.5 || caps.MaxAngularRps != 2.0 {
+			t.Errorf("unexpected velocity limits: %+v", caps)
+		}
+		if caps.MaxSpeed != 0.5 {
+			t.Errorf("expected session max speed 0.5, got %f", caps.MaxSpeed)
+		}
+		if !slices.Equal(caps.Modes, []string{"stand", "sit", "walk"}) || caps.Mode != "stand" {
+			t.Errorf("unexpected modes: %v (current %s)", caps.Modes, caps.Mode)
+		}
+	})
+}
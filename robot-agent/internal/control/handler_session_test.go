@@ -163,3 +163,34 @@ func TestHandler_SessionRevoked_MidSession(t *testing.T) {
 		t.Errorf("expected ErrSessionRevoked after revocation, got %v", err)
 	}
 }
+
+func TestHandler_RateLimited_ResetBySession(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h := NewHandler(robot, nil, nil, &mockSessionChecker{active: true}, 500*time.Millisecond)
+	h.SetRateLimiter(NewRateLimiterWithConfig(RateLimiterConfig{KVMHz: 1, BurstSize: 1}))
+
+	mouse := func() error {
+		data, _ := json.Marshal(&protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, DX: 1, T: time.Now().UnixMilli()})
+		_, err := h.HandleMessage(data)
+		return err
+	}
+
+	if err := mouse(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mouse(); err != ErrRateLimited {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+
+	// E-stop is never rate limited
+	estop, _ := json.Marshal(&protocol.EStopMessage{Type: protocol.TypeEStop, T: time.Now().UnixMilli()})
+	if _, err := h.HandleMessage(estop); err != nil {
+		t.Errorf("e-stop should bypass rate limit, got %v", err)
+	}
+
+	// A new session starts with a full bucket
+	h.ResetSession()
+	if err := mouse(); err != nil {
+		t.Errorf("expected allowed after session reset, got %v", err)
+	}
+}
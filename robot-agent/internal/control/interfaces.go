@@ -1,7 +1,12 @@
 // Package control implements control command handling for the Robot Agent.
 package control
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
 
 // Error types for control handling.
 var (
@@ -10,12 +15,23 @@ var (
 	ErrInvalidJSON      = errors.New("invalid JSON message")
 	ErrScopeNotAllowed  = errors.New("operation not permitted by scope")
 	ErrSessionRevoked   = errors.New("session has been revoked")
+	ErrOutOfOrder       = errors.New("command superseded by newer sequence")
+	ErrModeUnsupported  = errors.New("robot does not support modes")
+	ErrChordDenied      = errors.New("key chord denied by policy")
+	ErrRateLimited      = errors.New("command rate limit exceeded")
+	ErrSafeStopped      = errors.New("robot is safe-stopped")
+
+	ErrMouseModeUnsupported = errors.New("robot does not support mouse mode")
 )
 
 // Scope constants for authorization.
 const (
 	ScopeControl = "teleop:control"
 	ScopeEStop   = "teleop:estop"
+	ScopeMode    = "teleop:mode"
+
+	// ScopeKVMPrivileged permits key chords on the deny list.
+	ScopeKVMPrivileged = "teleop:kvm_privileged"
 )
 
 // RobotAPI defines the interface for robot control operations.
@@ -26,6 +42,43 @@ type RobotAPI interface {
 	EStop() error
 }
 
+// ModeController is implemented by robots with selectable modes, such as
+// stand/sit/walk gaits on a quadruped.
+type ModeController interface {
+	Modes() []string
+	Mode() string
+	SetMode(mode string) error
+}
+
+// AbsolutePointer is implemented by KVM backends with a tablet-style
+// absolute pointing device. x and y are normalised to [0, 1] across the
+// host display, matching the video frame the operator sees.
+type AbsolutePointer interface {
+	SendMouseAbsolute(x, y float64, buttons, scroll int) error
+}
+
+// CapabilityProvider is implemented by robots that describe their own
+// supported commands and velocity limits.
+type CapabilityProvider interface {
+	Capabilities() protocol.Capabilities
+}
+
+// ModeChangeCallback is called after a successful mode switch.
+type ModeChangeCallback func(from, to string)
+
+// ChordBlockedCallback is called when a denied key chord is blocked.
+type ChordBlockedCallback func(chord Chord)
+
+// TextTypedCallback is called when a kvm_type command starts. Only a hash
+// of the text is passed so secrets never leave the handler.
+type TextTypedCallback func(sha256Hex string, length int, layout string)
+
+// DriveFilter may adjust or reject a drive command before it is shaped and
+// sent to the robot (e.g., geofence or obstacle limits).
+type DriveFilter interface {
+	FilterDrive(v, w float64) (float64, float64, error)
+}
+
 // SafetyCallback is called when safety events occur.
 type SafetyCallback interface {
 	OnValidControl()
@@ -33,6 +86,12 @@ type SafetyCallback interface {
 	OnEStop()
 }
 
+// SafetyState is implemented by safety callbacks that can report whether a
+// safe-stop is in effect.
+type SafetyState interface {
+	IsStopped() bool
+}
+
 // ScopeChecker checks if a scope is allowed for the current session.
 type ScopeChecker interface {
 	HasScope(scope string) bool
@@ -42,3 +101,8 @@ type ScopeChecker interface {
 type SessionChecker interface {
 	IsActive() bool
 }
+
+// ClockOffsetSource provides the estimated console-minus-robot clock offset.
+type ClockOffsetSource interface {
+	ClockOffset() (offset, uncertainty time.Duration, ok bool)
+}
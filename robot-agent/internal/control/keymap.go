@@ -0,0 +1,109 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import "fmt"
+
+// keystroke is a single character expressed as a physical key press.
+type keystroke struct {
+	code  string // KeyboardEvent code
+	shift bool
+}
+
+// keymap maps characters to key presses for one host keyboard layout.
+type keymap map[rune]keystroke
+
+// DefaultLayout is used when kvm_type does not name a layout.
+const DefaultLayout = "us"
+
+var layouts = map[string]keymap{
+	"us": usLayout(),
+	"gb": gbLayout(),
+}
+
+// usLayout returns the US ANSI layout.
+func usLayout() keymap {
+	m := keymap{
+		' ':  {code: "Space"},
+		'\n': {code: "Enter"},
+		'\t': {code: "Tab"},
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		code := fmt.Sprintf("Key%c", c-'a'+'A')
+		m[c] = keystroke{code: code}
+		m[c-'a'+'A'] = keystroke{code: code, shift: true}
+	}
+	for c := '0'; c <= '9'; c++ {
+		m[c] = keystroke{code: fmt.Sprintf("Digit%c", c)}
+	}
+
+	shiftedDigits := ")!@#$%^&*("
+	for i, c := range shiftedDigits {
+		m[c] = keystroke{code: fmt.Sprintf("Digit%d", i), shift: true}
+	}
+
+	symbols := []struct {
+		plain, shifted rune
+		code           string
+	}{
+		{'`', '~', "Backquote"},
+		{'-', '_', "Minus"},
+		{'=', '+', "Equal"},
+		{'[', '{', "BracketLeft"},
+		{']', '}', "BracketRight"},
+		{'\\', '|', "Backslash"},
+		{';', ':', "Semicolon"},
+		{'\'', '"', "Quote"},
+		{',', '<', "Comma"},
+		{'.', '>', "Period"},
+		{'/', '?', "Slash"},
+	}
+	for _, s := range symbols {
+		m[s.plain] = keystroke{code: s.code}
+		m[s.shifted] = keystroke{code: s.code, shift: true}
+	}
+	return m
+}
+
+// gbLayout returns the UK ISO layout.
+func gbLayout() keymap {
+	m := usLayout()
+	m['"'] = keystroke{code: "Digit2", shift: true}
+	m['@'] = keystroke{code: "Quote", shift: true}
+	m['£'] = keystroke{code: "Digit3", shift: true}
+	m['#'] = keystroke{code: "Backslash"}
+	m['~'] = keystroke{code: "Backslash", shift: true}
+	m['\\'] = keystroke{code: "IntlBackslash"}
+	m['|'] = keystroke{code: "IntlBackslash", shift: true}
+	m['¬'] = keystroke{code: "Backquote", shift: true}
+	return m
+}
+
+// expandText converts text to key presses for the given layout.
+func expandText(text, layout string) ([]keystroke, error) {
+	if layout == "" {
+		layout = DefaultLayout
+	}
+	m, ok := layouts[layout]
+	if !ok {
+		return nil, &ValidationError{
+			Code:    ErrInvalidValue,
+			Message: fmt.Sprintf("unsupported layout %q", layout),
+			Field:   "layout",
+		}
+	}
+
+	strokes := make([]keystroke, 0, len(text))
+	for i, r := range []rune(text) {
+		s, ok := m[r]
+		if !ok {
+			// Report only the position: the text may be a secret
+			return nil, &ValidationError{
+				Code:    ErrInvalidValue,
+				Message: fmt.Sprintf("character at position %d cannot be typed on layout %q", i, layout),
+				Field:   "text",
+			}
+		}
+		strokes = append(strokes, s)
+	}
+	return strokes, nil
+}
@@ -0,0 +1,76 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"errors"
+	"time"
+)
+
+// SetKeyPolicy sets the deny list for key chords. A nil policy allows all keys.
+func (h *Handler) SetKeyPolicy(p *KeyPolicy) {
+	h.keyPolicy = p
+}
+
+// SetChordBlockedCallback sets the callback for blocked key chords.
+func (h *Handler) SetChordBlockedCallback(fn ChordBlockedCallback) {
+	h.onChordBlocked = fn
+}
+
+// SetInputIdleTimeout sets how long keys or buttons may stay held without
+// any KVM input before ReleaseIdleInputs releases them. Zero disables it.
+func (h *Handler) SetInputIdleTimeout(d time.Duration) {
+	h.inputIdle = d
+}
+
+// HeldInputs returns the keys and mouse buttons currently held on the host.
+func (h *Handler) HeldInputs() ([]string, int) {
+	return h.inputs.Held()
+}
+
+// ReleaseInputs sends key-up for every held key and releases held mouse
+// buttons. Used on safe-stop and session end so no input stays stuck.
+func (h *Handler) ReleaseInputs() error {
+	h.CancelTyping()
+	keys, buttons := h.inputs.Clear()
+
+	var errs []error
+	for _, key := range keys {
+		if err := h.robot.SendKey(key, "up", nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if buttons != 0 {
+		if err := h.releaseButtons(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReleaseIdleInputs releases held inputs if no KVM input arrived within the
+// idle timeout. It reports whether anything was released.
+func (h *Handler) ReleaseIdleInputs() (bool, error) {
+	if h.inputIdle <= 0 || !h.inputs.IdleFor(h.inputIdle) {
+		return false, nil
+	}
+	return true, h.ReleaseInputs()
+}
+
+// checkKeyPolicy blocks denied chords unless the session is privileged.
+// Modifiers held as separate keys count toward the chord.
+func (h *Handler) checkKeyPolicy(key string, modifiers []string) error {
+	if h.keyPolicy == nil || h.hasScope(ScopeKVMPrivileged) {
+		return nil
+	}
+
+	mods := append(h.inputs.HeldModifiers(), modifiers...)
+	chord, denied := h.keyPolicy.Denied(key, mods)
+	if !denied {
+		return nil
+	}
+
+	if h.onChordBlocked != nil {
+		h.onChordBlocked(chord)
+	}
+	return ErrChordDenied
+}
@@ -0,0 +1,99 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// modifierKeys maps physical modifier key codes to modifier names.
+var modifierKeys = map[string]string{
+	"ControlLeft":  "ctrl",
+	"ControlRight": "ctrl",
+	"AltLeft":      "alt",
+	"AltRight":     "alt",
+	"ShiftLeft":    "shift",
+	"ShiftRight":   "shift",
+	"MetaLeft":     "meta",
+	"MetaRight":    "meta",
+}
+
+// validModifiers are the modifier names accepted in chords.
+var validModifiers = []string{"ctrl", "alt", "shift", "meta"}
+
+// Chord is a key pressed together with a set of modifiers.
+type Chord struct {
+	Key       string
+	Modifiers []string
+}
+
+// String returns the chord in "mod+mod+Key" form.
+func (c Chord) String() string {
+	return strings.Join(append(slices.Clone(c.Modifiers), c.Key), "+")
+}
+
+// ParseChord parses a chord such as "ctrl+alt+Delete". Modifier names are
+// case-insensitive; the key is a KeyboardEvent code as sent by the console.
+func ParseChord(s string) (Chord, error) {
+	parts := strings.Split(strings.TrimSpace(s), "+")
+	key := strings.TrimSpace(parts[len(parts)-1])
+	if key == "" {
+		return Chord{}, fmt.Errorf("invalid chord %q: missing key", s)
+	}
+
+	var mods []string
+	for _, p := range parts[:len(parts)-1] {
+		mod := strings.ToLower(strings.TrimSpace(p))
+		if !slices.Contains(validModifiers, mod) {
+			return Chord{}, fmt.Errorf("invalid chord %q: unknown modifier %q", s, p)
+		}
+		mods = append(mods, mod)
+	}
+	return Chord{Key: key, Modifiers: mods}, nil
+}
+
+// KeyPolicy blocks denied key chords.
+type KeyPolicy struct {
+	denied []Chord
+}
+
+// NewKeyPolicy creates a policy from chord strings.
+func NewKeyPolicy(chords []string) (*KeyPolicy, error) {
+	p := &KeyPolicy{}
+	for _, s := range chords {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		c, err := ParseChord(s)
+		if err != nil {
+			return nil, err
+		}
+		p.denied = append(p.denied, c)
+	}
+	return p, nil
+}
+
+// Denied returns the matching denied chord if pressing key with the given
+// active modifiers is blocked.
+func (p *KeyPolicy) Denied(key string, modifiers []string) (Chord, bool) {
+	for _, c := range p.denied {
+		if !strings.EqualFold(c.Key, key) {
+			continue
+		}
+		if containsAll(modifiers, c.Modifiers) {
+			return c, true
+		}
+	}
+	return Chord{}, false
+}
+
+// containsAll reports whether have includes every modifier in want.
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		if !slices.ContainsFunc(have, func(h string) bool { return strings.EqualFold(h, w) }) {
+			return false
+		}
+	}
+	return true
+}
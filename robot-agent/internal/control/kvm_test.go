@@ -0,0 +1,155 @@
+package control
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+func keyMsg(key, action string, modifiers ...string) *protocol.KVMKeyMessage {
+	return &protocol.KVMKeyMessage{
+		Type:      protocol.TypeKVMKey,
+		Key:       key,
+		Action:    action,
+		Modifiers: modifiers,
+		T:         time.Now().UnixMilli(),
+	}
+}
+
+func newKVMHandler(t *testing.T, robot *mockRobotAPI, scopes map[string]bool) *Handler {
+	t.Helper()
+	policy, err := NewKeyPolicy([]string{"ctrl+alt+Delete", "alt+PrintScreen"})
+	if err != nil {
+		t.Fatalf("NewKeyPolicy: %v", err)
+	}
+	h := NewHandler(robot, nil, &mockScopeChecker{allowedScopes: scopes}, nil, 500*time.Millisecond)
+	h.SetKeyPolicy(policy)
+	return h
+}
+
+func TestParseChord(t *testing.T) {
+	c, err := ParseChord(" Ctrl + ALT + Delete ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Key != "Delete" || !slices.Equal(c.Modifiers, []string{"ctrl", "alt"}) {
+		t.Errorf("unexpected chord: %+v", c)
+	}
+	if c.String() != "ctrl+alt+Delete" {
+		t.Errorf("unexpected string form: %s", c.String())
+	}
+
+	for _, bad := range []string{"", "ctrl+", "hyper+KeyA"} {
+		if _, err := ParseChord(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestKeyPolicy_BlocksDeniedChord(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h := newKVMHandler(t, robot, map[string]bool{ScopeControl: true})
+
+	var blocked []string
+	h.SetChordBlockedCallback(func(c Chord) { blocked = append(blocked, c.String()) })
+
+	if err := h.HandleKVMKey(keyMsg("Delete", "down", "ctrl", "alt")); err != ErrChordDenied {
+		t.Errorf("expected ErrChordDenied, got %v", err)
+	}
+	if len(robot.keyCalls) != 0 {
+		t.Error("denied chord must not reach the host")
+	}
+
+	// Modifiers held as separate keys count toward the chord
+	h.HandleKVMKey(keyMsg("AltLeft", "down"))
+	if err := h.HandleKVMKey(keyMsg("PrintScreen", "down")); err != ErrChordDenied {
+		t.Errorf("expected held Alt + PrintScreen to be denied, got %v", err)
+	}
+
+	if !slices.Equal(blocked, []string{"ctrl+alt+Delete", "alt+PrintScreen"}) {
+		t.Errorf("unexpected blocked callbacks: %v", blocked)
+	}
+
+	// Partial chords and key-up events are allowed
+	h.HandleKVMKey(keyMsg("AltLeft", "up"))
+	if err := h.HandleKVMKey(keyMsg("Delete", "down", "ctrl")); err != nil {
+		t.Errorf("partial chord should be allowed, got %v", err)
+	}
+	if err := h.HandleKVMKey(keyMsg("Delete", "up", "ctrl", "alt")); err != nil {
+		t.Errorf("key up should be allowed, got %v", err)
+	}
+}
+
+func TestKeyPolicy_PrivilegedScopeBypasses(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h := newKVMHandler(t, robot, map[string]bool{ScopeControl: true, ScopeKVMPrivileged: true})
+
+	if err := h.HandleKVMKey(keyMsg("Delete", "down", "ctrl", "alt")); err != nil {
+		t.Errorf("privileged session should bypass deny list, got %v", err)
+	}
+}
+
+func TestInputTracking_ReleaseInputs(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h := newKVMHandler(t, robot, map[string]bool{ScopeControl: true})
+	now := time.Now().UnixMilli()
+
+	h.HandleKVMKey(keyMsg("ShiftLeft", "down"))
+	h.HandleKVMKey(keyMsg("KeyA", "down", "shift"))
+	h.HandleKVMKey(keyMsg("KeyB", "down"))
+	h.HandleKVMKey(keyMsg("KeyB", "up"))
+	h.HandleKVMMouse(&protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, Buttons: 1, T: now})
+
+	keys, buttons := h.HeldInputs()
+	if !slices.Equal(keys, []string{"KeyA", "ShiftLeft"}) || buttons != 1 {
+		t.Fatalf("unexpected held inputs: keys=%v buttons=%d", keys, buttons)
+	}
+
+	robot.keyCalls = nil
+	robot.mouseCalls = nil
+	if err := h.ReleaseInputs(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(robot.keyCalls) != 2 {
+		t.Fatalf("expected 2 key releases, got %d", len(robot.keyCalls))
+	}
+	for _, call := range robot.keyCalls {
+		if call.Action != "up" {
+			t.Errorf("expected key up, got %+v", call)
+		}
+	}
+	if len(robot.mouseCalls) != 1 || robot.mouseCalls[0].Buttons != 0 {
+		t.Errorf("expected button release, got %+v", robot.mouseCalls)
+	}
+
+	if keys, buttons := h.HeldInputs(); len(keys) != 0 || buttons != 0 {
+		t.Errorf("expected nothing held after release, got keys=%v buttons=%d", keys, buttons)
+	}
+}
+
+func TestInputTracking_IdleRelease(t *testing.T) {
+	robot := &mockRobotAPI{}
+	clock := newFakeClock()
+	h := newKVMHandler(t, robot, map[string]bool{ScopeControl: true})
+	h.inputs = newInputTrackerWithClock(clock.Now)
+	h.SetInputIdleTimeout(5 * time.Second)
+
+	if released, _ := h.ReleaseIdleInputs(); released {
+		t.Error("nothing held, nothing to release")
+	}
+
+	h.HandleKVMKey(keyMsg("KeyA", "down"))
+	clock.Advance(4 * time.Second)
+	if released, _ := h.ReleaseIdleInputs(); released {
+		t.Error("released before idle timeout")
+	}
+
+	clock.Advance(time.Second)
+	released, err := h.ReleaseIdleInputs()
+	if !released || err != nil {
+		t.Errorf("expected release after idle timeout, got released=%v err=%v", released, err)
+	}
+}
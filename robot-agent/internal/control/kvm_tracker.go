@@ -0,0 +1,103 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// InputTracker records keys and mouse buttons currently held on the target
+// host so they can be released if the console goes away.
+type InputTracker struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	keys      map[string]struct{}
+	buttons   int
+	lastInput time.Time
+}
+
+// NewInputTracker creates an empty input tracker.
+func NewInputTracker() *InputTracker {
+	return newInputTrackerWithClock(time.Now)
+}
+
+func newInputTrackerWithClock(now func() time.Time) *InputTracker {
+	return &InputTracker{now: now, keys: make(map[string]struct{})}
+}
+
+// Key records a key action sent to the host.
+func (t *InputTracker) Key(key, action string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch action {
+	case "down":
+		t.keys[key] = struct{}{}
+	case "up":
+		delete(t.keys, key)
+	}
+	t.lastInput = t.now()
+}
+
+// Buttons records the mouse button state sent to the host.
+func (t *InputTracker) Buttons(buttons int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buttons = buttons
+	t.lastInput = t.now()
+}
+
+// HeldModifiers returns modifier names for modifier keys currently held.
+func (t *InputTracker) HeldModifiers() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var mods []string
+	for key := range t.keys {
+		if mod, ok := modifierKeys[key]; ok && !slices.Contains(mods, mod) {
+			mods = append(mods, mod)
+		}
+	}
+	return mods
+}
+
+// Held returns the held keys (sorted) and mouse button bitmask.
+func (t *InputTracker) Held() ([]string, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.heldLocked()
+}
+
+// IdleFor reports whether inputs are held and nothing was sent for at least d.
+func (t *InputTracker) IdleFor(d time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.keys) == 0 && t.buttons == 0 {
+		return false
+	}
+	return t.now().Sub(t.lastInput) >= d
+}
+
+// Clear forgets all held inputs and returns what was held.
+func (t *InputTracker) Clear() ([]string, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys, buttons := t.heldLocked()
+	t.keys = make(map[string]struct{})
+	t.buttons = 0
+	return keys, buttons
+}
+
+// heldLocked returns held inputs (caller must hold lock).
+func (t *InputTracker) heldLocked() ([]string, int) {
+	keys := make([]string, 0, len(t.keys))
+	for key := range t.keys {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys, t.buttons
+}
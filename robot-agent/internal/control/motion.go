@@ -0,0 +1,184 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"log"
+	"time"
+)
+
+// ticker abstracts time.Ticker so ramp timing can be driven by tests.
+type ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realTicker wraps time.Ticker.
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
+
+func newRealTicker(d time.Duration) ticker {
+	return realTicker{t: time.NewTicker(d)}
+}
+
+// SetMotionShaper enables drive command shaping. A nil shaper passes
+// drive commands straight through to the robot.
+func (h *Handler) SetMotionShaper(s *MotionShaper) {
+	h.motionMu.Lock()
+	defer h.motionMu.Unlock()
+	h.shaper = s
+}
+
+// SetSpeedLimit sets the maximum speed fraction for the current session.
+func (h *Handler) SetSpeedLimit(fraction float64) {
+	h.motionMu.Lock()
+	defer h.motionMu.Unlock()
+
+	if h.shaper != nil {
+		h.shaper.SetMaxSpeed(fraction)
+	}
+}
+
+// RampToStop decelerates smoothly to zero within the shaper's limits.
+// Returns false if no shaper is configured and the caller must stop directly.
+func (h *Handler) RampToStop() bool {
+	h.motionMu.Lock()
+	defer h.motionMu.Unlock()
+
+	if h.shaper == nil {
+		return false
+	}
+
+	h.shaper.RampToZero()
+	h.startRampLocked()
+	return true
+}
+
+// HaltMotion cancels any ramp in progress and zeroes shaper state
+// immediately. Used by e-stop and non-recoverable safe-stops.
+func (h *Handler) HaltMotion() {
+	h.motionMu.Lock()
+	defer h.motionMu.Unlock()
+	h.haltLocked()
+}
+
+// haltLocked cancels any ramp and deadman and zeroes shaper state (caller
+// must hold motionMu).
+func (h *Handler) haltLocked() {
+	h.stopRampLocked()
+	h.disarmDeadmanLocked()
+	if h.shaper != nil {
+		h.shaper.Reset()
+	}
+}
+
+// StopNow drops velocity to zero at once, bypassing the shaper. Used when a
+// command is refused or an obstacle blocks travel and the previous velocity
+// must not stay applied.
+func (h *Handler) StopNow() error {
+	h.motionMu.Lock()
+	defer h.motionMu.Unlock()
+
+	h.haltLocked()
+	return h.robot.Drive(0, 0)
+}
+
+// drive sends a validated drive command through the shaper, if any.
+// A positive window arms the deadman so the command lapses without refresh.
+func (h *Handler) drive(v, w float64, window time.Duration) error {
+	h.motionMu.Lock()
+	defer h.motionMu.Unlock()
+
+	h.disarmDeadmanLocked()
+	if err := h.driveLocked(v, w); err != nil {
+		return err
+	}
+	h.armDeadmanLocked(window, v, w)
+	return nil
+}
+
+// driveLocked sends a drive command (caller must hold motionMu).
+func (h *Handler) driveLocked(v, w float64) error {
+	if h.shaper == nil {
+		return h.robot.Drive(v, w)
+	}
+
+	sv, sw := h.shaper.SetTarget(v, w)
+	if err := h.robot.Drive(sv, sw); err != nil {
+		return err
+	}
+	h.startRampLocked()
+	return nil
+}
+
+// startRampLocked starts the ramp loop if output has not converged
+// (caller must hold motionMu).
+func (h *Handler) startRampLocked() {
+	if h.rampStop != nil || h.shaper.Converged() {
+		return
+	}
+	stop := make(chan struct{})
+	h.rampStop = stop
+	go h.runRamp(stop)
+}
+
+// stopRampLocked stops the ramp loop (caller must hold motionMu).
+func (h *Handler) stopRampLocked() {
+	if h.rampStop != nil {
+		close(h.rampStop)
+		h.rampStop = nil
+	}
+}
+
+// runRamp keeps stepping the shaper toward its target until converged.
+func (h *Handler) runRamp(stop chan struct{}) {
+	h.motionMu.Lock()
+	shaper := h.shaper
+	h.motionMu.Unlock()
+
+	ticker := h.newTicker(shaper.TickInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C():
+		}
+
+		if done := h.rampStep(stop, shaper); done {
+			return
+		}
+	}
+}
+
+// rampStep performs one ramp iteration and reports whether the loop is done.
+func (h *Handler) rampStep(stop chan struct{}, shaper *MotionShaper) bool {
+	h.motionMu.Lock()
+	defer h.motionMu.Unlock()
+
+	select {
+	case <-stop:
+		return true
+	default:
+	}
+
+	v, w := shaper.Step()
+	if err := h.robot.Drive(v, w); err != nil {
+		log.Printf("control: drive failed while ramping, issuing e-stop: %v", err)
+		h.rampStop = nil
+		if err := h.robot.EStop(); err != nil {
+			log.Printf("control: e-stop after ramp failure failed: %v", err)
+		}
+		return true
+	}
+
+	if shaper.Converged() {
+		h.rampStop = nil
+		return true
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// SetAbsolutePointer enables absolute mouse mode for KVM backends with a
+// tablet-style pointing device.
+func (h *Handler) SetAbsolutePointer(p AbsolutePointer) {
+	h.mouseMu.Lock()
+	defer h.mouseMu.Unlock()
+	h.pointer = p
+}
+
+// MouseModes returns the pointer modes the backend supports.
+func (h *Handler) MouseModes() []string {
+	h.mouseMu.Lock()
+	defer h.mouseMu.Unlock()
+
+	if h.pointer == nil {
+		return []string{protocol.MouseModeRelative}
+	}
+	return []string{protocol.MouseModeRelative, protocol.MouseModeAbsolute}
+}
+
+// MouseMode returns the pointer mode negotiated for the session.
+func (h *Handler) MouseMode() string {
+	h.mouseMu.Lock()
+	defer h.mouseMu.Unlock()
+	return h.mouseMode
+}
+
+// HandleKVMMouseMode switches the session between relative and absolute
+// pointer input. Held buttons are released on the old device first so
+// none stays pressed across the switch.
+func (h *Handler) HandleKVMMouseMode(msg *protocol.KVMMouseModeMessage) error {
+	if !h.hasScope(ScopeControl) {
+		return ErrScopeNotAllowed
+	}
+
+	if err := h.validator.ValidateKVMMouseMode(msg); err != nil {
+		h.notifyInvalid()
+		return err
+	}
+
+	h.mouseMu.Lock()
+	unsupported := msg.Mode == protocol.MouseModeAbsolute && h.pointer == nil
+	unchanged := msg.Mode == h.mouseMode
+	h.mouseMu.Unlock()
+
+	if unsupported {
+		return ErrMouseModeUnsupported
+	}
+
+	if !unchanged {
+		if _, buttons := h.inputs.Held(); buttons != 0 {
+			if err := h.releaseButtons(); err != nil {
+				return err
+			}
+			h.inputs.Buttons(0)
+		}
+
+		h.mouseMu.Lock()
+		h.mouseMode = msg.Mode
+		h.mouseMu.Unlock()
+	}
+
+	h.notifyValid()
+	return nil
+}
+
+// checkMouseMode rejects mouse input that does not match the session's
+// pointer mode.
+func (h *Handler) checkMouseMode(msg *protocol.KVMMouseMessage) error {
+	absolute := msg.X != nil
+	if absolute == (h.MouseMode() == protocol.MouseModeAbsolute) {
+		return nil
+	}
+
+	if absolute {
+		return &ValidationError{
+			Code:    ErrInvalidValue,
+			Message: "x and y require absolute mouse mode",
+			Field:   "x",
+		}
+	}
+	return &ValidationError{
+		Code:    ErrMissingField,
+		Message: "absolute mouse mode requires x and y",
+		Field:   "x",
+	}
+}
+
+// sendMouse forwards mouse input to the device for the session's mode.
+func (h *Handler) sendMouse(msg *protocol.KVMMouseMessage) error {
+	if msg.X == nil {
+		return h.robot.SendMouse(msg.DX, msg.DY, msg.Buttons, msg.Scroll)
+	}
+
+	h.mouseMu.Lock()
+	defer h.mouseMu.Unlock()
+
+	if err := h.pointer.SendMouseAbsolute(*msg.X, *msg.Y, msg.Buttons, msg.Scroll); err != nil {
+		return err
+	}
+	h.lastX, h.lastY = *msg.X, *msg.Y
+	return nil
+}
+
+// releaseButtons releases all mouse buttons.
+func (h *Handler) releaseButtons() error {
+	return h.sendButtons(0)
+}
+
+// releaseLateButtons applies the button releases of an out-of-order mouse
+// message without its movement, keeping the buttons still held.
+func (h *Handler) releaseLateButtons(buttons int) error {
+	if err := h.sendButtons(buttons); err != nil {
+		return err
+	}
+	h.inputs.Buttons(buttons)
+	return nil
+}
+
+// sendButtons sets the mouse buttons on the device for the session's mode
+// without moving the pointer. An absolute event repeats the last position
+// so the pointer does not jump.
+func (h *Handler) sendButtons(buttons int) error {
+	h.mouseMu.Lock()
+	defer h.mouseMu.Unlock()
+
+	if h.mouseMode == protocol.MouseModeAbsolute && h.pointer != nil {
+		return h.pointer.SendMouseAbsolute(h.lastX, h.lastY, buttons, 0)
+	}
+	return h.robot.SendMouse(0, 0, buttons, 0)
+}
+
+// resetMouseMode returns the pointer to relative mode for a new session.
+func (h *Handler) resetMouseMode() {
+	h.mouseMu.Lock()
+	defer h.mouseMu.Unlock()
+	h.mouseMode = protocol.MouseModeRelative
+	h.lastX, h.lastY = 0, 0
+}
@@ -0,0 +1,158 @@
+package control
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// mockTabletRobot adds a tablet-style absolute pointer.
+type mockTabletRobot struct {
+	mockRobotAPI
+	absCalls []absMouseCall
+}
+
+type absMouseCall struct {
+	X, Y    float64
+	Buttons int
+}
+
+func (m *mockTabletRobot) SendMouseAbsolute(x, y float64, buttons, scroll int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.absCalls = append(m.absCalls, absMouseCall{X: x, Y: y, Buttons: buttons})
+	return nil
+}
+
+func ptr(f float64) *float64 {
+	return &f
+}
+
+func mouseModeMsg(mode string) *protocol.KVMMouseModeMessage {
+	return &protocol.KVMMouseModeMessage{
+		Type: protocol.TypeKVMMouseMode,
+		Mode: mode,
+		T:    time.Now().UnixMilli(),
+	}
+}
+
+func absMouseMsg(x, y float64, buttons int) *protocol.KVMMouseMessage {
+	return &protocol.KVMMouseMessage{
+		Type:    protocol.TypeKVMMouse,
+		X:       ptr(x),
+		Y:       ptr(y),
+		Buttons: buttons,
+		T:       time.Now().UnixMilli(),
+	}
+}
+
+func TestHandleKVMMouseMode_Unsupported(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h := NewHandler(robot, nil, nil, nil, 500*time.Millisecond)
+
+	if err := h.HandleKVMMouseMode(mouseModeMsg(protocol.MouseModeAbsolute)); !errors.Is(err, ErrMouseModeUnsupported) {
+		t.Errorf("expected ErrMouseModeUnsupported, got %v", err)
+	}
+	if h.MouseMode() != protocol.MouseModeRelative {
+		t.Errorf("expected relative mode, got %s", h.MouseMode())
+	}
+
+	caps := h.Capabilities()
+	if !slices.Equal(caps.MouseModes, []string{protocol.MouseModeRelative}) {
+		t.Errorf("unexpected mouse modes: %v", caps.MouseModes)
+	}
+	if slices.Contains(caps.Commands, protocol.TypeKVMMouseMode) {
+		t.Error("kvm_mouse_mode should not be advertised without an absolute pointer")
+	}
+}
+
+func TestHandleKVMMouse_AbsoluteMode(t *testing.T) {
+	robot := &mockTabletRobot{}
+	h := NewHandler(robot, nil, nil, nil, 500*time.Millisecond)
+	h.SetAbsolutePointer(robot)
+
+	caps := h.Capabilities()
+	if !slices.Contains(caps.MouseModes, protocol.MouseModeAbsolute) ||
+		!slices.Contains(caps.Commands, protocol.TypeKVMMouseMode) {
+		t.Errorf("expected absolute mode advertised, got %+v", caps)
+	}
+
+	// Absolute coordinates are rejected until the session selects the mode
+	var vErr *ValidationError
+	if err := h.HandleKVMMouse(absMouseMsg(0.5, 0.5, 0)); !errors.As(err, &vErr) || vErr.Code != ErrInvalidValue {
+		t.Fatalf("expected mode mismatch, got %v", err)
+	}
+
+	if err := h.HandleKVMMouseMode(mouseModeMsg(protocol.MouseModeAbsolute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.HandleKVMMouse(absMouseMsg(0.25, 0.75, 1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Relative deltas no longer apply
+	rel := &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, DX: 5, T: time.Now().UnixMilli()}
+	if err := h.HandleKVMMouse(rel); !errors.As(err, &vErr) || vErr.Code != ErrMissingField {
+		t.Errorf("expected missing x/y in absolute mode, got %v", err)
+	}
+
+	robot.mu.Lock()
+	if len(robot.absCalls) != 1 || robot.absCalls[0] != (absMouseCall{X: 0.25, Y: 0.75, Buttons: 1}) {
+		t.Errorf("unexpected absolute calls: %+v", robot.absCalls)
+	}
+	if len(robot.mouseCalls) != 0 {
+		t.Errorf("expected no relative calls, got %+v", robot.mouseCalls)
+	}
+	robot.mu.Unlock()
+
+	// Releasing the held button stays on the absolute device at the last position
+	if err := h.ReleaseInputs(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	robot.mu.Lock()
+	defer robot.mu.Unlock()
+	if got := robot.absCalls[len(robot.absCalls)-1]; got != (absMouseCall{X: 0.25, Y: 0.75}) {
+		t.Errorf("expected release at last position, got %+v", got)
+	}
+}
+
+func TestHandleKVMMouseMode_ReleasesButtonsOnSwitch(t *testing.T) {
+	robot := &mockTabletRobot{}
+	h := NewHandler(robot, nil, nil, nil, 500*time.Millisecond)
+	h.SetAbsolutePointer(robot)
+
+	press := &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, Buttons: 1, T: time.Now().UnixMilli()}
+	if err := h.HandleKVMMouse(press); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.HandleKVMMouseMode(mouseModeMsg(protocol.MouseModeAbsolute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	robot.mu.Lock()
+	if n := len(robot.mouseCalls); n != 2 || robot.mouseCalls[1].Buttons != 0 {
+		t.Errorf("expected relative button release before switch, got %+v", robot.mouseCalls)
+	}
+	robot.mu.Unlock()
+	if _, buttons := h.HeldInputs(); buttons != 0 {
+		t.Errorf("expected no held buttons, got %d", buttons)
+	}
+}
+
+func TestResetSession_RestoresRelativeMode(t *testing.T) {
+	robot := &mockTabletRobot{}
+	h := NewHandler(robot, nil, nil, nil, 500*time.Millisecond)
+	h.SetAbsolutePointer(robot)
+
+	if err := h.HandleKVMMouseMode(mouseModeMsg(protocol.MouseModeAbsolute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.ResetSession()
+
+	if h.MouseMode() != protocol.MouseModeRelative {
+		t.Errorf("expected relative mode after reset, got %s", h.MouseMode())
+	}
+}
@@ -68,6 +68,7 @@ type RateLimiter struct {
 	mu         sync.RWMutex
 	buckets    map[protocol.MessageType]*tokenBucket
 	limits     map[protocol.MessageType]int
+	denials    map[protocol.MessageType]uint64 // Never reset
 	logDenials bool
 }
 
@@ -91,6 +92,7 @@ func NewRateLimiterWithConfig(cfg RateLimiterConfig) *RateLimiter {
 	rl := &RateLimiter{
 		buckets:    make(map[protocol.MessageType]*tokenBucket),
 		limits:     make(map[protocol.MessageType]int),
+		denials:    make(map[protocol.MessageType]uint64),
 		logDenials: cfg.LogDenials,
 	}
 
@@ -130,13 +132,31 @@ func (rl *RateLimiter) Allow(msgType protocol.MessageType) bool {
 	}
 
 	allowed := bucket.allow()
-	if !allowed && logDenials {
-		log.Printf("rate limit exceeded: type=%s limit=%d Hz", msgType, limit)
+	if !allowed {
+		rl.mu.Lock()
+		rl.denials[msgType]++
+		rl.mu.Unlock()
+		if logDenials {
+			log.Printf("rate limit exceeded: type=%s limit=%d Hz", msgType, limit)
+		}
 	}
 
 	return allowed
 }
 
+// Denials returns how many commands of each type were rejected since the
+// limiter was created. Reset does not clear them.
+func (rl *RateLimiter) Denials() map[protocol.MessageType]uint64 {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	denials := make(map[protocol.MessageType]uint64, len(rl.denials))
+	for t, n := range rl.denials {
+		denials[t] = n
+	}
+	return denials
+}
+
 // Reset resets all rate limiters (e.g., for new session).
 func (rl *RateLimiter) Reset() {
 	rl.mu.Lock()
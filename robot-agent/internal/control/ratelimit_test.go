@@ -256,3 +256,25 @@ func TestRateLimiter_BurstRefill(t *testing.T) {
 		t.Errorf("expected at least 2 allowed after refill, got %d", allowed)
 	}
 }
+
+func TestRateLimiter_Denials(t *testing.T) {
+	rl := NewRateLimiter(1)
+
+	rl.Allow(protocol.TypeDrive)
+	rl.Allow(protocol.TypeDrive)
+	rl.Allow(protocol.TypeDrive)
+	rl.Allow(protocol.TypeKVMKey)
+	rl.Allow(protocol.TypeEStop)
+	rl.Allow(protocol.TypeEStop)
+
+	// Denial counts outlive the per-session reset
+	rl.Reset()
+
+	denials := rl.Denials()
+	if denials[protocol.TypeDrive] != 2 {
+		t.Errorf("expected 2 drive denials, got %d", denials[protocol.TypeDrive])
+	}
+	if len(denials) != 1 {
+		t.Errorf("expected only drive denials, got %v", denials)
+	}
+}
@@ -0,0 +1,105 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"sync"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// SequenceStats holds ordering statistics for sequenced control messages.
+type SequenceStats struct {
+	Applied      uint64 `json:"applied"`       // Sequenced messages accepted in order
+	Duplicates   uint64 `json:"duplicates"`    // Dropped: same seq as last applied
+	Reordered    uint64 `json:"reordered"`     // Dropped: older than last applied
+	Gaps         uint64 `json:"gaps"`          // Sequence numbers skipped (lost or still in flight)
+	LateReleases uint64 `json:"late_releases"` // Releases applied although not newer than last applied
+}
+
+// Dropped returns the total number of dropped messages.
+func (s SequenceStats) Dropped() uint64 {
+	return s.Duplicates + s.Reordered
+}
+
+// SequenceTracker enforces latest-wins ordering per message stream.
+// Messages without a sequence number (seq == 0) are always accepted, and
+// releases of held input are always applied (see AcceptRelease).
+type SequenceTracker struct {
+	mu      sync.Mutex
+	lastSeq map[protocol.MessageType]uint64
+	stats   SequenceStats
+}
+
+// NewSequenceTracker creates a new sequence tracker.
+func NewSequenceTracker() *SequenceTracker {
+	return &SequenceTracker{
+		lastSeq: make(map[protocol.MessageType]uint64),
+	}
+}
+
+// Accept reports whether a message with the given sequence number should be
+// applied. Accepted sequence numbers become the new high-water mark for the stream.
+func (t *SequenceTracker) Accept(stream protocol.MessageType, seq uint64) bool {
+	if seq == 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.acceptLocked(stream, seq)
+}
+
+// acceptLocked applies latest-wins ordering (caller must hold lock).
+func (t *SequenceTracker) acceptLocked(stream protocol.MessageType, seq uint64) bool {
+	last, seen := t.lastSeq[stream]
+	switch {
+	case seen && seq == last:
+		t.stats.Duplicates++
+		return false
+	case seen && seq < last:
+		t.stats.Reordered++
+		return false
+	}
+
+	if seen && seq > last+1 {
+		t.stats.Gaps += seq - last - 1
+	}
+	t.lastSeq[stream] = seq
+	t.stats.Applied++
+	return true
+}
+
+// AcceptRelease is Accept for a message that releases held input (key up
+// or mouse button release). It reports whether the message is in order; a
+// late release must still be applied so no input stays held, and is
+// counted as a late release without moving the high-water mark.
+func (t *SequenceTracker) AcceptRelease(stream protocol.MessageType, seq uint64) bool {
+	if seq == 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, seen := t.lastSeq[stream]; seen && seq <= last {
+		t.stats.LateReleases++
+		return false
+	}
+	return t.acceptLocked(stream, seq)
+}
+
+// Stats returns a snapshot of the ordering statistics.
+func (t *SequenceTracker) Stats() SequenceStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// Reset clears all stream state and statistics (e.g., for new session).
+func (t *SequenceTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastSeq = make(map[protocol.MessageType]uint64)
+	t.stats = SequenceStats{}
+}
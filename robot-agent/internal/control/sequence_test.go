@@ -0,0 +1,232 @@
+package control
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+func TestSequenceTracker_InOrder(t *testing.T) {
+	tr := NewSequenceTracker()
+
+	for seq := uint64(1); seq <= 5; seq++ {
+		if !tr.Accept(protocol.TypeDrive, seq) {
+			t.Fatalf("seq %d should be accepted", seq)
+		}
+	}
+
+	stats := tr.Stats()
+	if stats.Applied != 5 || stats.Dropped() != 0 || stats.Gaps != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSequenceTracker_DropsDuplicatesAndReordered(t *testing.T) {
+	tr := NewSequenceTracker()
+
+	tr.Accept(protocol.TypeDrive, 1)
+	tr.Accept(protocol.TypeDrive, 3)
+
+	if tr.Accept(protocol.TypeDrive, 3) {
+		t.Error("duplicate seq should be dropped")
+	}
+	if tr.Accept(protocol.TypeDrive, 2) {
+		t.Error("late seq should be dropped")
+	}
+	if !tr.Accept(protocol.TypeDrive, 4) {
+		t.Error("newer seq should be accepted")
+	}
+
+	stats := tr.Stats()
+	if stats.Applied != 3 {
+		t.Errorf("expected 3 applied, got %d", stats.Applied)
+	}
+	if stats.Duplicates != 1 {
+		t.Errorf("expected 1 duplicate, got %d", stats.Duplicates)
+	}
+	if stats.Reordered != 1 {
+		t.Errorf("expected 1 reordered, got %d", stats.Reordered)
+	}
+	if stats.Gaps != 1 {
+		t.Errorf("expected 1 gap, got %d", stats.Gaps)
+	}
+}
+
+func TestSequenceTracker_IndependentStreams(t *testing.T) {
+	tr := NewSequenceTracker()
+
+	tr.Accept(protocol.TypeDrive, 10)
+	if !tr.Accept(protocol.TypeKVMKey, 1) {
+		t.Error("kvm_key stream should be independent of drive stream")
+	}
+}
+
+func TestSequenceTracker_UnsequencedAlwaysAccepted(t *testing.T) {
+	tr := NewSequenceTracker()
+
+	tr.Accept(protocol.TypeDrive, 10)
+	for range 3 {
+		if !tr.Accept(protocol.TypeDrive, 0) {
+			t.Error("seq 0 should always be accepted")
+		}
+	}
+
+	if stats := tr.Stats(); stats.Applied != 1 {
+		t.Errorf("unsequenced messages should not be counted, got %d applied", stats.Applied)
+	}
+}
+
+func TestSequenceTracker_Reset(t *testing.T) {
+	tr := NewSequenceTracker()
+
+	tr.Accept(protocol.TypeDrive, 100)
+	tr.Reset()
+
+	if !tr.Accept(protocol.TypeDrive, 1) {
+		t.Error("seq 1 should be accepted after reset")
+	}
+	if stats := tr.Stats(); stats.Applied != 1 {
+		t.Errorf("expected stats cleared on reset, got %+v", stats)
+	}
+}
+
+func TestHandler_Drive_OutOfOrderDropped(t *testing.T) {
+	robot := &mockRobotAPI{}
+	safety := &mockSafetyCallback{}
+	h := NewHandler(robot, safety, nil, nil, 500*time.Millisecond)
+	now := time.Now().UnixMilli()
+
+	steps := []struct {
+		seq     uint64
+		dropped bool
+	}{
+		{seq: 1},
+		{seq: 3},
+		{seq: 2, dropped: true},
+		{seq: 3, dropped: true},
+		{seq: 4},
+	}
+
+	for _, step := range steps {
+		data, _ := json.Marshal(&protocol.DriveMessage{
+			Type: protocol.TypeDrive, V: 0.1, W: 0, T: now, Seq: step.seq,
+		})
+		ack, err := h.HandleMessage(data)
+		if step.dropped {
+			if err != ErrOutOfOrder {
+				t.Errorf("seq %d: expected ErrOutOfOrder, got %v", step.seq, err)
+			}
+			if ack != nil {
+				t.Errorf("seq %d: dropped command should not be acked", step.seq)
+			}
+		} else if err != nil {
+			t.Errorf("seq %d: unexpected error: %v", step.seq, err)
+		}
+	}
+
+	if len(robot.driveCalls) != 3 {
+		t.Errorf("expected 3 drive calls, got %d", len(robot.driveCalls))
+	}
+	if safety.invalidCount != 0 {
+		t.Errorf("reordering should not count as invalid, got %d", safety.invalidCount)
+	}
+
+	stats := h.SequenceStats()
+	if stats.Duplicates != 1 || stats.Reordered != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSequenceTracker_LateReleaseAccepted(t *testing.T) {
+	tr := NewSequenceTracker()
+
+	tr.Accept(protocol.TypeKVMKey, 7)
+	if tr.AcceptRelease(protocol.TypeKVMKey, 6) {
+		t.Error("late release should be reported as out of order")
+	}
+	if !tr.AcceptRelease(protocol.TypeKVMKey, 8) {
+		t.Error("newer release should be in order")
+	}
+	if tr.Accept(protocol.TypeKVMKey, 6) {
+		t.Error("late release must not lower the high-water mark")
+	}
+
+	stats := tr.Stats()
+	if stats.LateReleases != 1 || stats.Applied != 2 || stats.Reordered != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestHandler_KVMKey_LateKeyUpApplied(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h := NewHandler(robot, nil, nil, nil, 500*time.Millisecond)
+
+	down := keyMsg("KeyA", "down")
+	down.Seq = 5
+	if err := h.HandleKVMKey(down); err != nil {
+		t.Fatalf("A down: %v", err)
+	}
+	// B down (seq 7) overtakes A up (seq 6)
+	downB := keyMsg("KeyB", "down")
+	downB.Seq = 7
+	if err := h.HandleKVMKey(downB); err != nil {
+		t.Fatalf("B down: %v", err)
+	}
+	up := keyMsg("KeyA", "up")
+	up.Seq = 6
+	if err := h.HandleKVMKey(up); err != nil {
+		t.Fatalf("late A up should be applied, got %v", err)
+	}
+
+	if keys, _ := h.inputs.Held(); len(keys) != 1 || keys[0] != "KeyB" {
+		t.Errorf("expected only KeyB held, got %v", keys)
+	}
+	last := robot.keyCalls[len(robot.keyCalls)-1]
+	if last.Key != "KeyA" || last.Action != "up" {
+		t.Errorf("expected KeyA up sent to host, got %+v", last)
+	}
+
+	// A late key down is still dropped
+	late := keyMsg("KeyC", "down")
+	late.Seq = 6
+	if err := h.HandleKVMKey(late); err != ErrOutOfOrder {
+		t.Errorf("expected late key down dropped, got %v", err)
+	}
+	if stats := h.SequenceStats(); stats.LateReleases != 1 || stats.Reordered != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestHandler_KVMMouse_LateButtonReleaseApplied(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h := NewHandler(robot, nil, nil, nil, 500*time.Millisecond)
+	now := time.Now().UnixMilli()
+	mouse := func(seq uint64, dx, buttons int) error {
+		return h.HandleKVMMouse(&protocol.KVMMouseMessage{
+			Type: protocol.TypeKVMMouse, DX: dx, Buttons: buttons, T: now, Seq: seq,
+		})
+	}
+
+	// Left + right pressed, then a move with right still held overtakes
+	// the release of left
+	mouse(1, 0, 0b11)
+	mouse(3, 5, 0b11)
+	if err := mouse(2, 9, 0b10); err != nil {
+		t.Fatalf("late release should be applied, got %v", err)
+	}
+
+	last := robot.mouseCalls[len(robot.mouseCalls)-1]
+	if last != (MouseCommand{Buttons: 0b10}) {
+		t.Errorf("expected release of left without movement, got %+v", last)
+	}
+	if _, buttons := h.inputs.Held(); buttons != 0b10 {
+		t.Errorf("expected right button still held, got %b", buttons)
+	}
+
+	// A late message without releases is still dropped
+	if err := mouse(2, 9, 0b10); err != ErrOutOfOrder {
+		t.Errorf("expected late move dropped, got %v", err)
+	}
+}
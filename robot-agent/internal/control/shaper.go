@@ -0,0 +1,220 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxShaperStep caps the integration step so a long idle gap cannot
+// produce a large velocity jump on the next command.
+const maxShaperStep = 100 * time.Millisecond
+
+// convergenceEpsilon is the distance at which output is considered at target.
+const convergenceEpsilon = 1e-6
+
+// ShaperConfig holds motion shaping limits for drive commands.
+// Velocities are normalized to [-1, 1]; limits are in normalized units per
+// second (acceleration) and per second squared (jerk). Zero disables a limit.
+type ShaperConfig struct {
+	MaxLinearAccel  float64
+	MaxAngularAccel float64
+	MaxLinearJerk   float64
+	MaxAngularJerk  float64
+	Deadband        float64       // Inputs with |x| below this are treated as zero
+	MaxSpeed        float64       // Default maximum speed fraction (0 = 1.0)
+	TickInterval    time.Duration // Output rate while ramping toward target
+}
+
+// axis is the shaped state of a single velocity axis.
+type axis struct {
+	target   float64
+	value    float64
+	accel    float64
+	maxAccel float64
+	maxJerk  float64
+}
+
+// step advances the axis toward its target over dt seconds.
+func (a *axis) step(dt float64) {
+	diff := a.target - a.value
+	if math.Abs(diff) < convergenceEpsilon {
+		a.value = a.target
+		a.accel = 0
+		return
+	}
+
+	if a.maxAccel <= 0 {
+		a.value = a.target
+		a.accel = 0
+		return
+	}
+	if dt <= 0 {
+		return
+	}
+
+	desired := clamp(diff/dt, a.maxAccel)
+	if a.maxJerk > 0 {
+		desired = a.accel + clamp(desired-a.accel, a.maxJerk*dt)
+	}
+
+	next := a.value + desired*dt
+	// Never overshoot the target
+	if (diff > 0 && next > a.target) || (diff < 0 && next < a.target) {
+		next = a.target
+		desired = 0
+	}
+
+	a.value = next
+	a.accel = desired
+}
+
+// converged reports whether the axis output has reached its target.
+func (a *axis) converged() bool {
+	return a.value == a.target
+}
+
+// MotionShaper applies deadband, speed scaling and acceleration/jerk limits
+// to drive commands.
+type MotionShaper struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	deadband float64
+	maxSpeed float64
+	tick     time.Duration
+	linear   axis
+	angular  axis
+	last     time.Time
+}
+
+// NewMotionShaper creates a motion shaper using the wall clock.
+func NewMotionShaper(cfg ShaperConfig) *MotionShaper {
+	return newMotionShaperWithClock(cfg, time.Now)
+}
+
+// newMotionShaperWithClock creates a motion shaper with an injected clock.
+func newMotionShaperWithClock(cfg ShaperConfig, now func() time.Time) *MotionShaper {
+	maxSpeed := cfg.MaxSpeed
+	if maxSpeed <= 0 || maxSpeed > 1 {
+		maxSpeed = 1
+	}
+	tick := cfg.TickInterval
+	if tick <= 0 {
+		tick = 20 * time.Millisecond
+	}
+	return &MotionShaper{
+		now:      now,
+		deadband: cfg.Deadband,
+		maxSpeed: maxSpeed,
+		tick:     tick,
+		linear:   axis{maxAccel: cfg.MaxLinearAccel, maxJerk: cfg.MaxLinearJerk},
+		angular:  axis{maxAccel: cfg.MaxAngularAccel, maxJerk: cfg.MaxAngularJerk},
+	}
+}
+
+// TickInterval returns the output rate while ramping.
+func (s *MotionShaper) TickInterval() time.Duration {
+	return s.tick
+}
+
+// SetMaxSpeed sets the maximum speed fraction applied to subsequent targets.
+func (s *MotionShaper) SetMaxSpeed(fraction float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fraction <= 0 || fraction > 1 {
+		fraction = 1
+	}
+	s.maxSpeed = fraction
+}
+
+// MaxSpeed returns the current maximum speed fraction.
+func (s *MotionShaper) MaxSpeed() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxSpeed
+}
+
+// SetTarget sets new raw velocity targets and returns the shaped output for
+// the current instant.
+func (s *MotionShaper) SetTarget(v, w float64) (float64, float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.linear.target = s.scale(v)
+	s.angular.target = s.scale(w)
+	return s.stepLocked()
+}
+
+// Step advances toward the current targets and returns the shaped output.
+func (s *MotionShaper) Step() (float64, float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stepLocked()
+}
+
+// RampToZero sets zero targets so subsequent steps decelerate smoothly.
+func (s *MotionShaper) RampToZero() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.linear.target = 0
+	s.angular.target = 0
+}
+
+// Converged reports whether the output has reached the targets.
+func (s *MotionShaper) Converged() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.linear.converged() && s.angular.converged()
+}
+
+// Output returns the last shaped velocities.
+func (s *MotionShaper) Output() (float64, float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.linear.value, s.angular.value
+}
+
+// Reset zeroes all state immediately (used by e-stop, bypassing limits).
+func (s *MotionShaper) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.linear = axis{maxAccel: s.linear.maxAccel, maxJerk: s.linear.maxJerk}
+	s.angular = axis{maxAccel: s.angular.maxAccel, maxJerk: s.angular.maxJerk}
+	s.last = time.Time{}
+}
+
+// stepLocked integrates both axes (caller must hold lock).
+func (s *MotionShaper) stepLocked() (float64, float64) {
+	now := s.now()
+	dt := maxShaperStep
+	if !s.last.IsZero() {
+		dt = min(now.Sub(s.last), maxShaperStep)
+	}
+	s.last = now
+
+	s.linear.step(dt.Seconds())
+	s.angular.step(dt.Seconds())
+	return s.linear.value, s.angular.value
+}
+
+// scale applies deadband and speed fraction to a raw input.
+func (s *MotionShaper) scale(x float64) float64 {
+	mag := math.Abs(x)
+	if mag <= s.deadband {
+		return 0
+	}
+	// Rescale so output is continuous at the deadband edge
+	if s.deadband > 0 && s.deadband < 1 {
+		mag = (mag - s.deadband) / (1 - s.deadband)
+	}
+	return math.Copysign(math.Min(mag, 1)*s.maxSpeed, x)
+}
+
+// clamp limits x to [-limit, limit].
+func clamp(x, limit float64) float64 {
+	return math.Max(-limit, math.Min(limit, x))
+}
@@ -0,0 +1,306 @@
+package control
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// fakeClock is a manually advanced clock for deterministic shaping tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestMotionShaper_AccelerationLimit(t *testing.T) {
+	clock := newFakeClock()
+	s := newMotionShaperWithClock(ShaperConfig{MaxLinearAccel: 2.0, MaxAngularAccel: 4.0}, clock.Now)
+
+	// First command after idle integrates over the capped step (100ms)
+	v, w := s.SetTarget(1.0, -1.0)
+	if !approxEqual(v, 0.2) || !approxEqual(w, -0.4) {
+		t.Fatalf("expected v=0.2 w=-0.4 after first step, got v=%f w=%f", v, w)
+	}
+
+	clock.Advance(50 * time.Millisecond)
+	v, w = s.Step()
+	if !approxEqual(v, 0.3) || !approxEqual(w, -0.6) {
+		t.Errorf("expected v=0.3 w=-0.6, got v=%f w=%f", v, w)
+	}
+
+	for range 10 {
+		clock.Advance(50 * time.Millisecond)
+		s.Step()
+	}
+	v, w = s.Output()
+	if v != 1.0 || w != -1.0 {
+		t.Errorf("expected to converge to target, got v=%f w=%f", v, w)
+	}
+	if !s.Converged() {
+		t.Error("expected Converged() after reaching target")
+	}
+}
+
+func TestMotionShaper_ReversalIsRateLimited(t *testing.T) {
+	clock := newFakeClock()
+	s := newMotionShaperWithClock(ShaperConfig{MaxLinearAccel: 2.0}, clock.Now)
+
+	s.SetTarget(1.0, 0)
+	for range 10 {
+		clock.Advance(100 * time.Millisecond)
+		s.Step()
+	}
+
+	// Slam to full reverse in one frame
+	clock.Advance(20 * time.Millisecond)
+	v, _ := s.SetTarget(-1.0, 0)
+	if !approxEqual(v, 0.96) {
+		t.Errorf("expected v=0.96 after 20ms of max decel, got %f", v)
+	}
+}
+
+func TestMotionShaper_JerkLimit(t *testing.T) {
+	clock := newFakeClock()
+	s := newMotionShaperWithClock(ShaperConfig{MaxLinearAccel: 2.0, MaxLinearJerk: 10.0}, clock.Now)
+
+	s.Step() // establish time base
+	clock.Advance(50 * time.Millisecond)
+	v, _ := s.SetTarget(1.0, 0)
+
+	// accel limited to jerk*dt = 0.5, so v = 0.5*0.05
+	if !approxEqual(v, 0.025) {
+		t.Errorf("expected v=0.025 under jerk limit, got %f", v)
+	}
+}
+
+func TestMotionShaper_Deadband(t *testing.T) {
+	clock := newFakeClock()
+	s := newMotionShaperWithClock(ShaperConfig{Deadband: 0.1}, clock.Now)
+
+	v, w := s.SetTarget(0.05, -0.09)
+	if v != 0 || w != 0 {
+		t.Errorf("inputs inside deadband should be zero, got v=%f w=%f", v, w)
+	}
+
+	v, _ = s.SetTarget(0.55, 0)
+	if !approxEqual(v, 0.5) {
+		t.Errorf("expected deadband rescaling to 0.5, got %f", v)
+	}
+
+	v, _ = s.SetTarget(1.0, 0)
+	if v != 1.0 {
+		t.Errorf("full input should map to full output, got %f", v)
+	}
+}
+
+func TestMotionShaper_SpeedFraction(t *testing.T) {
+	clock := newFakeClock()
+	s := newMotionShaperWithClock(ShaperConfig{}, clock.Now)
+	s.SetMaxSpeed(0.3)
+
+	v, w := s.SetTarget(1.0, -0.5)
+	if !approxEqual(v, 0.3) || !approxEqual(w, -0.15) {
+		t.Errorf("expected v=0.3 w=-0.15, got v=%f w=%f", v, w)
+	}
+
+	s.SetMaxSpeed(0)
+	if s.MaxSpeed() != 1 {
+		t.Errorf("invalid fraction should reset to 1, got %f", s.MaxSpeed())
+	}
+}
+
+func TestMotionShaper_RampToZeroAndReset(t *testing.T) {
+	clock := newFakeClock()
+	s := newMotionShaperWithClock(ShaperConfig{MaxLinearAccel: 2.0}, clock.Now)
+
+	s.SetTarget(1.0, 0)
+	for range 10 {
+		clock.Advance(100 * time.Millisecond)
+		s.Step()
+	}
+
+	s.RampToZero()
+	clock.Advance(100 * time.Millisecond)
+	if v, _ := s.Step(); !approxEqual(v, 0.8) {
+		t.Errorf("expected smooth decel to 0.8, got %f", v)
+	}
+
+	s.Reset()
+	if v, w := s.Output(); v != 0 || w != 0 {
+		t.Errorf("expected zero output after reset, got v=%f w=%f", v, w)
+	}
+}
+
+// manualTicker is a ticker driven by the test.
+type manualTicker struct {
+	ch chan time.Time
+}
+
+func (m *manualTicker) C() <-chan time.Time { return m.ch }
+func (m *manualTicker) Stop()               {}
+
+// newShapedHandler creates a handler with a deterministic shaper and ticker.
+func newShapedHandler(robot *mockRobotAPI, clock *fakeClock) (*Handler, *manualTicker) {
+	tk := &manualTicker{ch: make(chan time.Time)}
+	h := NewHandler(robot, nil, nil, nil, 500*time.Millisecond)
+	h.newTicker = func(time.Duration) ticker { return tk }
+	h.SetMotionShaper(newMotionShaperWithClock(ShaperConfig{MaxLinearAccel: 2.0}, clock.Now))
+	return h, tk
+}
+
+// tick advances the clock and delivers one tick to the ramp loop.
+func (m *manualTicker) tick(clock *fakeClock, d time.Duration) {
+	clock.Advance(d)
+	m.ch <- clock.Now()
+}
+
+func (m *mockRobotAPI) lastDrive() DriveCommand {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.driveCalls[len(m.driveCalls)-1]
+}
+
+func waitForDriveCalls(t *testing.T, robot *mockRobotAPI, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		robot.mu.Lock()
+		got := len(robot.driveCalls)
+		robot.mu.Unlock()
+		if got >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d drive calls", n)
+}
+
+func TestHandler_ShapedDrive_RampsTowardTarget(t *testing.T) {
+	robot := &mockRobotAPI{}
+	clock := newFakeClock()
+	h, tk := newShapedHandler(robot, clock)
+
+	err := h.HandleDrive(&protocol.DriveMessage{Type: protocol.TypeDrive, V: 1.0, T: time.Now().UnixMilli()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := robot.lastDrive().V; !approxEqual(v, 0.2) {
+		t.Fatalf("expected shaped v=0.2, got %f", v)
+	}
+
+	// Ramp loop continues toward target without further commands
+	for i := 2; i <= 5; i++ {
+		tk.tick(clock, 100*time.Millisecond)
+		waitForDriveCalls(t, robot, i)
+	}
+	if v := robot.lastDrive().V; !approxEqual(v, 1.0) {
+		t.Errorf("expected v=1.0 after ramp, got %f", v)
+	}
+}
+
+func TestHandler_RampToStop(t *testing.T) {
+	robot := &mockRobotAPI{}
+	clock := newFakeClock()
+	h, tk := newShapedHandler(robot, clock)
+
+	h.HandleDrive(&protocol.DriveMessage{Type: protocol.TypeDrive, V: 0.2, T: time.Now().UnixMilli()})
+	if !h.RampToStop() {
+		t.Fatal("expected RampToStop to use shaper")
+	}
+
+	tk.tick(clock, 100*time.Millisecond)
+	waitForDriveCalls(t, robot, 2)
+	if v := robot.lastDrive().V; v != 0 {
+		t.Errorf("expected ramp to reach zero, got %f", v)
+	}
+	if robot.estopCalls != 0 {
+		t.Error("smooth ramp should not issue e-stop")
+	}
+}
+
+func TestHandler_EStopBypassesShaper(t *testing.T) {
+	robot := &mockRobotAPI{}
+	clock := newFakeClock()
+	h, _ := newShapedHandler(robot, clock)
+
+	h.HandleDrive(&protocol.DriveMessage{Type: protocol.TypeDrive, V: 1.0, T: time.Now().UnixMilli()})
+	if err := h.HandleEStop(&protocol.EStopMessage{Type: protocol.TypeEStop}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if robot.estopCalls != 1 {
+		t.Errorf("expected immediate e-stop, got %d calls", robot.estopCalls)
+	}
+	if v, _ := h.shaper.Output(); v != 0 {
+		t.Errorf("expected shaper state zeroed by e-stop, got %f", v)
+	}
+}
+
+func TestHandler_RampToStop_NoShaper(t *testing.T) {
+	h := NewHandler(&mockRobotAPI{}, nil, nil, nil, 500*time.Millisecond)
+
+	if h.RampToStop() {
+		t.Error("RampToStop should report false without a shaper")
+	}
+}
+
+// rejectFilter refuses every drive command.
+type rejectFilter struct{}
+
+func (rejectFilter) FilterDrive(v, w float64) (float64, float64, error) {
+	return 0, 0, ErrScopeNotAllowed
+}
+
+func TestHandler_FilterRejectionHaltsImmediately(t *testing.T) {
+	robot := &mockRobotAPI{}
+	clock := newFakeClock()
+	h, tk := newShapedHandler(robot, clock)
+	safety := &mockSafetyCallback{}
+	h.safety = safety
+
+	h.HandleDrive(&protocol.DriveMessage{Type: protocol.TypeDrive, V: 1.0, T: time.Now().UnixMilli()})
+	for i := 2; i <= 3; i++ {
+		tk.tick(clock, 100*time.Millisecond)
+		waitForDriveCalls(t, robot, i)
+	}
+
+	h.AddDriveFilter(rejectFilter{})
+	err := h.HandleDrive(&protocol.DriveMessage{Type: protocol.TypeDrive, V: 1.0, T: time.Now().UnixMilli()})
+	if err != ErrScopeNotAllowed {
+		t.Fatalf("expected filter rejection, got %v", err)
+	}
+
+	// Velocity drops at once instead of ramping down through the shaper
+	if got := robot.lastDrive(); got.V != 0 || got.W != 0 {
+		t.Errorf("expected immediate zero velocity, got %+v", got)
+	}
+	if v, _ := h.shaper.Output(); v != 0 {
+		t.Errorf("expected shaper state zeroed, got %f", v)
+	}
+	if safety.validCount != 1 {
+		t.Errorf("rejected command must not refresh control, got %d valid callbacks", safety.validCount)
+	}
+}
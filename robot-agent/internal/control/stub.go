@@ -38,6 +38,16 @@ func (s *StubRobotAPI) SendMouse(dx, dy, buttons, scroll int) error {
 	return nil
 }
 
+// SendMouseAbsolute logs absolute mouse command (no-op for POC).
+func (s *StubRobotAPI) SendMouseAbsolute(x, y float64, buttons, scroll int) error {
+	s.logger.Debug("absolute mouse command",
+		zap.Float64("x", x),
+		zap.Float64("y", y),
+		zap.Int("buttons", buttons),
+		zap.Int("scroll", scroll))
+	return nil
+}
+
 // EStop logs emergency stop (no-op for POC).
 func (s *StubRobotAPI) EStop() error {
 	s.logger.Warn("emergency stop triggered")
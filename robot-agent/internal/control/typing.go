@@ -0,0 +1,180 @@
+// Package control implements control command handling for the Robot Agent.
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+const (
+	// defaultTypeInterval paces typed characters (~30 characters/second).
+	defaultTypeInterval = 33 * time.Millisecond
+	// defaultMaxTypeLength bounds a single kvm_type command (characters).
+	defaultMaxTypeLength = 1024
+)
+
+// SetTypingRate sets how many characters per second kvm_type sends.
+func (h *Handler) SetTypingRate(charsPerSecond int) {
+	if charsPerSecond <= 0 {
+		return
+	}
+	h.typingMu.Lock()
+	defer h.typingMu.Unlock()
+	h.typeInterval = time.Second / time.Duration(charsPerSecond)
+}
+
+// SetMaxTypeLength sets the maximum kvm_type text length in characters.
+func (h *Handler) SetMaxTypeLength(n int) {
+	h.maxTypeLen = n
+}
+
+// SetTextTypedCallback sets the callback for started kvm_type commands.
+func (h *Handler) SetTextTypedCallback(fn TextTypedCallback) {
+	h.onTextTyped = fn
+}
+
+// HandleKVMType processes a text typing command. The text is typed
+// asynchronously and is canceled by e-stop, release or any new KVM command.
+func (h *Handler) HandleKVMType(msg *protocol.KVMTypeMessage) error {
+	if !h.hasScope(ScopeControl) {
+		return ErrScopeNotAllowed
+	}
+
+	if err := h.validator.ValidateKVMType(msg, h.maxTypeLen); err != nil {
+		h.notifyInvalid()
+		return err
+	}
+
+	if !h.sequences.Accept(protocol.TypeKVMType, msg.Seq) {
+		return ErrOutOfOrder
+	}
+
+	strokes, err := expandText(msg.Text, msg.Layout)
+	if err != nil {
+		h.notifyInvalid()
+		return err
+	}
+
+	// Modifiers held by earlier kvm_key downs apply to every typed key,
+	// so the text must not complete a denied chord with them
+	h.CancelTyping()
+	for _, s := range strokes {
+		var mods []string
+		if s.shift {
+			mods = []string{"shift"}
+		}
+		if err := h.checkKeyPolicy(s.code, mods); err != nil {
+			return err
+		}
+	}
+
+	h.startTyping(strokes)
+
+	if h.onTextTyped != nil {
+		layout := msg.Layout
+		if layout == "" {
+			layout = DefaultLayout
+		}
+		sum := sha256.Sum256([]byte(msg.Text))
+		h.onTextTyped(hex.EncodeToString(sum[:]), utf8.RuneCountInString(msg.Text), layout)
+	}
+
+	h.notifyValid()
+	return nil
+}
+
+// CancelTyping stops text being typed. When it returns, no further
+// keystrokes from the canceled text will be sent.
+func (h *Handler) CancelTyping() {
+	h.typingMu.Lock()
+	defer h.typingMu.Unlock()
+
+	if h.typeStop != nil {
+		close(h.typeStop)
+		h.typeStop = nil
+	}
+}
+
+// startTyping replaces any text in progress with strokes.
+func (h *Handler) startTyping(strokes []keystroke) {
+	h.typingMu.Lock()
+	defer h.typingMu.Unlock()
+
+	if h.typeStop != nil {
+		close(h.typeStop)
+	}
+	stop := make(chan struct{})
+	h.typeStop = stop
+	go h.runTyping(stop, strokes, h.typeInterval)
+}
+
+// runTyping sends one keystroke per tick until done or canceled.
+func (h *Handler) runTyping(stop chan struct{}, strokes []keystroke, interval time.Duration) {
+	ticker := h.newTicker(interval)
+	defer ticker.Stop()
+
+	for i, s := range strokes {
+		if i > 0 {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C():
+			}
+		}
+		if done := h.typeStroke(stop, s); done {
+			return
+		}
+	}
+
+	h.typingMu.Lock()
+	if h.typeStop == stop {
+		h.typeStop = nil
+	}
+	h.typingMu.Unlock()
+}
+
+// typeStroke sends one character and reports whether typing must stop.
+// It holds typingMu so cancellation cannot interleave with a keystroke
+// and leave Shift held.
+func (h *Handler) typeStroke(stop chan struct{}, s keystroke) bool {
+	h.typingMu.Lock()
+	defer h.typingMu.Unlock()
+
+	select {
+	case <-stop:
+		return true
+	default:
+	}
+
+	var mods []string
+	if s.shift {
+		mods = []string{"shift"}
+		if !h.sendTypedKey("ShiftLeft", "down", nil) {
+			return true
+		}
+	}
+	ok := h.sendTypedKey(s.code, "down", mods) && h.sendTypedKey(s.code, "up", mods)
+	if s.shift {
+		ok = h.sendTypedKey("ShiftLeft", "up", nil) && ok
+	}
+	if !ok {
+		h.typeStop = nil
+		return true
+	}
+	return false
+}
+
+// sendTypedKey sends and tracks one key event from typed text.
+func (h *Handler) sendTypedKey(key, action string, mods []string) bool {
+	if err := h.robot.SendKey(key, action, mods); err != nil {
+		log.Printf("control: key send failed while typing, canceling: %v", err)
+		return false
+	}
+	h.inputs.Key(key, action)
+	return true
+}
@@ -0,0 +1,221 @@
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+func typeMsg(text, layout string) *protocol.KVMTypeMessage {
+	return &protocol.KVMTypeMessage{
+		Type:   protocol.TypeKVMType,
+		Text:   text,
+		Layout: layout,
+		T:      time.Now().UnixMilli(),
+	}
+}
+
+func newTypingHandler(robot *mockRobotAPI) (*Handler, *manualTicker) {
+	tk := &manualTicker{ch: make(chan time.Time)}
+	h := NewHandler(robot, nil, nil, nil, 500*time.Millisecond)
+	h.newTicker = func(time.Duration) ticker { return tk }
+	return h, tk
+}
+
+func waitForKeyCalls(t *testing.T, robot *mockRobotAPI, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		robot.mu.Lock()
+		got := len(robot.keyCalls)
+		robot.mu.Unlock()
+		if got >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d key calls", n)
+}
+
+func TestExpandText(t *testing.T) {
+	strokes, err := expandText("aB@", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []keystroke{{code: "KeyA"}, {code: "KeyB", shift: true}, {code: "Digit2", shift: true}}
+	if len(strokes) != len(want) {
+		t.Fatalf("expected %d strokes, got %d", len(want), len(strokes))
+	}
+	for i := range want {
+		if strokes[i] != want[i] {
+			t.Errorf("stroke %d: expected %+v, got %+v", i, want[i], strokes[i])
+		}
+	}
+
+	// Layout changes the physical key for the same character
+	gb, err := expandText("@", "gb")
+	if err != nil || gb[0] != (keystroke{code: "Quote", shift: true}) {
+		t.Errorf("unexpected gb stroke: %+v (err %v)", gb, err)
+	}
+
+	if _, err := expandText("a", "xx"); err == nil {
+		t.Error("expected error for unknown layout")
+	}
+	if _, err := expandText("snow☃", "us"); err == nil {
+		t.Error("expected error for untypeable character")
+	} else if strings.Contains(err.Error(), "☃") {
+		t.Error("error must not include the typed text")
+	}
+}
+
+func TestHandleKVMType_TypesAtTickRate(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h, tk := newTypingHandler(robot)
+
+	if err := h.HandleKVMType(typeMsg("aB", "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// First character is typed immediately, the next waits for a tick
+	waitForKeyCalls(t, robot, 2)
+	robot.mu.Lock()
+	if len(robot.keyCalls) != 2 {
+		t.Errorf("expected only first character before tick, got %d calls", len(robot.keyCalls))
+	}
+	robot.mu.Unlock()
+
+	tk.ch <- time.Now()
+	waitForKeyCalls(t, robot, 6)
+
+	robot.mu.Lock()
+	defer robot.mu.Unlock()
+	want := []KeyCommand{
+		{Key: "KeyA", Action: "down"},
+		{Key: "KeyA", Action: "up"},
+		{Key: "ShiftLeft", Action: "down"},
+		{Key: "KeyB", Action: "down", Modifiers: []string{"shift"}},
+		{Key: "KeyB", Action: "up", Modifiers: []string{"shift"}},
+		{Key: "ShiftLeft", Action: "up"},
+	}
+	for i, w := range want {
+		got := robot.keyCalls[i]
+		if got.Key != w.Key || got.Action != w.Action || len(got.Modifiers) != len(w.Modifiers) {
+			t.Errorf("call %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+}
+
+func TestHandleKVMType_CanceledByEStop(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h, tk := newTypingHandler(robot)
+
+	h.HandleKVMType(typeMsg("abc", ""))
+	waitForKeyCalls(t, robot, 2)
+
+	h.HandleEStop(&protocol.EStopMessage{Type: protocol.TypeEStop, T: time.Now().UnixMilli()})
+
+	// Further ticks are not consumed by a canceled typer
+	select {
+	case tk.ch <- time.Now():
+		t.Error("typing continued after e-stop")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	robot.mu.Lock()
+	defer robot.mu.Unlock()
+	if len(robot.keyCalls) != 2 {
+		t.Errorf("expected no keystrokes after e-stop, got %d calls", len(robot.keyCalls))
+	}
+}
+
+func TestHandleKVMType_CanceledByManualInput(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h, _ := newTypingHandler(robot)
+
+	h.HandleKVMType(typeMsg("abc", ""))
+	waitForKeyCalls(t, robot, 2)
+
+	if err := h.HandleKVMKey(keyMsg("Enter", "down")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h.typingMu.Lock()
+	defer h.typingMu.Unlock()
+	if h.typeStop != nil {
+		t.Error("expected typing canceled by manual key input")
+	}
+}
+
+func TestHandleKVMType_HeldModifiersCountTowardChord(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h, _ := newTypingHandler(robot)
+	policy, err := NewKeyPolicy([]string{"ctrl+alt+KeyT"})
+	if err != nil {
+		t.Fatalf("NewKeyPolicy: %v", err)
+	}
+	h.SetKeyPolicy(policy)
+	h.scopes = &mockScopeChecker{allowedScopes: map[string]bool{ScopeControl: true}}
+
+	h.HandleKVMKey(keyMsg("ControlLeft", "down"))
+	h.HandleKVMKey(keyMsg("AltLeft", "down"))
+	if err := h.HandleKVMType(typeMsg("t", "")); err != ErrChordDenied {
+		t.Fatalf("expected held ctrl+alt + typed t to be denied, got %v", err)
+	}
+
+	robot.mu.Lock()
+	defer robot.mu.Unlock()
+	for _, call := range robot.keyCalls {
+		if call.Key == "KeyT" {
+			t.Fatal("denied typed key must not reach the host")
+		}
+	}
+}
+
+func TestHandleKVMType_Validation(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h, _ := newTypingHandler(robot)
+	h.SetMaxTypeLength(4)
+
+	var vErr *ValidationError
+	if err := h.HandleKVMType(typeMsg("", "")); !errors.As(err, &vErr) || vErr.Code != ErrMissingField {
+		t.Errorf("expected missing field, got %v", err)
+	}
+	if err := h.HandleKVMType(typeMsg("hello", "")); !errors.As(err, &vErr) || vErr.Code != ErrOutOfRange {
+		t.Errorf("expected length limit, got %v", err)
+	}
+	if err := h.HandleKVMType(typeMsg("\xff", "")); !errors.As(err, &vErr) || vErr.Code != ErrInvalidValue {
+		t.Errorf("expected invalid UTF-8, got %v", err)
+	}
+}
+
+func TestHandleKVMType_CallbackReceivesHashOnly(t *testing.T) {
+	robot := &mockRobotAPI{}
+	h, _ := newTypingHandler(robot)
+
+	var gotHash, gotLayout string
+	var gotLen int
+	h.SetTextTypedCallback(func(hash string, length int, layout string) {
+		gotHash, gotLen, gotLayout = hash, length, layout
+	})
+
+	if err := h.HandleKVMType(typeMsg("pässword", "")); err == nil {
+		t.Fatal("expected untypeable character on us layout")
+	}
+	if gotHash != "" {
+		t.Error("callback should not fire for rejected text")
+	}
+
+	if err := h.HandleKVMType(typeMsg("secret", "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sum := sha256.Sum256([]byte("secret"))
+	if gotHash != hex.EncodeToString(sum[:]) || gotLen != 6 || gotLayout != DefaultLayout {
+		t.Errorf("unexpected callback: hash=%s len=%d layout=%s", gotHash, gotLen, gotLayout)
+	}
+	h.CancelTyping()
+}
@@ -3,18 +3,31 @@ package control
 
 import (
 	"fmt"
+	"math"
 	"time"
+	"unicode/utf8"
 
 	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
 )
 
 // Validation error codes.
 const (
-	ErrOutOfRange        = "OUT_OF_RANGE"
-	ErrStaleCommand      = "STALE_COMMAND"
-	ErrInvalidTimestamp  = "INVALID_TIMESTAMP"
-	ErrMissingField      = "MISSING_FIELD"
-	ErrInvalidValue      = "INVALID_VALUE"
+	ErrOutOfRange       = "OUT_OF_RANGE"
+	ErrStaleCommand     = "STALE_COMMAND"
+	ErrInvalidTimestamp = "INVALID_TIMESTAMP"
+	ErrMissingField     = "MISSING_FIELD"
+	ErrInvalidValue     = "INVALID_VALUE"
+)
+
+// maxDriveValidityMS bounds how long a single drive command may stay in effect.
+const maxDriveValidityMS = 5000
+
+// Mouse report limits. Relative moves inside these bounds are clamped by
+// the backend; anything outside them is malformed.
+const (
+	maxMouseDelta   = 32767 // 16-bit HID relative axis
+	maxMouseScroll  = 127   // 8-bit HID wheel
+	maxMouseButtons = 0x1F  // Left, right, middle, back, forward
 )
 
 // ValidationError represents a command validation failure.
@@ -34,6 +47,7 @@ func (e *ValidationError) Error() string {
 // Validator validates incoming control messages.
 type Validator struct {
 	staleThreshold time.Duration
+	clockOffset    ClockOffsetSource
 }
 
 // NewValidator creates a new message validator.
@@ -43,6 +57,12 @@ func NewValidator(staleThreshold time.Duration) *Validator {
 	}
 }
 
+// SetClockOffsetSource sets the console clock offset used to correct
+// command timestamps before the staleness check.
+func (v *Validator) SetClockOffsetSource(src ClockOffsetSource) {
+	v.clockOffset = src
+}
+
 // ValidateDrive validates a drive command message.
 func (v *Validator) ValidateDrive(msg *protocol.DriveMessage) error {
 	if err := v.checkStale(msg.T); err != nil {
@@ -66,6 +86,14 @@ func (v *Validator) ValidateDrive(msg *protocol.DriveMessage) error {
 		}
 	}
 
+	if msg.ValidMS < 0 || msg.ValidMS > maxDriveValidityMS {
+		return &ValidationError{
+			Code:    ErrOutOfRange,
+			Message: fmt.Sprintf("validity window must be in range [0, %d] ms", maxDriveValidityMS),
+			Field:   "valid_ms",
+		}
+	}
+
 	return nil
 }
 
@@ -100,8 +128,128 @@ func (v *Validator) ValidateKVMMouse(msg *protocol.KVMMouseMessage) error {
 		return err
 	}
 
-	// Mouse movements are clamped, not rejected
-	// No additional validation needed
+	if msg.Buttons < 0 || msg.Buttons > maxMouseButtons {
+		return &ValidationError{
+			Code:    ErrOutOfRange,
+			Message: fmt.Sprintf("buttons must be a bitmask within 0x%X", maxMouseButtons),
+			Field:   "buttons",
+		}
+	}
+	if msg.Scroll < -maxMouseScroll || msg.Scroll > maxMouseScroll {
+		return &ValidationError{
+			Code:    ErrOutOfRange,
+			Message: fmt.Sprintf("scroll must be in [-%d, %d]", maxMouseScroll, maxMouseScroll),
+			Field:   "scroll",
+		}
+	}
+
+	if msg.X == nil && msg.Y == nil {
+		if abs(msg.DX) > maxMouseDelta || abs(msg.DY) > maxMouseDelta {
+			return &ValidationError{
+				Code:    ErrOutOfRange,
+				Message: fmt.Sprintf("dx and dy must be in [-%d, %d]", maxMouseDelta, maxMouseDelta),
+				Field:   "dx",
+			}
+		}
+		return nil
+	}
+
+	// Absolute position: both coordinates, normalised to the video frame
+	if msg.X == nil || msg.Y == nil {
+		return &ValidationError{
+			Code:    ErrMissingField,
+			Message: "absolute position requires both x and y",
+			Field:   "x",
+		}
+	}
+	if !inUnitRange(*msg.X) || !inUnitRange(*msg.Y) {
+		return &ValidationError{
+			Code:    ErrOutOfRange,
+			Message: "x and y must be in [0, 1]",
+			Field:   "x",
+		}
+	}
+	if msg.DX != 0 || msg.DY != 0 {
+		return &ValidationError{
+			Code:    ErrInvalidValue,
+			Message: "dx and dy cannot be combined with an absolute position",
+			Field:   "dx",
+		}
+	}
+	return nil
+}
+
+// ValidateKVMMouseMode validates a mouse mode selection message.
+func (v *Validator) ValidateKVMMouseMode(msg *protocol.KVMMouseModeMessage) error {
+	if err := v.checkStale(msg.T); err != nil {
+		return err
+	}
+
+	switch msg.Mode {
+	case protocol.MouseModeRelative, protocol.MouseModeAbsolute:
+		return nil
+	case "":
+		return &ValidationError{
+			Code:    ErrMissingField,
+			Message: "mode is required",
+			Field:   "mode",
+		}
+	default:
+		return &ValidationError{
+			Code:    ErrInvalidValue,
+			Message: fmt.Sprintf("unknown mouse mode %q", msg.Mode),
+			Field:   "mode",
+		}
+	}
+}
+
+// ValidateMode validates a mode switch message.
+func (v *Validator) ValidateMode(msg *protocol.ModeMessage) error {
+	if err := v.checkStale(msg.T); err != nil {
+		return err
+	}
+
+	if msg.Mode == "" {
+		return &ValidationError{
+			Code:    ErrMissingField,
+			Message: "mode is required",
+			Field:   "mode",
+		}
+	}
+
+	return nil
+}
+
+// ValidateKVMType validates a text typing message.
+func (v *Validator) ValidateKVMType(msg *protocol.KVMTypeMessage, maxLength int) error {
+	if err := v.checkStale(msg.T); err != nil {
+		return err
+	}
+
+	if msg.Text == "" {
+		return &ValidationError{
+			Code:    ErrMissingField,
+			Message: "text is required",
+			Field:   "text",
+		}
+	}
+
+	if !utf8.ValidString(msg.Text) {
+		return &ValidationError{
+			Code:    ErrInvalidValue,
+			Message: "text must be valid UTF-8",
+			Field:   "text",
+		}
+	}
+
+	if n := utf8.RuneCountInString(msg.Text); maxLength > 0 && n > maxLength {
+		return &ValidationError{
+			Code:    ErrOutOfRange,
+			Message: fmt.Sprintf("text length %d exceeds limit %d", n, maxLength),
+			Field:   "text",
+		}
+	}
+
 	return nil
 }
 
@@ -123,13 +271,49 @@ func (v *Validator) checkStale(t int64) error {
 	}
 
 	age := time.Since(time.UnixMilli(t))
-	if age > v.staleThreshold {
+
+	// Without a clock offset estimate, fall back to raw wall-clock comparison.
+	if v.clockOffset == nil {
+		return v.checkAge(age, 0)
+	}
+	offset, uncertainty, ok := v.clockOffset.ClockOffset()
+	if !ok {
+		return v.checkAge(age, 0)
+	}
+
+	// Console time = robot time + offset, so shift the command back into robot time.
+	corrected := age + offset
+	if corrected+uncertainty < -v.staleThreshold {
+		return &ValidationError{
+			Code:    ErrInvalidTimestamp,
+			Message: fmt.Sprintf("command timestamp is in the future (age: %v)", corrected),
+			Field:   "t",
+		}
+	}
+	return v.checkAge(corrected, uncertainty)
+}
+
+// checkAge rejects commands older than the stale threshold, allowing for
+// the given clock uncertainty.
+func (v *Validator) checkAge(age, uncertainty time.Duration) error {
+	if age-uncertainty > v.staleThreshold {
 		return &ValidationError{
 			Code:    ErrStaleCommand,
 			Message: fmt.Sprintf("command is stale (age: %v)", age),
 			Field:   "t",
 		}
 	}
-
 	return nil
 }
+
+// inUnitRange reports whether f is a number in [0, 1].
+func inUnitRange(f float64) bool {
+	return !math.IsNaN(f) && f >= 0 && f <= 1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -1,6 +1,7 @@
 package control
 
 import (
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -229,10 +230,63 @@ func TestValidator_ValidateKVMMouse(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:    "excessive movement clamped (no error)",
+			name:    "large movement clamped (no error)",
 			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, DX: 10000, DY: 10000, Buttons: 0, T: now},
 			wantErr: false, // Will be clamped, not rejected
 		},
+		{
+			name:    "movement beyond HID range",
+			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, DX: 40000, DY: 0, T: now},
+			wantErr: true,
+			errCode: ErrOutOfRange,
+		},
+		{
+			name:    "unknown button bits",
+			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, Buttons: 0x20, T: now},
+			wantErr: true,
+			errCode: ErrOutOfRange,
+		},
+		{
+			name:    "negative buttons",
+			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, Buttons: -1, T: now},
+			wantErr: true,
+			errCode: ErrOutOfRange,
+		},
+		{
+			name:    "scroll beyond wheel range",
+			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, Scroll: -200, T: now},
+			wantErr: true,
+			errCode: ErrOutOfRange,
+		},
+		{
+			name:    "valid absolute position",
+			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, X: ptr(0.5), Y: ptr(1.0), Buttons: 1, T: now},
+			wantErr: false,
+		},
+		{
+			name:    "absolute position missing y",
+			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, X: ptr(0.5), T: now},
+			wantErr: true,
+			errCode: ErrMissingField,
+		},
+		{
+			name:    "absolute position outside frame",
+			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, X: ptr(1.2), Y: ptr(0.5), T: now},
+			wantErr: true,
+			errCode: ErrOutOfRange,
+		},
+		{
+			name:    "absolute position NaN",
+			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, X: ptr(math.NaN()), Y: ptr(0.5), T: now},
+			wantErr: true,
+			errCode: ErrOutOfRange,
+		},
+		{
+			name:    "absolute position with relative delta",
+			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, DX: 3, X: ptr(0.1), Y: ptr(0.1), T: now},
+			wantErr: true,
+			errCode: ErrInvalidValue,
+		},
 		{
 			name:    "stale command",
 			msg:     &protocol.KVMMouseMessage{Type: protocol.TypeKVMMouse, DX: 10, DY: 5, Buttons: 0, T: now - 1000},
@@ -302,3 +356,83 @@ func TestValidator_ValidateEStop(t *testing.T) {
 		})
 	}
 }
+
+// fixedClockOffset is a ClockOffsetSource with a constant estimate.
+type fixedClockOffset struct {
+	offset, uncertainty time.Duration
+	ok                  bool
+}
+
+func (f fixedClockOffset) ClockOffset() (time.Duration, time.Duration, bool) {
+	return f.offset, f.uncertainty, f.ok
+}
+
+func TestValidator_ClockOffsetCorrection(t *testing.T) {
+	const threshold = 200 * time.Millisecond
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		source  fixedClockOffset
+		t       time.Time
+		errCode string
+	}{
+		{
+			name:   "console ahead by 2s, fresh command",
+			source: fixedClockOffset{offset: 2 * time.Second, uncertainty: 5 * time.Millisecond, ok: true},
+			t:      now.Add(2 * time.Second),
+		},
+		{
+			name:   "console behind by 2s, fresh command",
+			source: fixedClockOffset{offset: -2 * time.Second, uncertainty: 5 * time.Millisecond, ok: true},
+			t:      now.Add(-2 * time.Second),
+		},
+		{
+			name:    "console ahead by 2s, old command",
+			source:  fixedClockOffset{offset: 2 * time.Second, uncertainty: 5 * time.Millisecond, ok: true},
+			t:       now.Add(1 * time.Second),
+			errCode: ErrStaleCommand,
+		},
+		{
+			name:    "future-dated command",
+			source:  fixedClockOffset{offset: 0, uncertainty: 5 * time.Millisecond, ok: true},
+			t:       now.Add(5 * time.Second),
+			errCode: ErrInvalidTimestamp,
+		},
+		{
+			name:   "uncertainty tolerated",
+			source: fixedClockOffset{offset: 0, uncertainty: 50 * time.Millisecond, ok: true},
+			t:      now.Add(-threshold - 30*time.Millisecond),
+		},
+		{
+			name:    "no estimate falls back to raw comparison",
+			source:  fixedClockOffset{offset: 2 * time.Second, ok: false},
+			t:       now.Add(-1 * time.Second),
+			errCode: ErrStaleCommand,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(threshold)
+			v.SetClockOffsetSource(tt.source)
+
+			err := v.ValidateDrive(&protocol.DriveMessage{
+				Type: protocol.TypeDrive, V: 0.1, T: tt.t.UnixMilli(),
+			})
+			if tt.errCode == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			ve, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			if ve.Code != tt.errCode {
+				t.Errorf("expected code %s, got %s", tt.errCode, ve.Code)
+			}
+		})
+	}
+}
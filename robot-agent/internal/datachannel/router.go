@@ -108,7 +108,7 @@ func (r *Router) sendError(code, reason string, refType protocol.MessageType, re
 func isKnownType(t protocol.MessageType) bool {
 	switch t {
 	case protocol.TypeAuth, protocol.TypeAuthOK, protocol.TypeAuthErr,
-		protocol.TypeDrive, protocol.TypeKVMKey, protocol.TypeKVMMouse, protocol.TypeEStop,
+		protocol.TypeDrive, protocol.TypeKVMKey, protocol.TypeKVMMouse, protocol.TypeKVMMouseMode, protocol.TypeKVMType, protocol.TypeEStop, protocol.TypeMode,
 		protocol.TypePing, protocol.TypePong,
 		protocol.TypeAck, protocol.TypeError, protocol.TypeState:
 		return true
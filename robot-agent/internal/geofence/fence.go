@@ -0,0 +1,254 @@
+// Package geofence enforces allowed-area and keep-out zones for drive commands.
+package geofence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+)
+
+// Error definitions.
+var (
+	ErrOutsideFence    = errors.New("drive command would leave geofence")
+	ErrPoseUnavailable = errors.New("robot pose unavailable")
+	ErrInvalidConfig   = errors.New("invalid geofence config")
+)
+
+// Mode selects how unsafe drive commands are handled.
+type Mode string
+
+const (
+	// ModeClamp scales linear velocity down to the largest safe fraction.
+	ModeClamp Mode = "clamp"
+	// ModeReject rejects unsafe drive commands outright.
+	ModeReject Mode = "reject"
+)
+
+const (
+	// predictionSteps is the number of points checked along the predicted path.
+	predictionSteps = 10
+	// clampIterations is the bisection depth for the safe velocity fraction.
+	clampIterations = 8
+)
+
+// PoseSource provides the current robot pose in the fence frame.
+// RobotAPI backends that know their pose may implement this directly.
+type PoseSource interface {
+	Pose() (Pose, error)
+}
+
+// Config describes the fence geometry and prediction parameters.
+type Config struct {
+	Allowed       [][2]float64   `json:"allowed"`         // Allowed area polygon
+	KeepOut       [][][2]float64 `json:"keep_out"`        // Keep-out zone polygons
+	Mode          Mode           `json:"mode"`            // "clamp" (default) or "reject"
+	MarginM       float64        `json:"margin_m"`        // Required clearance from any boundary
+	LookaheadS    float64        `json:"lookahead_s"`     // Prediction horizon for drive commands
+	MaxLinearMps  float64        `json:"max_linear_mps"`  // Speed at v = 1
+	MaxAngularRps float64        `json:"max_angular_rps"` // Turn rate at w = 1
+}
+
+// LoadConfig reads a JSON fence config from path.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read geofence config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return cfg, cfg.validate()
+}
+
+// validate checks the config and fills in defaults.
+func (c *Config) validate() error {
+	if len(c.Allowed) < 3 {
+		return fmt.Errorf("%w: allowed polygon needs at least 3 vertices", ErrInvalidConfig)
+	}
+	for i, zone := range c.KeepOut {
+		if len(zone) < 3 {
+			return fmt.Errorf("%w: keep_out[%d] needs at least 3 vertices", ErrInvalidConfig, i)
+		}
+	}
+
+	switch c.Mode {
+	case "":
+		c.Mode = ModeClamp
+	case ModeClamp, ModeReject:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidConfig, c.Mode)
+	}
+
+	if c.LookaheadS <= 0 {
+		c.LookaheadS = 1.0
+	}
+	if c.MaxLinearMps <= 0 {
+		c.MaxLinearMps = 1.0
+	}
+	if c.MaxAngularRps <= 0 {
+		c.MaxAngularRps = 1.0
+	}
+	return nil
+}
+
+// Violation describes a drive command that was clamped or rejected.
+type Violation struct {
+	Action    string  // "clamped" or "rejected"
+	Pose      Pose    // Robot pose at the time of the command
+	Clearance float64 // Current clearance (meters, negative = outside)
+	V, W      float64 // Requested command
+}
+
+// ViolationCallback is called when the fence starts modifying commands.
+type ViolationCallback func(v Violation)
+
+// Fence enforces an allowed area and keep-out zones.
+type Fence struct {
+	cfg     Config
+	allowed Polygon
+	keepOut []Polygon
+	source  PoseSource
+
+	mu          sync.Mutex
+	violating   bool
+	onViolation ViolationCallback
+}
+
+// New creates a fence from config and a pose source.
+func New(cfg Config, source PoseSource) (*Fence, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	f := &Fence{
+		cfg:     cfg,
+		allowed: toPolygon(cfg.Allowed),
+		source:  source,
+	}
+	for _, zone := range cfg.KeepOut {
+		f.keepOut = append(f.keepOut, toPolygon(zone))
+	}
+	return f, nil
+}
+
+// SetViolationCallback sets the callback for the start of a violation.
+func (f *Fence) SetViolationCallback(fn ViolationCallback) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onViolation = fn
+}
+
+// Clearance returns the signed distance from p to the nearest fence
+// boundary: positive inside the allowed area and outside all keep-out zones.
+func (f *Fence) Clearance(p Point) float64 {
+	c := f.allowed.SignedDistance(p)
+	for _, zone := range f.keepOut {
+		c = math.Min(c, -zone.SignedDistance(p))
+	}
+	return c
+}
+
+// Check returns the robot's current clearance and whether it is inside the fence.
+func (f *Fence) Check() (clearance float64, inside bool, err error) {
+	pose, err := f.source.Pose()
+	if err != nil {
+		return 0, false, errors.Join(ErrPoseUnavailable, err)
+	}
+	clearance = f.Clearance(pose.Point())
+	return clearance, clearance >= 0, nil
+}
+
+// FilterDrive checks a normalized drive command against the fence and
+// returns the permitted command. Commands that move the robot back toward
+// the allowed area are always permitted.
+func (f *Fence) FilterDrive(v, w float64) (float64, float64, error) {
+	pose, err := f.source.Pose()
+	if err != nil {
+		return 0, 0, errors.Join(ErrPoseUnavailable, err)
+	}
+
+	current := f.Clearance(pose.Point())
+	if f.pathSafe(pose, v, w, current) {
+		f.clearViolation()
+		return v, w, nil
+	}
+
+	if f.cfg.Mode == ModeClamp {
+		if scale := f.safeFraction(pose, v, w, current); scale > 0 || f.pathSafe(pose, 0, w, current) {
+			f.reportViolation(Violation{Action: "clamped", Pose: pose, Clearance: current, V: v, W: w})
+			return v * scale, w, nil
+		}
+	}
+
+	f.reportViolation(Violation{Action: "rejected", Pose: pose, Clearance: current, V: v, W: w})
+	return 0, 0, ErrOutsideFence
+}
+
+// pathSafe reports whether the predicted path keeps the required margin,
+// or, if already inside the margin, never reduces clearance.
+func (f *Fence) pathSafe(pose Pose, v, w, current float64) bool {
+	vMps := v * f.cfg.MaxLinearMps
+	wRps := w * f.cfg.MaxAngularRps
+	dt := f.cfg.LookaheadS / predictionSteps
+
+	minClearance := math.Inf(1)
+	p := pose
+	for range predictionSteps {
+		p = integrate(p, vMps, wRps, dt)
+		minClearance = math.Min(minClearance, f.Clearance(p.Point()))
+	}
+
+	if minClearance >= f.cfg.MarginM {
+		return true
+	}
+	// Recovery: inside the margin, allow moves that never reduce clearance
+	const epsilon = 1e-9
+	return current < f.cfg.MarginM && minClearance >= current-epsilon
+}
+
+// safeFraction finds the largest fraction of v that keeps the path safe.
+func (f *Fence) safeFraction(pose Pose, v, w, current float64) float64 {
+	lo, hi := 0.0, 1.0
+	for range clampIterations {
+		mid := (lo + hi) / 2
+		if f.pathSafe(pose, v*mid, w, current) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// reportViolation fires the callback on the first violation of a run.
+func (f *Fence) reportViolation(v Violation) {
+	f.mu.Lock()
+	first := !f.violating
+	f.violating = true
+	cb := f.onViolation
+	f.mu.Unlock()
+
+	if first && cb != nil {
+		cb(v)
+	}
+}
+
+// clearViolation ends the current violation run.
+func (f *Fence) clearViolation() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.violating = false
+}
+
+func toPolygon(pts [][2]float64) Polygon {
+	poly := make(Polygon, len(pts))
+	for i, p := range pts {
+		poly[i] = Point{X: p[0], Y: p[1]}
+	}
+	return poly
+}
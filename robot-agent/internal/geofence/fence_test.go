@@ -0,0 +1,185 @@
+package geofence
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// staticPose is a fixed PoseSource for testing.
+type staticPose struct {
+	pose Pose
+	err  error
+}
+
+func (s *staticPose) Pose() (Pose, error) {
+	return s.pose, s.err
+}
+
+func testConfig(mode Mode) Config {
+	return Config{
+		Allowed:       [][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+		KeepOut:       [][][2]float64{{{4, 4}, {6, 4}, {6, 6}, {4, 6}}},
+		Mode:          mode,
+		MarginM:       0.5,
+		LookaheadS:    1.0,
+		MaxLinearMps:  1.0,
+		MaxAngularRps: 1.0,
+	}
+}
+
+func newTestFence(t *testing.T, mode Mode, pose Pose) (*Fence, *staticPose) {
+	t.Helper()
+	src := &staticPose{pose: pose}
+	f, err := New(testConfig(mode), src)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return f, src
+}
+
+func TestFence_AllowsSafeCommand(t *testing.T) {
+	f, _ := newTestFence(t, ModeClamp, Pose{X: 2, Y: 2})
+
+	v, w, err := f.FilterDrive(0.5, 0.2)
+	if err != nil || v != 0.5 || w != 0.2 {
+		t.Errorf("expected command unchanged, got v=%f w=%f err=%v", v, w, err)
+	}
+}
+
+func TestFence_ClampsTowardBoundary(t *testing.T) {
+	// Facing +X, 1m from the east wall
+	f, _ := newTestFence(t, ModeClamp, Pose{X: 9, Y: 2})
+
+	v, _, err := f.FilterDrive(1.0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v <= 0 || v >= 1.0 {
+		t.Errorf("expected clamped 0 < v < 1, got %f", v)
+	}
+}
+
+func TestFence_RejectMode(t *testing.T) {
+	f, _ := newTestFence(t, ModeReject, Pose{X: 9, Y: 2})
+
+	v, w, err := f.FilterDrive(1.0, 0)
+	if !errors.Is(err, ErrOutsideFence) {
+		t.Errorf("expected ErrOutsideFence, got %v", err)
+	}
+	if v != 0 || w != 0 {
+		t.Errorf("expected zero command on reject, got v=%f w=%f", v, w)
+	}
+}
+
+func TestFence_KeepOutZone(t *testing.T) {
+	// Facing +X toward keep-out zone starting at x=4
+	f, _ := newTestFence(t, ModeReject, Pose{X: 3, Y: 5})
+
+	if _, _, err := f.FilterDrive(1.0, 0); !errors.Is(err, ErrOutsideFence) {
+		t.Errorf("expected keep-out rejection, got %v", err)
+	}
+}
+
+func TestFence_AllowsRecoveryFromOutside(t *testing.T) {
+	// Outside the east wall, facing +X
+	f, _ := newTestFence(t, ModeReject, Pose{X: 11, Y: 2})
+
+	if _, _, err := f.FilterDrive(1.0, 0); err == nil {
+		t.Error("driving further out should be rejected")
+	}
+	if v, _, err := f.FilterDrive(-0.5, 0); err != nil || v != -0.5 {
+		t.Errorf("reversing back inside should be allowed, got v=%f err=%v", v, err)
+	}
+	if _, _, err := f.FilterDrive(0, 1.0); err != nil {
+		t.Errorf("rotating in place should be allowed, got %v", err)
+	}
+}
+
+func TestFence_PoseUnavailable(t *testing.T) {
+	f, src := newTestFence(t, ModeClamp, Pose{X: 2, Y: 2})
+	src.err = errors.New("no odometry")
+
+	if _, _, err := f.FilterDrive(0.1, 0); !errors.Is(err, ErrPoseUnavailable) {
+		t.Errorf("expected ErrPoseUnavailable, got %v", err)
+	}
+}
+
+func TestFence_Check(t *testing.T) {
+	f, src := newTestFence(t, ModeClamp, Pose{X: 2, Y: 2})
+
+	if _, inside, _ := f.Check(); !inside {
+		t.Error("expected inside")
+	}
+
+	src.pose = Pose{X: 5, Y: 5}
+	if _, inside, _ := f.Check(); inside {
+		t.Error("expected outside when in keep-out zone")
+	}
+}
+
+func TestFence_ViolationCallbackOnEdge(t *testing.T) {
+	f, src := newTestFence(t, ModeReject, Pose{X: 9, Y: 2})
+
+	var violations []Violation
+	f.SetViolationCallback(func(v Violation) { violations = append(violations, v) })
+
+	f.FilterDrive(1.0, 0)
+	f.FilterDrive(1.0, 0)
+	if len(violations) != 1 || violations[0].Action != "rejected" {
+		t.Fatalf("expected one rejected violation, got %+v", violations)
+	}
+
+	src.pose = Pose{X: 2, Y: 2}
+	f.FilterDrive(0.1, 0)
+	src.pose = Pose{X: 9, Y: 2}
+	f.FilterDrive(1.0, 0)
+	if len(violations) != 2 {
+		t.Errorf("expected new violation after safe command, got %d", len(violations))
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fence.json")
+	data := `{"allowed": [[0,0],[5,0],[5,5],[0,5]], "keep_out": [], "margin_m": 0.3}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Mode != ModeClamp || cfg.LookaheadS != 1.0 || cfg.MarginM != 0.3 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fence.json")
+	os.WriteFile(path, []byte(`{"allowed": [[0,0],[1,1]]}`), 0600)
+
+	if _, err := LoadConfig(path); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestOdometry_DeadReckoning(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	o := newOdometryWithClock(Pose{X: 1, Y: 1}, 2.0, 1.0, clock)
+
+	o.Record(0.5, 0) // 1 m/s along +X
+	now = now.Add(2 * time.Second)
+	o.Stop()
+	now = now.Add(5 * time.Second)
+
+	pose, _ := o.Pose()
+	if pose.X < 2.999 || pose.X > 3.001 || pose.Y != 1 {
+		t.Errorf("expected pose (3,1), got (%f,%f)", pose.X, pose.Y)
+	}
+}
@@ -0,0 +1,87 @@
+// Package geofence enforces allowed-area and keep-out zones for drive commands.
+package geofence
+
+import "math"
+
+// Point is a 2D position in the fence frame (meters).
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Pose is a robot position and heading in the fence frame.
+type Pose struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Theta float64 `json:"theta"` // Heading in radians, counter-clockwise from +X
+}
+
+// Point returns the position component of the pose.
+func (p Pose) Point() Point {
+	return Point{X: p.X, Y: p.Y}
+}
+
+// Polygon is a closed polygon given by its vertices in order.
+type Polygon []Point
+
+// Contains reports whether p lies inside the polygon (ray casting).
+func (poly Polygon) Contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) &&
+			p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// SignedDistance returns the distance from p to the polygon boundary,
+// positive inside and negative outside.
+func (poly Polygon) SignedDistance(p Point) float64 {
+	if len(poly) < 3 {
+		return math.Inf(-1)
+	}
+
+	d := math.Inf(1)
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		d = math.Min(d, segmentDistance(p, poly[j], poly[i]))
+	}
+	if poly.Contains(p) {
+		return d
+	}
+	return -d
+}
+
+// segmentDistance returns the distance from p to segment ab.
+func segmentDistance(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lenSq := dx*dx + dy*dy
+	if lenSq == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / lenSq
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.X-(a.X+t*dx), p.Y-(a.Y+t*dy))
+}
+
+// integrate advances a pose along a unicycle arc for dt seconds.
+func integrate(p Pose, v, w, dt float64) Pose {
+	if math.Abs(w) < 1e-9 {
+		return Pose{
+			X:     p.X + v*math.Cos(p.Theta)*dt,
+			Y:     p.Y + v*math.Sin(p.Theta)*dt,
+			Theta: p.Theta,
+		}
+	}
+
+	theta := p.Theta + w*dt
+	r := v / w
+	return Pose{
+		X:     p.X + r*(math.Sin(theta)-math.Sin(p.Theta)),
+		Y:     p.Y - r*(math.Cos(theta)-math.Cos(p.Theta)),
+		Theta: theta,
+	}
+}
@@ -0,0 +1,59 @@
+package geofence
+
+import (
+	"math"
+	"testing"
+)
+
+func square(min, max float64) Polygon {
+	return Polygon{{min, min}, {max, min}, {max, max}, {min, max}}
+}
+
+func TestPolygon_Contains(t *testing.T) {
+	poly := square(0, 10)
+
+	tests := []struct {
+		p    Point
+		want bool
+	}{
+		{Point{5, 5}, true},
+		{Point{0.1, 9.9}, true},
+		{Point{-1, 5}, false},
+		{Point{5, 11}, false},
+	}
+	for _, tt := range tests {
+		if got := poly.Contains(tt.p); got != tt.want {
+			t.Errorf("Contains(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestPolygon_SignedDistance(t *testing.T) {
+	poly := square(0, 10)
+
+	if d := poly.SignedDistance(Point{2, 5}); math.Abs(d-2) > 1e-9 {
+		t.Errorf("expected +2 inside, got %f", d)
+	}
+	if d := poly.SignedDistance(Point{-3, 5}); math.Abs(d+3) > 1e-9 {
+		t.Errorf("expected -3 outside, got %f", d)
+	}
+	if d := (Polygon{{0, 0}, {1, 1}}).SignedDistance(Point{0, 0}); !math.IsInf(d, -1) {
+		t.Errorf("degenerate polygon should be -Inf, got %f", d)
+	}
+}
+
+func TestIntegrate(t *testing.T) {
+	straight := integrate(Pose{}, 1, 0, 2)
+	if math.Abs(straight.X-2) > 1e-9 || math.Abs(straight.Y) > 1e-9 {
+		t.Errorf("expected (2,0), got (%f,%f)", straight.X, straight.Y)
+	}
+
+	// Quarter circle of radius 1
+	arc := integrate(Pose{}, 1, 1, math.Pi/2)
+	if math.Abs(arc.X-1) > 1e-9 || math.Abs(arc.Y-1) > 1e-9 {
+		t.Errorf("expected (1,1), got (%f,%f)", arc.X, arc.Y)
+	}
+	if math.Abs(arc.Theta-math.Pi/2) > 1e-9 {
+		t.Errorf("expected heading pi/2, got %f", arc.Theta)
+	}
+}
@@ -0,0 +1,70 @@
+// Package geofence enforces allowed-area and keep-out zones for drive commands.
+package geofence
+
+import (
+	"sync"
+	"time"
+)
+
+// Odometry dead-reckons robot pose from commanded velocities. It is a
+// fallback pose source for backends that cannot report their own pose.
+type Odometry struct {
+	mu            sync.Mutex
+	now           func() time.Time
+	maxLinearMps  float64
+	maxAngularRps float64
+
+	pose     Pose
+	v, w     float64 // Current commanded velocity (m/s, rad/s)
+	lastTime time.Time
+}
+
+// NewOdometry creates an odometry tracker starting at the given pose.
+// Normalized drive commands are scaled by the given maximum speeds.
+func NewOdometry(start Pose, maxLinearMps, maxAngularRps float64) *Odometry {
+	return newOdometryWithClock(start, maxLinearMps, maxAngularRps, time.Now)
+}
+
+func newOdometryWithClock(start Pose, maxLinearMps, maxAngularRps float64, now func() time.Time) *Odometry {
+	return &Odometry{
+		now:           now,
+		maxLinearMps:  maxLinearMps,
+		maxAngularRps: maxAngularRps,
+		pose:          start,
+		lastTime:      now(),
+	}
+}
+
+// Record integrates motion up to now and applies a new normalized command.
+func (o *Odometry) Record(v, w float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.advanceLocked()
+	o.v = v * o.maxLinearMps
+	o.w = w * o.maxAngularRps
+}
+
+// Stop records that the robot has halted.
+func (o *Odometry) Stop() {
+	o.Record(0, 0)
+}
+
+// Pose returns the current dead-reckoned pose.
+func (o *Odometry) Pose() (Pose, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.advanceLocked()
+	return o.pose, nil
+}
+
+// advanceLocked integrates the current velocity (caller must hold lock).
+func (o *Odometry) advanceLocked() {
+	now := o.now()
+	dt := now.Sub(o.lastTime).Seconds()
+	o.lastTime = now
+	if dt > 0 {
+		o.pose = integrate(o.pose, o.v, o.w, dt)
+	}
+}
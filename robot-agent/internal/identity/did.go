@@ -0,0 +1,80 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidDID indicates a DID that is not an Ed25519 did:key.
+var ErrInvalidDID = errors.New("invalid Ed25519 did:key")
+
+const (
+	didKeyPrefix = "did:key:z" // z = base58btc multibase
+	base58Chars  = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+// ed25519Multicodec is the varint-encoded multicodec for ed25519-pub.
+var ed25519Multicodec = []byte{0xed, 0x01}
+
+// DIDFromPublicKey encodes an Ed25519 public key as a did:key.
+func DIDFromPublicKey(pub ed25519.PublicKey) string {
+	return didKeyPrefix + base58Encode(append(append([]byte{}, ed25519Multicodec...), pub...))
+}
+
+// PublicKeyFromDID decodes the Ed25519 public key embedded in a did:key.
+func PublicKeyFromDID(did string) (ed25519.PublicKey, error) {
+	if !strings.HasPrefix(did, didKeyPrefix) {
+		return nil, ErrInvalidDID
+	}
+	data, ok := base58Decode(strings.TrimPrefix(did, didKeyPrefix))
+	if !ok || len(data) != len(ed25519Multicodec)+ed25519.PublicKeySize ||
+		data[0] != ed25519Multicodec[0] || data[1] != ed25519Multicodec[1] {
+		return nil, ErrInvalidDID
+	}
+	return ed25519.PublicKey(data[len(ed25519Multicodec):]), nil
+}
+
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Chars[mod.Int64()])
+	}
+	// Leading zero bytes are encoded as leading '1's
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Chars[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func base58Decode(s string) ([]byte, bool) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		idx := strings.IndexRune(base58Chars, c)
+		if idx < 0 {
+			return nil, false
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Chars[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), true
+}
@@ -0,0 +1,137 @@
+// Package identity manages the Robot Agent's Ed25519 did:key identity.
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Error definitions.
+var (
+	ErrInvalidKeyFile = errors.New("invalid identity key file")
+)
+
+const pemBlockType = "PRIVATE KEY"
+
+// Identity is the robot's signing key and the DID derived from it.
+type Identity struct {
+	priv ed25519.PrivateKey
+	did  string
+}
+
+// New creates an identity from an Ed25519 private key.
+func New(priv ed25519.PrivateKey) *Identity {
+	return &Identity{
+		priv: priv,
+		did:  DIDFromPublicKey(priv.Public().(ed25519.PublicKey)),
+	}
+}
+
+// LoadOrCreate loads the identity key from path, generating and saving a
+// new key on first boot. created reports whether a key was generated, in
+// which case the new DID must be recorded for gateway enrollment.
+func LoadOrCreate(path string) (id *Identity, created bool, err error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		priv, err := parsePrivateKey(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", path, err)
+		}
+		return New(priv), false, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, false, err
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := savePrivateKey(path, priv); err != nil {
+		return nil, false, err
+	}
+	return New(priv), true, nil
+}
+
+func parsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemBlockType {
+		return nil, ErrInvalidKeyFile
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an Ed25519 key", ErrInvalidKeyFile)
+	}
+	return priv, nil
+}
+
+func savePrivateKey(path string, priv ed25519.PrivateKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// O_EXCL: never overwrite a key another process just created
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(f, &pem.Block{Type: pemBlockType, Bytes: der}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// DID returns the robot's did:key identifier.
+func (id *Identity) DID() string {
+	return id.did
+}
+
+// PublicKey returns the robot's public key.
+func (id *Identity) PublicKey() ed25519.PublicKey {
+	return id.priv.Public().(ed25519.PublicKey)
+}
+
+// Sign signs msg with the robot's private key.
+func (id *Identity) Sign(msg []byte) []byte {
+	return ed25519.Sign(id.priv, msg)
+}
+
+// JoinToken returns a short-lived EdDSA JWT proving the robot's identity to
+// the gateway at audience. The issuer and kid are the robot's DID, so it
+// can be verified with the key embedded in the DID.
+func (id *Identity) JoinToken(robotID, audience string, ttl time.Duration) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.RegisteredClaims{
+		Issuer:    id.did,
+		Subject:   robotID,
+		Audience:  jwt.ClaimStrings{audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		ID:        fmt.Sprintf("%x", jti),
+	})
+	token.Header["kid"] = id.did
+	return token.SignedString(id.priv)
+}
@@ -0,0 +1,111 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestLoadOrCreate_GeneratesThenLoads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "identity.pem")
+
+	first, created, err := LoadOrCreate(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !created {
+		t.Error("expected a key to be generated on first boot")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("key file mode = %o, want 600", perm)
+	}
+
+	second, created, err := LoadOrCreate(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if created {
+		t.Error("expected existing key to be loaded")
+	}
+	if first.DID() != second.DID() {
+		t.Errorf("DID changed across restarts: %s != %s", first.DID(), second.DID())
+	}
+}
+
+func TestLoadOrCreate_RejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity.pem")
+	if err := os.WriteFile(path, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := LoadOrCreate(path); !errors.Is(err, ErrInvalidKeyFile) {
+		t.Errorf("expected ErrInvalidKeyFile, got %v", err)
+	}
+}
+
+func TestDID_RoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := New(priv)
+
+	// Every Ed25519 did:key starts with z6Mk
+	if !strings.HasPrefix(id.DID(), "did:key:z6Mk") {
+		t.Errorf("unexpected DID %s", id.DID())
+	}
+
+	got, err := PublicKeyFromDID(id.DID())
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !got.Equal(pub) {
+		t.Error("decoded key does not match")
+	}
+}
+
+func TestPublicKeyFromDID_Invalid(t *testing.T) {
+	for _, did := range []string{"", "did:web:example.com", "did:key:z0OIl", "did:key:z6Mk"} {
+		if _, err := PublicKeyFromDID(did); !errors.Is(err, ErrInvalidDID) {
+			t.Errorf("%q: expected ErrInvalidDID, got %v", did, err)
+		}
+	}
+}
+
+func TestJoinToken_VerifiesWithDIDKey(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := New(priv)
+
+	signed, err := id.JoinToken("robot-1", "wss://gateway/v1/signal", time.Minute)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	// Verify the way the gateway would: resolve the key from the kid DID
+	claims := &jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return PublicKeyFromDID(kid)
+	}, jwt.WithValidMethods([]string{"EdDSA"}), jwt.WithAudience("wss://gateway/v1/signal"))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if claims.Issuer != id.DID() || claims.Subject != "robot-1" || claims.ID == "" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultClockOffsetWindow = 32
+
+	// remoteTimestampResolution is the granularity of console timestamps (ms).
+	remoteTimestampResolution = time.Millisecond
+
+	// driftFilterFactor keeps samples whose RTT is within this multiple of the
+	// minimum RTT when fitting drift, discarding queue-delayed exchanges.
+	driftFilterFactor = 2
+
+	// minDriftSpan is the minimum time span of samples needed to estimate drift.
+	minDriftSpan = 10 * time.Second
+)
+
+// clockOffsetSample is a single offset measurement from a ping/pong exchange.
+type clockOffsetSample struct {
+	at     time.Time     // Local midpoint of the exchange
+	offset time.Duration // Remote minus local clock
+	rtt    time.Duration
+}
+
+// ClockOffsetEstimate is the current console-minus-robot clock offset.
+type ClockOffsetEstimate struct {
+	Valid       bool          `json:"valid"`
+	Offset      time.Duration `json:"offset"`
+	Uncertainty time.Duration `json:"uncertainty"`
+	DriftPPM    float64       `json:"drift_ppm"`
+	MinRTT      time.Duration `json:"min_rtt"`
+	Samples     int           `json:"samples"`
+}
+
+// ClockOffsetEstimator estimates the offset between the console and robot
+// wall clocks using Cristian's algorithm with a minimum-RTT filter over a
+// sliding window of ping/pong exchanges.
+type ClockOffsetEstimator struct {
+	mu         sync.Mutex
+	samples    []clockOffsetSample
+	windowSize int
+}
+
+// NewClockOffsetEstimator creates an estimator over the last windowSize exchanges.
+func NewClockOffsetEstimator(windowSize int) *ClockOffsetEstimator {
+	if windowSize <= 0 {
+		windowSize = defaultClockOffsetWindow
+	}
+	return &ClockOffsetEstimator{
+		samples:    make([]clockOffsetSample, 0, windowSize),
+		windowSize: windowSize,
+	}
+}
+
+// AddSample records an exchange. sendTime and recvTime are local Unix
+// nanoseconds; remoteMs is the console's Unix millisecond receive time.
+func (e *ClockOffsetEstimator) AddSample(sendTime, recvTime, remoteMs int64) {
+	if remoteMs <= 0 || recvTime < sendTime {
+		return
+	}
+
+	rtt := time.Duration(recvTime - sendTime)
+	midpoint := sendTime + int64(rtt/2)
+	sample := clockOffsetSample{
+		at:     time.Unix(0, midpoint),
+		offset: time.Duration(remoteMs*int64(time.Millisecond) - midpoint),
+		rtt:    rtt,
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.samples) >= e.windowSize {
+		e.samples = e.samples[1:]
+	}
+	e.samples = append(e.samples, sample)
+}
+
+// Estimate returns the current offset estimate.
+func (e *ClockOffsetEstimator) Estimate() ClockOffsetEstimate {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.estimateLocked(time.Now())
+}
+
+// ClockOffset returns the offset and its uncertainty, and whether an
+// estimate is available.
+func (e *ClockOffsetEstimator) ClockOffset() (offset, uncertainty time.Duration, ok bool) {
+	est := e.Estimate()
+	return est.Offset, est.Uncertainty, est.Valid
+}
+
+// Reset discards all samples (e.g., for new session).
+func (e *ClockOffsetEstimator) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.samples = make([]clockOffsetSample, 0, e.windowSize)
+}
+
+// estimateLocked computes the estimate (caller must hold lock).
+func (e *ClockOffsetEstimator) estimateLocked(now time.Time) ClockOffsetEstimate {
+	if len(e.samples) == 0 {
+		return ClockOffsetEstimate{}
+	}
+
+	best := e.samples[0]
+	for _, s := range e.samples[1:] {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+
+	drift := e.driftLocked(best.rtt)
+	offset := best.offset + time.Duration(drift*float64(now.Sub(best.at)))
+
+	return ClockOffsetEstimate{
+		Valid:       true,
+		Offset:      offset,
+		Uncertainty: best.rtt/2 + remoteTimestampResolution,
+		DriftPPM:    drift * 1e6,
+		MinRTT:      best.rtt,
+		Samples:     len(e.samples),
+	}
+}
+
+// driftLocked fits a least-squares line through low-RTT samples and returns
+// the offset slope (seconds per second). Returns 0 if the span is too short.
+func (e *ClockOffsetEstimator) driftLocked(minRTT time.Duration) float64 {
+	var filtered []clockOffsetSample
+	for _, s := range e.samples {
+		if s.rtt <= minRTT*driftFilterFactor {
+			filtered = append(filtered, s)
+		}
+	}
+	if len(filtered) < 2 || filtered[len(filtered)-1].at.Sub(filtered[0].at) < minDriftSpan {
+		return 0
+	}
+
+	origin := filtered[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range filtered {
+		x := s.at.Sub(origin).Seconds()
+		y := s.offset.Seconds()
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	n := float64(len(filtered))
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
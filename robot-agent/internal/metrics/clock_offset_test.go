@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+// exchange builds a ping/pong sample where the remote clock is ahead by offset.
+func exchange(e *ClockOffsetEstimator, at time.Time, rtt, offset time.Duration) {
+	send := at.UnixNano()
+	recv := send + int64(rtt)
+	remote := time.Unix(0, send+int64(rtt/2)).Add(offset).UnixMilli()
+	e.AddSample(send, recv, remote)
+}
+
+func TestClockOffsetEstimator_NoSamples(t *testing.T) {
+	e := NewClockOffsetEstimator(8)
+
+	if _, _, ok := e.ClockOffset(); ok {
+		t.Error("expected no estimate without samples")
+	}
+}
+
+func TestClockOffsetEstimator_MinRTTFilter(t *testing.T) {
+	e := NewClockOffsetEstimator(8)
+	base := time.Now()
+
+	// Queue-delayed exchange with asymmetric delay skews the raw offset.
+	exchange(e, base, 200*time.Millisecond, 350*time.Millisecond)
+	exchange(e, base.Add(time.Second), 10*time.Millisecond, 300*time.Millisecond)
+	exchange(e, base.Add(2*time.Second), 80*time.Millisecond, 260*time.Millisecond)
+
+	est := e.Estimate()
+	if !est.Valid {
+		t.Fatal("expected valid estimate")
+	}
+	if est.MinRTT != 10*time.Millisecond {
+		t.Errorf("expected min RTT 10ms, got %v", est.MinRTT)
+	}
+	if diff := est.Offset - 300*time.Millisecond; diff < -2*time.Millisecond || diff > 2*time.Millisecond {
+		t.Errorf("expected offset ~300ms, got %v", est.Offset)
+	}
+	if est.Uncertainty != 6*time.Millisecond {
+		t.Errorf("expected uncertainty 6ms (rtt/2 + 1ms), got %v", est.Uncertainty)
+	}
+}
+
+func TestClockOffsetEstimator_NegativeOffset(t *testing.T) {
+	e := NewClockOffsetEstimator(8)
+	exchange(e, time.Now(), 4*time.Millisecond, -2*time.Second)
+
+	offset, _, ok := e.ClockOffset()
+	if !ok {
+		t.Fatal("expected valid estimate")
+	}
+	if diff := offset + 2*time.Second; diff < -2*time.Millisecond || diff > 2*time.Millisecond {
+		t.Errorf("expected offset ~-2s, got %v", offset)
+	}
+}
+
+func TestClockOffsetEstimator_Drift(t *testing.T) {
+	e := NewClockOffsetEstimator(32)
+	base := time.Now().Add(-30 * time.Second)
+
+	// Remote clock gains 1ms per second (1000 ppm).
+	for i := range 31 {
+		offset := time.Duration(i) * time.Millisecond
+		exchange(e, base.Add(time.Duration(i)*time.Second), 4*time.Millisecond, offset)
+	}
+
+	est := e.Estimate()
+	if est.DriftPPM < 900 || est.DriftPPM > 1100 {
+		t.Errorf("expected drift ~1000 ppm, got %.1f", est.DriftPPM)
+	}
+}
+
+func TestClockOffsetEstimator_WindowAndReset(t *testing.T) {
+	e := NewClockOffsetEstimator(2)
+	base := time.Now()
+
+	exchange(e, base, 1*time.Millisecond, 500*time.Millisecond)
+	exchange(e, base.Add(time.Second), 20*time.Millisecond, 0)
+	exchange(e, base.Add(2*time.Second), 20*time.Millisecond, 0)
+
+	if est := e.Estimate(); est.Samples != 2 || est.MinRTT != 20*time.Millisecond {
+		t.Errorf("expected oldest sample evicted, got %+v", est)
+	}
+
+	e.Reset()
+	if _, _, ok := e.ClockOffset(); ok {
+		t.Error("expected no estimate after reset")
+	}
+}
+
+func TestClockOffsetEstimator_IgnoresMissingRemoteTime(t *testing.T) {
+	e := NewClockOffsetEstimator(8)
+	now := time.Now().UnixNano()
+
+	e.AddSample(now, now+int64(time.Millisecond), 0)
+
+	if _, _, ok := e.ClockOffset(); ok {
+		t.Error("sample without remote time should be ignored")
+	}
+}
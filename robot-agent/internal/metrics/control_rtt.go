@@ -18,13 +18,28 @@ type ControlRTTSample struct {
 	RTT      time.Duration
 }
 
+// ControlSequenceStats holds ordering statistics for sequenced control messages.
+type ControlSequenceStats struct {
+	Applied      uint64 `json:"applied"`
+	Duplicates   uint64 `json:"duplicates"`
+	Reordered    uint64 `json:"reordered"`
+	Gaps         uint64 `json:"gaps"`
+	LateReleases uint64 `json:"late_releases"`
+}
+
+// SequenceStatsSource supplies the current control ordering statistics.
+type SequenceStatsSource func() ControlSequenceStats
+
 // ControlRTTCollector collects control RTT measurements.
 type ControlRTTCollector struct {
-	mu           sync.Mutex
-	samples      []ControlRTTSample
-	maxSamples   int
-	pendingPings map[uint32]int64
-	nextSeq      uint32
+	mu             sync.Mutex
+	samples        []ControlRTTSample
+	maxSamples     int
+	pendingPings   map[uint32]int64
+	nextSeq        uint32
+	sequenceSource SequenceStatsSource
+	clockOffset    *ClockOffsetEstimator
+	histogram      *Histogram
 }
 
 // NewControlRTTCollector creates a new RTT collector.
@@ -36,9 +51,30 @@ func NewControlRTTCollector(maxSamples int) *ControlRTTCollector {
 		samples:      make([]ControlRTTSample, 0, maxSamples),
 		maxSamples:   maxSamples,
 		pendingPings: make(map[uint32]int64),
+		clockOffset:  NewClockOffsetEstimator(defaultClockOffsetWindow),
+		histogram:    NewHistogram(ControlRTTBuckets),
 	}
 }
 
+// Histogram returns the RTT histogram covering every sample since the
+// collector was created.
+func (c *ControlRTTCollector) Histogram() *Histogram {
+	return c.histogram
+}
+
+// ClockOffset returns the console clock offset estimator fed by pongs.
+func (c *ControlRTTCollector) ClockOffset() *ClockOffsetEstimator {
+	return c.clockOffset
+}
+
+// SetSequenceStatsSource sets the source for control ordering statistics
+// included in generated reports.
+func (c *ControlRTTCollector) SetSequenceStatsSource(fn SequenceStatsSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sequenceSource = fn
+}
+
 // GeneratePing creates a new ping message and tracks it.
 func (c *ControlRTTCollector) GeneratePing() *protocol.PingMessage {
 	c.mu.Lock()
@@ -86,6 +122,9 @@ func (c *ControlRTTCollector) RecordPong(pong *protocol.PongMessage) {
 		c.samples = c.samples[1:]
 	}
 	c.samples = append(c.samples, sample)
+	c.histogram.ObserveDuration(rtt)
+
+	c.clockOffset.AddSample(sendTime, recvTime, pong.TRecv)
 }
 
 // Stats computes percentile statistics from collected samples.
@@ -126,6 +165,17 @@ func (c *ControlRTTCollector) statsLocked() RevocationStats {
 	}
 }
 
+// LastRTT returns the most recent RTT sample.
+func (c *ControlRTTCollector) LastRTT() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.samples) == 0 {
+		return 0, false
+	}
+	return c.samples[len(c.samples)-1].RTT, true
+}
+
 // Count returns the number of recorded samples.
 func (c *ControlRTTCollector) Count() int {
 	c.mu.Lock()
@@ -140,6 +190,7 @@ func (c *ControlRTTCollector) Reset() {
 
 	c.samples = make([]ControlRTTSample, 0, c.maxSamples)
 	c.pendingPings = make(map[uint32]int64)
+	c.clockOffset.Reset()
 }
 
 // cleanupStalePings removes pending pings older than threshold.
@@ -14,11 +14,13 @@ type ControlRTTTargets struct {
 
 // ControlRTTReport contains statistical analysis of control RTT measurements.
 type ControlRTTReport struct {
-	GeneratedAt time.Time          `json:"generated_at"`
-	SampleCount int                `json:"sample_count"`
-	Stats       RevocationStats    `json:"stats"`
-	MeetsTarget bool               `json:"meets_target"`
-	Targets     ControlRTTTargets  `json:"targets"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	SampleCount int                  `json:"sample_count"`
+	Stats       RevocationStats      `json:"stats"`
+	MeetsTarget bool                 `json:"meets_target"`
+	Targets     ControlRTTTargets    `json:"targets"`
+	Sequence    ControlSequenceStats `json:"sequence"`
+	ClockOffset ClockOffsetEstimate  `json:"clock_offset"`
 }
 
 // DefaultControlRTTTargets returns LAN targets from NFR-P2.
@@ -37,12 +39,19 @@ func (c *ControlRTTCollector) GenerateReport(targets ControlRTTTargets) ControlR
 	stats := c.statsLocked()
 	meetsTarget := stats.P50 <= targets.P50 && stats.P95 <= targets.P95
 
+	var sequence ControlSequenceStats
+	if c.sequenceSource != nil {
+		sequence = c.sequenceSource()
+	}
+
 	return ControlRTTReport{
 		GeneratedAt: time.Now().UTC(),
 		SampleCount: len(c.samples),
 		Stats:       stats,
 		MeetsTarget: meetsTarget,
 		Targets:     targets,
+		Sequence:    sequence,
+		ClockOffset: c.clockOffset.Estimate(),
 	}
 }
 
@@ -62,6 +71,19 @@ func (r ControlRTTReport) String() string {
 	result += "\nTargets:\n"
 	result += fmt.Sprintf("  P50: %v\n", r.Targets.P50)
 	result += fmt.Sprintf("  P95: %v\n", r.Targets.P95)
+	result += "\nCommand Ordering:\n"
+	result += fmt.Sprintf("  Applied: %d\n", r.Sequence.Applied)
+	result += fmt.Sprintf("  Duplicates: %d\n", r.Sequence.Duplicates)
+	result += fmt.Sprintf("  Reordered: %d\n", r.Sequence.Reordered)
+	result += fmt.Sprintf("  Gaps: %d\n", r.Sequence.Gaps)
+	result += fmt.Sprintf("  Late releases: %d\n", r.Sequence.LateReleases)
+	result += "\nClock Offset:\n"
+	if r.ClockOffset.Valid {
+		result += fmt.Sprintf("  Offset: %v (±%v)\n", r.ClockOffset.Offset, r.ClockOffset.Uncertainty)
+		result += fmt.Sprintf("  Drift: %.1f ppm\n", r.ClockOffset.DriftPPM)
+	} else {
+		result += "  No estimate\n"
+	}
 	result += fmt.Sprintf("\nMeets Target: %v\n", r.MeetsTarget)
 	return result
 }
@@ -282,3 +282,19 @@ func TestControlRTTCollector_GenerateReport(t *testing.T) {
 			report.Stats.P95, targets.P95)
 	}
 }
+
+func TestControlRTTReport_IncludesSequenceStats(t *testing.T) {
+	c := NewControlRTTCollector(100)
+	c.SetSequenceStatsSource(func() ControlSequenceStats {
+		return ControlSequenceStats{Applied: 10, Duplicates: 1, Reordered: 2, Gaps: 3}
+	})
+
+	report := c.GenerateReport(DefaultControlRTTTargets())
+
+	if report.Sequence.Applied != 10 || report.Sequence.Reordered != 2 {
+		t.Errorf("unexpected sequence stats: %+v", report.Sequence)
+	}
+	if !strings.Contains(report.String(), "Reordered: 2") {
+		t.Error("string report should include ordering stats")
+	}
+}
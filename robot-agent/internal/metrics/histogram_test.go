@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+func TestHistogram_Snapshot(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 0.5, 1})
+
+	h.Observe(0.05)
+	h.Observe(0.1) // Upper bounds are inclusive
+	h.ObserveDuration(300 * time.Millisecond)
+	h.Observe(2)
+
+	s := h.Snapshot()
+	want := []uint64{2, 3, 3}
+	for i := range want {
+		if s.Cumulative[i] != want[i] {
+			t.Errorf("bucket le=%g: expected %d, got %d", s.Bounds[i], want[i], s.Cumulative[i])
+		}
+	}
+	if s.Count != 4 {
+		t.Errorf("expected count 4, got %d", s.Count)
+	}
+	if s.Sum < 2.449 || s.Sum > 2.451 {
+		t.Errorf("expected sum 2.45, got %g", s.Sum)
+	}
+}
+
+func TestCollectorHistograms_SurviveReset(t *testing.T) {
+	rtt := NewControlRTTCollector(10)
+	ping := rtt.GeneratePing()
+	rtt.RecordPong(&protocol.PongMessage{Type: protocol.TypePong, Seq: ping.Seq, TMono: ping.TMono})
+	rtt.Reset()
+	if n := rtt.Histogram().Snapshot().Count; n != 1 {
+		t.Errorf("expected RTT histogram to keep 1 sample after reset, got %d", n)
+	}
+
+	setup := NewSessionSetupCollector(10)
+	now := time.Now()
+	setup.Record(SessionSetupTimestamps{
+		OfferReceived:  now,
+		TokenValidated: now.Add(5 * time.Millisecond),
+		AnswerSent:     now.Add(-time.Millisecond), // Out of order: not measured
+	})
+	setup.Reset()
+	hists := setup.Histograms()
+	if n := hists[PhaseTokenValidation].Snapshot().Count; n != 1 {
+		t.Errorf("expected token validation observed once, got %d", n)
+	}
+	if n := hists[PhaseWebRTCSetup].Snapshot().Count; n != 0 {
+		t.Errorf("expected unmeasured phase not observed, got %d", n)
+	}
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Prometheus metric types.
+const (
+	PromGauge     = "gauge"
+	PromCounter   = "counter"
+	PromHistogram = "histogram"
+	PromSummary   = "summary"
+)
+
+// PromWriter writes metrics in the Prometheus text exposition format.
+// The first write error is kept and returned by Err.
+type PromWriter struct {
+	w   io.Writer
+	err error
+}
+
+// NewPromWriter creates a writer emitting to w.
+func NewPromWriter(w io.Writer) *PromWriter {
+	return &PromWriter{w: w}
+}
+
+// Err returns the first error encountered while writing.
+func (p *PromWriter) Err() error {
+	return p.err
+}
+
+// Family starts a metric family with its HELP and TYPE lines. Samples of
+// the family must follow before the next Family call.
+func (p *PromWriter) Family(name, help, typ string) {
+	p.printf("# HELP %s %s\n", name, escapeHelp(help))
+	p.printf("# TYPE %s %s\n", name, typ)
+}
+
+// Sample writes one sample. labels are name/value pairs.
+func (p *PromWriter) Sample(name string, value float64, labels ...string) {
+	p.printf("%s%s %s\n", name, formatLabels(labels), formatValue(value))
+}
+
+// Histogram writes a complete histogram family from a snapshot.
+func (p *PromWriter) Histogram(name, help string, s HistogramSnapshot) {
+	p.Family(name, help, PromHistogram)
+	p.HistogramSamples(name, s)
+}
+
+// HistogramSamples writes the bucket, sum and count samples of one
+// labelled histogram within an already started family.
+func (p *PromWriter) HistogramSamples(name string, s HistogramSnapshot, labels ...string) {
+	for i, bound := range s.Bounds {
+		p.Sample(name+"_bucket", float64(s.Cumulative[i]), append(labels, "le", formatValue(bound))...)
+	}
+	p.Sample(name+"_bucket", float64(s.Count), append(labels, "le", "+Inf")...)
+	p.Sample(name+"_sum", s.Sum, labels...)
+	p.Sample(name+"_count", float64(s.Count), labels...)
+}
+
+func (p *PromWriter) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(labels[i])
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabel(labels[i+1]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+func escapeHelp(v string) string  { return helpEscaper.Replace(v) }
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPromWriter_Format(t *testing.T) {
+	var sb strings.Builder
+	p := NewPromWriter(&sb)
+
+	p.Family("agent_up", "Whether the agent\nis up.", PromGauge)
+	p.Sample("agent_up", 1)
+	p.Family("agent_denials_total", "Denials.", PromCounter)
+	p.Sample("agent_denials_total", 3, "type", `kvm"key`)
+
+	h := NewHistogram([]float64{0.5, 1})
+	h.Observe(0.25)
+	h.Observe(5)
+	p.Family("agent_setup_seconds", "Setup time.", PromHistogram)
+	p.HistogramSamples("agent_setup_seconds", h.Snapshot(), "phase", "total")
+
+	want := `# HELP agent_up Whether the agent\nis up.
+# TYPE agent_up gauge
+agent_up 1
+# HELP agent_denials_total Denials.
+# TYPE agent_denials_total counter
+agent_denials_total{type="kvm\"key"} 3
+# HELP agent_setup_seconds Setup time.
+# TYPE agent_setup_seconds histogram
+agent_setup_seconds_bucket{phase="total",le="0.5"} 1
+agent_setup_seconds_bucket{phase="total",le="1"} 1
+agent_setup_seconds_bucket{phase="total",le="+Inf"} 2
+agent_setup_seconds_sum{phase="total"} 5.25
+agent_setup_seconds_count{phase="total"} 2
+`
+	if p.Err() != nil {
+		t.Fatalf("unexpected error: %v", p.Err())
+	}
+	if sb.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
+
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	w.n++
+	return 0, errors.New("closed")
+}
+
+func TestPromWriter_StopsAfterError(t *testing.T) {
+	w := &failingWriter{}
+	p := NewPromWriter(w)
+
+	p.Family("agent_up", "Up.", PromGauge)
+	p.Sample("agent_up", 1)
+
+	if p.Err() == nil {
+		t.Error("expected write error")
+	}
+	if w.n != 1 {
+		t.Errorf("expected writes to stop after the first error, got %d", w.n)
+	}
+}
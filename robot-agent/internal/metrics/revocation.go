@@ -86,6 +86,7 @@ type RevocationCollector struct {
 	mu           sync.Mutex
 	measurements []RevocationTimestamps
 	maxSamples   int
+	histogram    *Histogram
 }
 
 // NewRevocationCollector creates a new collector with specified max samples.
@@ -96,9 +97,16 @@ func NewRevocationCollector(maxSamples int) *RevocationCollector {
 	return &RevocationCollector{
 		measurements: make([]RevocationTimestamps, 0, maxSamples),
 		maxSamples:   maxSamples,
+		histogram:    NewHistogram(RevocationBuckets),
 	}
 }
 
+// Histogram returns the total propagation time histogram covering every
+// revocation since the collector was created.
+func (c *RevocationCollector) Histogram() *Histogram {
+	return c.histogram
+}
+
 // Record adds a new measurement to the collector.
 func (c *RevocationCollector) Record(ts RevocationTimestamps) {
 	c.mu.Lock()
@@ -109,6 +117,9 @@ func (c *RevocationCollector) Record(ts RevocationTimestamps) {
 		c.measurements = c.measurements[1:]
 	}
 	c.measurements = append(c.measurements, ts)
+	if total := ts.Calculate().Total; total > 0 {
+		c.histogram.ObserveDuration(total)
+	}
 }
 
 // Stats calculates statistics for total propagation time.
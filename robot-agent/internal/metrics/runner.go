@@ -58,13 +58,28 @@ func (c RunConfig) Targets() LatencyTargets {
 	}
 }
 
+// WebRTCStatsTargets returns the media quality targets for this profile.
+func (c RunConfig) WebRTCStatsTargets() WebRTCStatsTargets {
+	switch c.Profile {
+	case ProfileWAN:
+		return WebRTCStatsTargets{
+			RTTP95:    c.SimulatedRTT + 150*time.Millisecond,
+			JitterP95: 50 * time.Millisecond,
+			LossP95:   0.03,
+		}
+	default:
+		return DefaultWebRTCStatsTargets()
+	}
+}
+
 // RevocationFunc is a function that triggers a revocation.
 type RevocationFunc func(sessionID, reason string)
 
 // MeasurementRunner executes revocation measurement tests.
 type MeasurementRunner struct {
-	collector *RevocationCollector
-	config    RunConfig
+	collector   *RevocationCollector
+	config      RunConfig
+	webrtcStats *WebRTCStatsCollector
 }
 
 // NewMeasurementRunner creates a new runner with the given configuration.
@@ -81,6 +96,12 @@ func (r *MeasurementRunner) Collector() *RevocationCollector {
 	return r.collector
 }
 
+// SetWebRTCStats adds a media quality report from c, covering the
+// measured iterations, to each run's result.
+func (r *MeasurementRunner) SetWebRTCStats(c *WebRTCStatsCollector) {
+	r.webrtcStats = c
+}
+
 // Run executes the measurement test using the provided revocation function.
 func (r *MeasurementRunner) Run(revokeFn RevocationFunc) MeasurementResult {
 	startTime := time.Now()
@@ -93,6 +114,9 @@ func (r *MeasurementRunner) Run(revokeFn RevocationFunc) MeasurementResult {
 		revokeFn(sessionID, "warmup")
 	}
 	r.collector.Reset() // Discard warmup measurements
+	if r.webrtcStats != nil {
+		r.webrtcStats.Reset()
+	}
 
 	// Actual measurement runs
 	for i := range r.config.Iterations {
@@ -102,11 +126,17 @@ func (r *MeasurementRunner) Run(revokeFn RevocationFunc) MeasurementResult {
 	}
 
 	report := r.collector.GenerateReport(r.config.Targets())
+	var webrtcReport *WebRTCStatsReport
+	if r.webrtcStats != nil {
+		wr := r.webrtcStats.GenerateReport(r.config.WebRTCStatsTargets())
+		webrtcReport = &wr
+	}
 	duration := time.Since(startTime)
 
 	return MeasurementResult{
 		Config:      r.config,
 		Report:      report,
+		WebRTCStats: webrtcReport,
 		RunDuration: duration,
 		StartTime:   startTime,
 		EndTime:     time.Now(),
@@ -121,11 +151,12 @@ func (r *MeasurementRunner) simulateLatency() {
 
 // MeasurementResult contains the complete results of a measurement run.
 type MeasurementResult struct {
-	Config      RunConfig        `json:"config"`
-	Report      RevocationReport `json:"report"`
-	RunDuration time.Duration    `json:"run_duration"`
-	StartTime   time.Time        `json:"start_time"`
-	EndTime     time.Time        `json:"end_time"`
+	Config      RunConfig          `json:"config"`
+	Report      RevocationReport   `json:"report"`
+	WebRTCStats *WebRTCStatsReport `json:"webrtc_stats,omitempty"` // Set when the runner has a stats collector
+	RunDuration time.Duration      `json:"run_duration"`
+	StartTime   time.Time          `json:"start_time"`
+	EndTime     time.Time          `json:"end_time"`
 }
 
 // JSON returns the result as JSON.
@@ -144,7 +175,7 @@ func (r MeasurementResult) WriteToFile(path string) error {
 
 // String returns a human-readable summary.
 func (r MeasurementResult) String() string {
-	return fmt.Sprintf(`
+	summary := fmt.Sprintf(`
 === Revocation Latency Measurement ===
 Profile: %s
 Iterations: %d
@@ -153,6 +184,10 @@ Run Duration: %v
 %s
 Overall: %s
 `, r.Config.Profile, r.Config.Iterations, r.RunDuration, r.Report.String(), statusStr(r.Report.MeetsTarget))
+	if r.WebRTCStats != nil {
+		summary += "\n" + r.WebRTCStats.String()
+	}
+	return summary
 }
 
 // CompareResults compares LAN and WAN results.
@@ -148,3 +148,52 @@ func newTestRevokeFn(collector *RevocationCollector) RevocationFunc {
 		})
 	}
 }
+
+func TestRunConfig_WebRTCStatsTargets(t *testing.T) {
+	if got := DefaultLANConfig().WebRTCStatsTargets(); got != DefaultWebRTCStatsTargets() {
+		t.Errorf("LAN: expected default targets, got %+v", got)
+	}
+
+	wan := DefaultWANConfig().WebRTCStatsTargets()
+	if wan.RTTP95 != 250*time.Millisecond {
+		t.Errorf("WAN RTTP95: expected 250ms, got %v", wan.RTTP95)
+	}
+	if wan.LossP95 <= DefaultWebRTCStatsTargets().LossP95 {
+		t.Errorf("WAN loss target should be looser than LAN, got %v", wan.LossP95)
+	}
+}
+
+func TestMeasurementRunner_WebRTCStatsReport(t *testing.T) {
+	runner := NewMeasurementRunner(RunConfig{Profile: ProfileLAN, Iterations: 3, WarmupRuns: 1})
+	stats := NewWebRTCStatsCollector(10)
+	runner.SetWebRTCStats(stats)
+
+	base := time.Now()
+	step := 0
+	record := func(rtt time.Duration) {
+		stats.Record(WebRTCStatsSnapshot{Timestamp: base.Add(time.Duration(step) * time.Second), RoundTripTime: rtt})
+		step++
+	}
+	record(0)
+	record(time.Second) // Warmup sample, discarded
+
+	revoke := newTestRevokeFn(runner.Collector())
+	result := runner.Run(func(sessionID, reason string) {
+		revoke(sessionID, reason)
+		if reason == "measurement" {
+			record(20 * time.Millisecond)
+		}
+	})
+
+	if result.WebRTCStats == nil {
+		t.Fatal("expected a WebRTC stats report in the result")
+	}
+	// The first measured snapshot only sets the baseline after the reset
+	if result.WebRTCStats.SampleCount != 2 || result.WebRTCStats.RoundTripTime.Max != 20*time.Millisecond {
+		t.Errorf("expected 2 measured samples at 20ms, got %d, max RTT %v",
+			result.WebRTCStats.SampleCount, result.WebRTCStats.RoundTripTime.Max)
+	}
+	if result.WebRTCStats.Targets != DefaultLANConfig().WebRTCStatsTargets() {
+		t.Errorf("expected profile targets, got %+v", result.WebRTCStats.Targets)
+	}
+}
@@ -25,6 +25,9 @@ type SessionSetupTimestamps struct {
 	// Session activation
 	SessionActivated time.Time
 	DataChannelReady time.Time
+
+	// CandidatePairType is the ICE path the session selected: host, srflx or relay
+	CandidatePairType string
 }
 
 // SessionSetupPhases contains duration breakdowns for session setup.
@@ -52,16 +55,53 @@ type SessionSetupCollector struct {
 	mu         sync.Mutex
 	samples    []SessionSetupTimestamps
 	maxSamples int
+	histograms map[string]*Histogram // By phase name
 }
 
+// Session setup phase names, as exported in histograms.
+const (
+	PhaseTotal             = "total"
+	PhaseTokenValidation   = "token_validation"
+	PhaseWebRTCSetup       = "webrtc_setup"
+	PhaseIceNegotiation    = "ice_negotiation"
+	PhaseSessionActivation = "session_activation"
+)
+
 // NewSessionSetupCollector creates a new collector with specified max samples.
 func NewSessionSetupCollector(maxSamples int) *SessionSetupCollector {
 	if maxSamples <= 0 {
 		maxSamples = 1000
 	}
+	histograms := make(map[string]*Histogram)
+	for _, phase := range []string{PhaseTotal, PhaseTokenValidation, PhaseWebRTCSetup, PhaseIceNegotiation, PhaseSessionActivation} {
+		histograms[phase] = NewHistogram(SessionSetupBuckets)
+	}
 	return &SessionSetupCollector{
 		samples:    make([]SessionSetupTimestamps, 0, maxSamples),
 		maxSamples: maxSamples,
+		histograms: histograms,
+	}
+}
+
+// Histograms returns the per-phase duration histograms covering every
+// session since the collector was created.
+func (c *SessionSetupCollector) Histograms() map[string]*Histogram {
+	return c.histograms
+}
+
+// observe adds the measured phases of ts to the histograms.
+func (c *SessionSetupCollector) observe(ts SessionSetupTimestamps) {
+	p := ts.Calculate()
+	for phase, d := range map[string]time.Duration{
+		PhaseTotal:             p.Total,
+		PhaseTokenValidation:   p.TokenValidation,
+		PhaseWebRTCSetup:       p.WebRTCSetup,
+		PhaseIceNegotiation:    p.IceNegotiation,
+		PhaseSessionActivation: p.SessionActivation,
+	} {
+		if d > 0 {
+			c.histograms[phase].ObserveDuration(d)
+		}
 	}
 }
 
@@ -74,6 +114,7 @@ func (c *SessionSetupCollector) Record(ts SessionSetupTimestamps) {
 		c.samples = c.samples[1:]
 	}
 	c.samples = append(c.samples, ts)
+	c.observe(ts)
 }
 
 // Count returns the number of recorded measurements.
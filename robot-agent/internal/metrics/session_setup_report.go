@@ -3,6 +3,7 @@ package metrics
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -16,19 +17,20 @@ type SessionSetupTargets struct {
 // DefaultSessionSetupTargets returns the default targets from PRD NFR-P1.
 func DefaultSessionSetupTargets() SessionSetupTargets {
 	return SessionSetupTargets{
-		TotalP50: 2 * time.Second,  // LAN: p50 ≤ 2s
-		TotalP95: 5 * time.Second,  // LAN: p95 ≤ 5s
+		TotalP50: 2 * time.Second, // LAN: p50 ≤ 2s
+		TotalP95: 5 * time.Second, // LAN: p95 ≤ 5s
 	}
 }
 
 // SessionSetupReport contains a complete session setup measurement report.
 type SessionSetupReport struct {
-	GeneratedAt time.Time           `json:"generated_at"`
-	SampleCount int                 `json:"sample_count"`
-	Total       RevocationStats     `json:"total_setup_time"`
-	Breakdown   SessionSetupBreakdown `json:"breakdown"`
-	MeetsTarget bool                `json:"meets_target"`
-	Targets     SessionSetupTargets `json:"targets"`
+	GeneratedAt    time.Time             `json:"generated_at"`
+	SampleCount    int                   `json:"sample_count"`
+	Total          RevocationStats       `json:"total_setup_time"`
+	Breakdown      SessionSetupBreakdown `json:"breakdown"`
+	CandidatePairs map[string]int        `json:"candidate_pairs,omitempty"`
+	MeetsTarget    bool                  `json:"meets_target"`
+	Targets        SessionSetupTargets   `json:"targets"`
 }
 
 // SessionSetupBreakdown contains stats for each setup phase.
@@ -52,6 +54,17 @@ func (c *SessionSetupCollector) GenerateReport(targets SessionSetupTargets) Sess
 
 	totalStats := phaseStats(func(p SessionSetupPhases) time.Duration { return p.Total })
 
+	var pairs map[string]int
+	for _, ts := range c.samples {
+		if ts.CandidatePairType == "" {
+			continue
+		}
+		if pairs == nil {
+			pairs = make(map[string]int)
+		}
+		pairs[ts.CandidatePairType]++
+	}
+
 	return SessionSetupReport{
 		GeneratedAt: time.Now().UTC(),
 		SampleCount: len(c.samples),
@@ -62,8 +75,9 @@ func (c *SessionSetupCollector) GenerateReport(targets SessionSetupTargets) Sess
 			IceNegotiation:    phaseStats(func(p SessionSetupPhases) time.Duration { return p.IceNegotiation }),
 			SessionActivation: phaseStats(func(p SessionSetupPhases) time.Duration { return p.SessionActivation }),
 		},
-		MeetsTarget: totalStats.P50 <= targets.TotalP50 && totalStats.P95 <= targets.TotalP95,
-		Targets:     targets,
+		CandidatePairs: pairs,
+		MeetsTarget:    totalStats.P50 <= targets.TotalP50 && totalStats.P95 <= targets.TotalP95,
+		Targets:        targets,
 	}
 }
 
@@ -93,6 +107,18 @@ func (r SessionSetupReport) String() string {
 	sb.WriteString("  Session Activation:\n")
 	writeStatsIndented(&sb, r.Breakdown.SessionActivation, "    ")
 
+	if len(r.CandidatePairs) > 0 {
+		sb.WriteString("\nCandidate Pairs:\n")
+		types := make([]string, 0, len(r.CandidatePairs))
+		for t := range r.CandidatePairs {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			sb.WriteString(fmt.Sprintf("  %s: %d\n", t, r.CandidatePairs[t]))
+		}
+	}
+
 	sb.WriteString("\nTargets:\n")
 	sb.WriteString(fmt.Sprintf("  P50: %v (target: %v)\n", r.Total.P50, r.Targets.TotalP50))
 	sb.WriteString(fmt.Sprintf("  P95: %v (target: %v)\n", r.Total.P95, r.Targets.TotalP95))
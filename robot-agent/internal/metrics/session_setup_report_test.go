@@ -176,3 +176,26 @@ func TestDefaultSessionSetupTargets(t *testing.T) {
 		t.Errorf("TotalP95 = %v, want 5s", targets.TotalP95)
 	}
 }
+
+func TestSessionSetupReport_CandidatePairs(t *testing.T) {
+	c := NewSessionSetupCollector(100)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, pair := range []string{"host", "relay", "host", ""} {
+		c.Record(SessionSetupTimestamps{
+			SessionID:         "sess",
+			OfferReceived:     base,
+			DataChannelReady:  base.Add(time.Second),
+			CandidatePairType: pair,
+		})
+	}
+
+	report := c.GenerateReport(DefaultSessionSetupTargets())
+
+	if report.CandidatePairs["host"] != 2 || report.CandidatePairs["relay"] != 1 || len(report.CandidatePairs) != 2 {
+		t.Errorf("CandidatePairs = %v, want host=2 relay=1", report.CandidatePairs)
+	}
+	if s := report.String(); !strings.Contains(s, "Candidate Pairs:\n  host: 2\n  relay: 1\n") {
+		t.Errorf("expected candidate pair summary, got:\n%s", s)
+	}
+}
@@ -0,0 +1,241 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WebRTCStatsSnapshot holds cumulative peer connection counters at one
+// point in time, as read from GetStats.
+type WebRTCStatsSnapshot struct {
+	Timestamp          time.Time
+	BytesSent          uint64        // Outbound RTP payload bytes
+	PacketsSent        uint64        // Outbound RTP packets
+	FramesSent         uint64        // Outbound video frames
+	FractionLost       float64       // Latest remote-reported loss, worst stream
+	Jitter             time.Duration // Latest remote-reported jitter, worst stream
+	NACKCount          uint32        // NACKs received from the peer
+	PLICount           uint32        // PLIs received from the peer
+	RoundTripTime      time.Duration // Selected candidate pair RTT
+	SCTPBufferedAmount uint64        // Control data bytes queued for send
+}
+
+// WebRTCStatsSource reads the current counters. It returns an error while
+// there is no peer connection.
+type WebRTCStatsSource func() (WebRTCStatsSnapshot, error)
+
+// WebRTCStatsSample is one polling interval, derived from two snapshots.
+type WebRTCStatsSample struct {
+	Timestamp          time.Time
+	Bitrate            float64 // Outbound RTP bits per second
+	FrameRate          float64 // Frames sent per second
+	FramesSent         uint64
+	PacketLoss         float64 // Fraction of packets lost, 0-1
+	Jitter             time.Duration
+	NACKs              uint32
+	PLIs               uint32
+	RoundTripTime      time.Duration
+	SCTPBufferedAmount uint64
+}
+
+// WebRTCStatsCollector periodically polls peer connection stats and keeps
+// per-interval samples for reporting.
+type WebRTCStatsCollector struct {
+	mu         sync.Mutex
+	samples    []WebRTCStatsSample
+	maxSamples int
+	source     WebRTCStatsSource
+	last       WebRTCStatsSnapshot
+	hasLast    bool
+}
+
+// NewWebRTCStatsCollector creates a new WebRTC stats collector.
+func NewWebRTCStatsCollector(maxSamples int) *WebRTCStatsCollector {
+	if maxSamples <= 0 {
+		maxSamples = 1000
+	}
+	return &WebRTCStatsCollector{
+		samples:    make([]WebRTCStatsSample, 0, maxSamples),
+		maxSamples: maxSamples,
+	}
+}
+
+// SetSource sets where Poll reads stats from.
+func (c *WebRTCStatsCollector) SetSource(fn WebRTCStatsSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.source = fn
+}
+
+// Run polls the source every interval until ctx is cancelled.
+func (c *WebRTCStatsCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Poll()
+		}
+	}
+}
+
+// Poll reads one snapshot from the source and records it. A failed read
+// means the peer connection is gone, so the next snapshot starts a new
+// baseline.
+func (c *WebRTCStatsCollector) Poll() error {
+	c.mu.Lock()
+	source := c.source
+	c.mu.Unlock()
+
+	if source == nil {
+		return nil
+	}
+	snap, err := source()
+	if err != nil {
+		c.mu.Lock()
+		c.hasLast = false
+		c.mu.Unlock()
+		return err
+	}
+	c.Record(snap)
+	return nil
+}
+
+// Record adds a snapshot. The first snapshot, and any whose counters went
+// backwards because a new peer connection started, only sets the baseline.
+func (c *WebRTCStatsCollector) Record(snap WebRTCStatsSnapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last, hasLast := c.last, c.hasLast
+	c.last, c.hasLast = snap, true
+
+	elapsed := snap.Timestamp.Sub(last.Timestamp).Seconds()
+	if !hasLast || elapsed <= 0 || snap.BytesSent < last.BytesSent || snap.FramesSent < last.FramesSent ||
+		snap.NACKCount < last.NACKCount || snap.PLICount < last.PLICount {
+		return
+	}
+
+	frames := snap.FramesSent - last.FramesSent
+	sample := WebRTCStatsSample{
+		Timestamp:          snap.Timestamp,
+		Bitrate:            float64(snap.BytesSent-last.BytesSent) * 8 / elapsed,
+		FrameRate:          float64(frames) / elapsed,
+		FramesSent:         frames,
+		PacketLoss:         snap.FractionLost,
+		Jitter:             snap.Jitter,
+		NACKs:              snap.NACKCount - last.NACKCount,
+		PLIs:               snap.PLICount - last.PLICount,
+		RoundTripTime:      snap.RoundTripTime,
+		SCTPBufferedAmount: snap.SCTPBufferedAmount,
+	}
+
+	if len(c.samples) >= c.maxSamples {
+		c.samples = c.samples[1:]
+	}
+	c.samples = append(c.samples, sample)
+}
+
+// Count returns the number of recorded samples.
+func (c *WebRTCStatsCollector) Count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.samples)
+}
+
+// Samples returns a copy of the recorded samples.
+func (c *WebRTCStatsCollector) Samples() []WebRTCStatsSample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]WebRTCStatsSample(nil), c.samples...)
+}
+
+// Reset clears all samples and the baseline snapshot.
+func (c *WebRTCStatsCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples = c.samples[:0]
+	c.hasLast = false
+}
+
+// ValueStats holds statistical analysis of a non-duration measurement.
+type ValueStats struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+}
+
+// calculateValueStats computes statistics over all values, zeros included.
+func calculateValueStats(values []float64) ValueStats {
+	if len(values) == 0 {
+		return ValueStats{}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var total float64
+	for _, v := range sorted {
+		total += v
+	}
+
+	at := func(p int) float64 {
+		idx := (p * len(sorted)) / 100
+		if idx >= len(sorted) {
+			idx = len(sorted) - 1
+		}
+		return sorted[idx]
+	}
+
+	return ValueStats{
+		Count: len(sorted),
+		P50:   at(50),
+		P95:   at(95),
+		P99:   at(99),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Avg:   total / float64(len(sorted)),
+	}
+}
+
+// calculateDurationStats computes statistics over measured (non-zero)
+// durations.
+func calculateDurationStats(durations []time.Duration) RevocationStats {
+	measured := make([]time.Duration, 0, len(durations))
+	for _, d := range durations {
+		if d > 0 {
+			measured = append(measured, d)
+		}
+	}
+	if len(measured) == 0 {
+		return RevocationStats{}
+	}
+
+	sort.Slice(measured, func(i, j int) bool {
+		return measured[i] < measured[j]
+	})
+
+	var total time.Duration
+	for _, d := range measured {
+		total += d
+	}
+
+	return RevocationStats{
+		Count: len(measured),
+		P50:   percentile(measured, 50),
+		P95:   percentile(measured, 95),
+		P99:   percentile(measured, 99),
+		Min:   measured[0],
+		Max:   measured[len(measured)-1],
+		Avg:   total / time.Duration(len(measured)),
+	}
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WebRTCStatsTargets defines media quality targets.
+type WebRTCStatsTargets struct {
+	RTTP95    time.Duration `json:"rtt_p95"`
+	JitterP95 time.Duration `json:"jitter_p95"`
+	LossP95   float64       `json:"loss_p95"`
+}
+
+// DefaultWebRTCStatsTargets returns LAN media quality targets.
+func DefaultWebRTCStatsTargets() WebRTCStatsTargets {
+	return WebRTCStatsTargets{
+		RTTP95:    50 * time.Millisecond,
+		JitterP95: 30 * time.Millisecond,
+		LossP95:   0.01, // 1% loss
+	}
+}
+
+// WebRTCStatsReport contains statistical analysis of WebRTC stats samples.
+type WebRTCStatsReport struct {
+	GeneratedAt   time.Time          `json:"generated_at"`
+	SampleCount   int                `json:"sample_count"`
+	Bitrate       ValueStats         `json:"bitrate_bps"`
+	FrameRate     ValueStats         `json:"frame_rate"`
+	PacketLoss    ValueStats         `json:"packet_loss"`
+	Jitter        RevocationStats    `json:"jitter"`
+	RoundTripTime RevocationStats    `json:"round_trip_time"`
+	SCTPBuffered  ValueStats         `json:"sctp_buffered_bytes"`
+	FramesSent    uint64             `json:"frames_sent"`
+	NACKs         uint64             `json:"nacks"`
+	PLIs          uint64             `json:"plis"`
+	MeetsTarget   bool               `json:"meets_target"`
+	Targets       WebRTCStatsTargets `json:"targets"`
+}
+
+// GenerateReport creates a statistical report from collected samples.
+func (c *WebRTCStatsCollector) GenerateReport(targets WebRTCStatsTargets) WebRTCStatsReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.samples)
+	bitrate := make([]float64, 0, n)
+	frameRate := make([]float64, 0, n)
+	loss := make([]float64, 0, n)
+	buffered := make([]float64, 0, n)
+	jitter := make([]time.Duration, 0, n)
+	rtt := make([]time.Duration, 0, n)
+
+	report := WebRTCStatsReport{
+		GeneratedAt: time.Now().UTC(),
+		SampleCount: n,
+		Targets:     targets,
+	}
+	for _, s := range c.samples {
+		bitrate = append(bitrate, s.Bitrate)
+		frameRate = append(frameRate, s.FrameRate)
+		loss = append(loss, s.PacketLoss)
+		buffered = append(buffered, float64(s.SCTPBufferedAmount))
+		jitter = append(jitter, s.Jitter)
+		rtt = append(rtt, s.RoundTripTime)
+		report.FramesSent += s.FramesSent
+		report.NACKs += uint64(s.NACKs)
+		report.PLIs += uint64(s.PLIs)
+	}
+
+	report.Bitrate = calculateValueStats(bitrate)
+	report.FrameRate = calculateValueStats(frameRate)
+	report.PacketLoss = calculateValueStats(loss)
+	report.SCTPBuffered = calculateValueStats(buffered)
+	report.Jitter = calculateDurationStats(jitter)
+	report.RoundTripTime = calculateDurationStats(rtt)
+	report.MeetsTarget = report.RoundTripTime.P95 <= targets.RTTP95 &&
+		report.Jitter.P95 <= targets.JitterP95 &&
+		report.PacketLoss.P95 <= targets.LossP95
+
+	return report
+}
+
+// String returns a human-readable report.
+func (r WebRTCStatsReport) String() string {
+	var result string
+	result += "=== WebRTC Stats Report ===\n"
+	result += fmt.Sprintf("Generated: %s\n", r.GeneratedAt.Format(time.RFC3339))
+	result += fmt.Sprintf("Sample Count: %d\n", r.SampleCount)
+	result += "\nOutbound Media:\n"
+	result += fmt.Sprintf("  Bitrate P50: %.0f bps, P95: %.0f bps\n", r.Bitrate.P50, r.Bitrate.P95)
+	result += fmt.Sprintf("  Frame Rate P50: %.1f fps\n", r.FrameRate.P50)
+	result += fmt.Sprintf("  Frames Sent: %d\n", r.FramesSent)
+	result += fmt.Sprintf("  NACKs: %d, PLIs: %d\n", r.NACKs, r.PLIs)
+	result += "\nNetwork:\n"
+	result += fmt.Sprintf("  RTT P50: %v, P95: %v (target: %v)\n", r.RoundTripTime.P50, r.RoundTripTime.P95, r.Targets.RTTP95)
+	result += fmt.Sprintf("  Jitter P50: %v, P95: %v (target: %v)\n", r.Jitter.P50, r.Jitter.P95, r.Targets.JitterP95)
+	result += fmt.Sprintf("  Packet Loss P50: %.2f%%, P95: %.2f%% (target: %.2f%%)\n",
+		r.PacketLoss.P50*100, r.PacketLoss.P95*100, r.Targets.LossP95*100)
+	result += "\nControl Channel:\n"
+	result += fmt.Sprintf("  SCTP Buffered P95: %.0f bytes, Max: %.0f bytes\n", r.SCTPBuffered.P95, r.SCTPBuffered.Max)
+	result += fmt.Sprintf("\nMeets Target: %v\n", r.MeetsTarget)
+	return result
+}
+
+// JSON returns the report as JSON.
+func (r WebRTCStatsReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebRTCStatsCollector_RecordDerivesRates(t *testing.T) {
+	c := NewWebRTCStatsCollector(10)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	c.Record(WebRTCStatsSnapshot{Timestamp: base, BytesSent: 1000, FramesSent: 10, NACKCount: 1})
+	if c.Count() != 0 {
+		t.Fatalf("first snapshot must only set the baseline, got %d samples", c.Count())
+	}
+
+	c.Record(WebRTCStatsSnapshot{
+		Timestamp:          base.Add(2 * time.Second),
+		BytesSent:          251000,
+		FramesSent:         70,
+		FractionLost:       0.02,
+		Jitter:             5 * time.Millisecond,
+		NACKCount:          4,
+		PLICount:           1,
+		RoundTripTime:      20 * time.Millisecond,
+		SCTPBufferedAmount: 512,
+	})
+
+	samples := c.Samples()
+	if len(samples) != 1 {
+		t.Fatalf("expected 1 sample, got %d", len(samples))
+	}
+	s := samples[0]
+	if s.Bitrate != 1000000 {
+		t.Errorf("Bitrate = %v, want 1000000", s.Bitrate)
+	}
+	if s.FrameRate != 30 || s.FramesSent != 60 {
+		t.Errorf("FrameRate = %v, FramesSent = %d, want 30 and 60", s.FrameRate, s.FramesSent)
+	}
+	if s.NACKs != 3 || s.PLIs != 1 {
+		t.Errorf("NACKs = %d, PLIs = %d, want 3 and 1", s.NACKs, s.PLIs)
+	}
+	if s.PacketLoss != 0.02 || s.Jitter != 5*time.Millisecond || s.RoundTripTime != 20*time.Millisecond || s.SCTPBufferedAmount != 512 {
+		t.Errorf("unexpected gauges: %+v", s)
+	}
+}
+
+func TestWebRTCStatsCollector_NewConnectionRebaselines(t *testing.T) {
+	c := NewWebRTCStatsCollector(10)
+	base := time.Now()
+
+	c.Record(WebRTCStatsSnapshot{Timestamp: base, BytesSent: 5000})
+	c.Record(WebRTCStatsSnapshot{Timestamp: base.Add(time.Second), BytesSent: 100})
+	if c.Count() != 0 {
+		t.Fatalf("counters going backwards must not produce a sample, got %d", c.Count())
+	}
+	c.Record(WebRTCStatsSnapshot{Timestamp: base.Add(2 * time.Second), BytesSent: 1100})
+	if s := c.Samples(); len(s) != 1 || s[0].Bitrate != 8000 {
+		t.Errorf("expected a sample against the new baseline, got %+v", s)
+	}
+}
+
+func TestWebRTCStatsCollector_PollSourceError(t *testing.T) {
+	c := NewWebRTCStatsCollector(10)
+	base := time.Now()
+	snap := WebRTCStatsSnapshot{Timestamp: base}
+	var srcErr error
+	c.SetSource(func() (WebRTCStatsSnapshot, error) { return snap, srcErr })
+
+	if err := c.Poll(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The peer connection went away between polls
+	srcErr = errors.New("no peer connection")
+	if err := c.Poll(); err == nil {
+		t.Fatal("expected source error")
+	}
+
+	srcErr = nil
+	snap = WebRTCStatsSnapshot{Timestamp: base.Add(2 * time.Second), BytesSent: 1000}
+	c.Poll()
+	if c.Count() != 0 {
+		t.Errorf("expected a fresh baseline after a failed poll, got %d samples", c.Count())
+	}
+}
+
+func TestWebRTCStatsCollector_RingBuffer(t *testing.T) {
+	c := NewWebRTCStatsCollector(3)
+	base := time.Now()
+	for i := range 6 {
+		c.Record(WebRTCStatsSnapshot{Timestamp: base.Add(time.Duration(i) * time.Second)})
+	}
+	if c.Count() != 3 {
+		t.Errorf("Count = %d, want 3", c.Count())
+	}
+
+	c.Reset()
+	c.Record(WebRTCStatsSnapshot{Timestamp: base.Add(10 * time.Second)})
+	if c.Count() != 0 {
+		t.Errorf("expected Reset to clear samples and baseline, got %d", c.Count())
+	}
+}
+
+func TestWebRTCStatsCollector_GenerateReport(t *testing.T) {
+	c := NewWebRTCStatsCollector(200)
+	base := time.Now()
+
+	for i := range 101 {
+		c.Record(WebRTCStatsSnapshot{
+			Timestamp:     base.Add(time.Duration(i) * time.Second),
+			BytesSent:     uint64(i) * 250000, // 2 Mbps
+			FramesSent:    uint64(i) * 30,
+			NACKCount:     uint32(i),
+			RoundTripTime: time.Duration(i%10+1) * time.Millisecond,
+			Jitter:        2 * time.Millisecond,
+		})
+	}
+
+	report := c.GenerateReport(DefaultWebRTCStatsTargets())
+
+	if report.SampleCount != 100 {
+		t.Errorf("SampleCount = %d, want 100", report.SampleCount)
+	}
+	if report.Bitrate.P50 != 2000000 {
+		t.Errorf("Bitrate.P50 = %v, want 2000000", report.Bitrate.P50)
+	}
+	if report.FrameRate.P95 != 30 || report.FramesSent != 3000 || report.NACKs != 100 {
+		t.Errorf("unexpected frame/NACK totals: %+v", report)
+	}
+	if report.RoundTripTime.Min != time.Millisecond || report.RoundTripTime.Max != 10*time.Millisecond {
+		t.Errorf("unexpected RTT stats: %+v", report.RoundTripTime)
+	}
+	if !report.MeetsTarget {
+		t.Error("expected LAN targets met")
+	}
+
+	if s := report.String(); !strings.Contains(s, "Frames Sent: 3000") || !strings.Contains(s, "Meets Target: true") {
+		t.Errorf("unexpected report:\n%s", s)
+	}
+}
+
+func TestWebRTCStatsReport_MissesLossTarget(t *testing.T) {
+	c := NewWebRTCStatsCollector(10)
+	base := time.Now()
+	c.Record(WebRTCStatsSnapshot{Timestamp: base})
+	c.Record(WebRTCStatsSnapshot{Timestamp: base.Add(time.Second), FractionLost: 0.05})
+
+	if c.GenerateReport(DefaultWebRTCStatsTargets()).MeetsTarget {
+		t.Error("expected 5% loss to miss the LAN target")
+	}
+	if !c.GenerateReport(WebRTCStatsTargets{RTTP95: time.Second, JitterP95: time.Second, LossP95: 0.1}).MeetsTarget {
+		t.Error("expected loose targets met")
+	}
+}
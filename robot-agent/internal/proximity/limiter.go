@@ -0,0 +1,208 @@
+// Package proximity limits drive speed based on range sensor readings.
+package proximity
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Limit reasons reported to the console.
+const (
+	ReasonObstacleSlow = "obstacle_slow"
+	ReasonObstacleStop = "obstacle_stop"
+	ReasonSensorStale  = "sensor_stale"
+)
+
+// Reading is a single range measurement in the robot frame.
+type Reading struct {
+	Angle    float64 // Radians, 0 = forward, counter-clockwise positive
+	Distance float64 // Meters; non-positive, NaN or Inf readings are ignored
+}
+
+// Scan is a set of range readings captured at the same time.
+// It can represent a LaserScan or a ring of sonar sensors.
+type Scan struct {
+	Readings []Reading
+	Time     time.Time
+}
+
+// ScanFromLaser converts LaserScan-style evenly spaced ranges into a Scan.
+func ScanFromLaser(angleMin, angleIncrement float64, ranges []float64, t time.Time) Scan {
+	readings := make([]Reading, len(ranges))
+	for i, r := range ranges {
+		readings[i] = Reading{Angle: angleMin + float64(i)*angleIncrement, Distance: r}
+	}
+	return Scan{Readings: readings, Time: t}
+}
+
+// RangeStream is implemented by robot backends that publish range data.
+type RangeStream interface {
+	SubscribeRanges(fn func(Scan))
+}
+
+// Config holds proximity limiting parameters.
+type Config struct {
+	StopDistance float64       // Forward motion is blocked within this distance (m)
+	SlowDistance float64       // Speed scales linearly from here down to StopDistance (m)
+	HalfArc      float64       // Half-width of the forward/rear detection cone (rad)
+	MaxScanAge   time.Duration // Older scans are treated as missing
+}
+
+// LimitCallback is called when the set of active limit reasons changes.
+type LimitCallback func(reasons []string)
+
+// Limiter scales linear velocity down as obstacles get closer.
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	scan     Scan
+	dir      float64 // Sign of the last commanded linear velocity
+	reasons  []string
+	onChange LimitCallback
+	onStop   func()
+}
+
+// NewLimiter creates a proximity limiter.
+func NewLimiter(cfg Config) *Limiter {
+	return newLimiterWithClock(cfg, time.Now)
+}
+
+func newLimiterWithClock(cfg Config, now func() time.Time) *Limiter {
+	if cfg.SlowDistance < cfg.StopDistance {
+		cfg.SlowDistance = cfg.StopDistance
+	}
+	return &Limiter{cfg: cfg, now: now}
+}
+
+// SetLimitCallback sets the callback for limit reason changes.
+func (l *Limiter) SetLimitCallback(fn LimitCallback) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onChange = fn
+}
+
+// SetStopFunc sets the function that halts the robot immediately when an
+// obstacle stop starts, bypassing any motion shaping.
+func (l *Limiter) SetStopFunc(fn func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onStop = fn
+}
+
+// Update stores the latest scan and re-evaluates the limits for the last
+// commanded direction of travel, so an obstacle stop takes effect without
+// waiting for the next drive command.
+func (l *Limiter) Update(scan Scan) {
+	l.mu.Lock()
+	l.scan = scan
+	dir := l.dir
+	l.mu.Unlock()
+
+	_, reasons := l.evaluate(scan, dir)
+	l.setReasons(reasons)
+}
+
+// Reasons returns the currently active limit reasons.
+func (l *Limiter) Reasons() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.reasons)
+}
+
+// FilterDrive scales linear velocity by obstacle proximity in the direction
+// of travel. Angular velocity is never limited, and motion away from an
+// obstacle is always allowed. Limits stay active while the robot is
+// stopped, until a scan clears them or motion changes direction.
+func (l *Limiter) FilterDrive(v, w float64) (float64, float64, error) {
+	l.mu.Lock()
+	if v != 0 {
+		l.dir = math.Copysign(1, v)
+	}
+	scan, dir := l.scan, l.dir
+	l.mu.Unlock()
+
+	factor, reasons := l.evaluate(scan, dir)
+	l.setReasons(reasons)
+	return v * factor, w, nil
+}
+
+// evaluate returns the allowed speed fraction and limit reasons for travel
+// in direction dir (positive forward, negative reverse, zero not moving).
+func (l *Limiter) evaluate(scan Scan, dir float64) (float64, []string) {
+	switch {
+	case dir == 0:
+		// Turning in place is always allowed
+		return 1, nil
+	case scan.Time.IsZero() || l.now().Sub(scan.Time) > l.cfg.MaxScanAge:
+		// Fail safe: without fresh data, allow only reversing
+		if dir > 0 {
+			return 0, []string{ReasonSensorStale}
+		}
+		return 1, nil
+	}
+
+	heading := 0.0
+	if dir < 0 {
+		heading = math.Pi
+	}
+	factor := l.speedFactor(scan, heading)
+	switch {
+	case factor == 0:
+		return 0, []string{ReasonObstacleStop}
+	case factor < 1:
+		return factor, []string{ReasonObstacleSlow}
+	}
+	return 1, nil
+}
+
+// speedFactor returns the allowed speed fraction for travel toward heading.
+func (l *Limiter) speedFactor(scan Scan, heading float64) float64 {
+	nearest := math.Inf(1)
+	for _, r := range scan.Readings {
+		if r.Distance <= 0 || math.IsNaN(r.Distance) || math.IsInf(r.Distance, 0) {
+			continue
+		}
+		if angleDiff(r.Angle, heading) <= l.cfg.HalfArc {
+			nearest = math.Min(nearest, r.Distance)
+		}
+	}
+
+	if nearest <= l.cfg.StopDistance {
+		return 0
+	}
+	if nearest >= l.cfg.SlowDistance {
+		return 1
+	}
+	return (nearest - l.cfg.StopDistance) / (l.cfg.SlowDistance - l.cfg.StopDistance)
+}
+
+// setReasons records the active reasons and notifies on change. Entering
+// an obstacle stop halts the robot at once.
+func (l *Limiter) setReasons(reasons []string) {
+	l.mu.Lock()
+	changed := !slices.Equal(l.reasons, reasons)
+	stopping := slices.Contains(reasons, ReasonObstacleStop) && !slices.Contains(l.reasons, ReasonObstacleStop)
+	l.reasons = reasons
+	cb, stop := l.onChange, l.onStop
+	l.mu.Unlock()
+
+	if stopping && stop != nil {
+		stop()
+	}
+	if changed && cb != nil {
+		cb(slices.Clone(reasons))
+	}
+}
+
+// angleDiff returns the absolute angular distance between a and b in [0, pi].
+func angleDiff(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 2*math.Pi)
+	if d > math.Pi {
+		d = 2*math.Pi - d
+	}
+	return d
+}
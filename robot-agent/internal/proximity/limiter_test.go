@@ -0,0 +1,182 @@
+package proximity
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func testLimiter(now *time.Time) *Limiter {
+	return newLimiterWithClock(Config{
+		StopDistance: 0.3,
+		SlowDistance: 1.3,
+		HalfArc:      math.Pi / 6,
+		MaxScanAge:   500 * time.Millisecond,
+	}, func() time.Time { return *now })
+}
+
+func scanAt(t time.Time, readings ...Reading) Scan {
+	return Scan{Readings: readings, Time: t}
+}
+
+func TestLimiter_NoObstacle(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := testLimiter(&now)
+	l.Update(scanAt(now, Reading{Angle: 0, Distance: 5}))
+
+	v, w, _ := l.FilterDrive(1.0, 0.5)
+	if v != 1.0 || w != 0.5 {
+		t.Errorf("expected unchanged command, got v=%f w=%f", v, w)
+	}
+	if len(l.Reasons()) != 0 {
+		t.Errorf("expected no limit reasons, got %v", l.Reasons())
+	}
+}
+
+func TestLimiter_ScalesForwardSpeed(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := testLimiter(&now)
+	l.Update(scanAt(now, Reading{Angle: 0.1, Distance: 0.8}))
+
+	v, _, _ := l.FilterDrive(1.0, 0)
+	if math.Abs(v-0.5) > 1e-9 {
+		t.Errorf("expected v=0.5 halfway through slow zone, got %f", v)
+	}
+	if !slices.Equal(l.Reasons(), []string{ReasonObstacleSlow}) {
+		t.Errorf("expected obstacle_slow, got %v", l.Reasons())
+	}
+}
+
+func TestLimiter_HardStopAllowsReverse(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := testLimiter(&now)
+	l.Update(scanAt(now, Reading{Angle: 0, Distance: 0.2}))
+
+	if v, _, _ := l.FilterDrive(0.5, 0); v != 0 {
+		t.Errorf("expected hard stop, got v=%f", v)
+	}
+	if !slices.Equal(l.Reasons(), []string{ReasonObstacleStop}) {
+		t.Errorf("expected obstacle_stop, got %v", l.Reasons())
+	}
+
+	if v, _, _ := l.FilterDrive(-0.5, 0); v != -0.5 {
+		t.Errorf("reversing away should be allowed, got v=%f", v)
+	}
+	if v, w, _ := l.FilterDrive(0, 1.0); v != 0 || w != 1.0 {
+		t.Errorf("turning in place should be allowed, got v=%f w=%f", v, w)
+	}
+}
+
+func TestLimiter_RearObstacleLimitsReverse(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := testLimiter(&now)
+	l.Update(scanAt(now, Reading{Angle: math.Pi, Distance: 0.1}))
+
+	if v, _, _ := l.FilterDrive(-0.5, 0); v != 0 {
+		t.Errorf("expected reverse blocked by rear obstacle, got v=%f", v)
+	}
+	if v, _, _ := l.FilterDrive(0.5, 0); v != 0.5 {
+		t.Errorf("forward should be unaffected by rear obstacle, got v=%f", v)
+	}
+}
+
+func TestLimiter_IgnoresOutOfArcAndInvalid(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := testLimiter(&now)
+	l.Update(scanAt(now,
+		Reading{Angle: math.Pi / 2, Distance: 0.1},
+		Reading{Angle: 0, Distance: math.Inf(1)},
+		Reading{Angle: 0, Distance: 0},
+		Reading{Angle: 0, Distance: math.NaN()},
+	))
+
+	if v, _, _ := l.FilterDrive(1.0, 0); v != 1.0 {
+		t.Errorf("side and invalid readings should be ignored, got v=%f", v)
+	}
+}
+
+func TestLimiter_StaleScanBlocksForward(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := testLimiter(&now)
+	l.Update(scanAt(now, Reading{Angle: 0, Distance: 5}))
+
+	now = now.Add(time.Second)
+	if v, _, _ := l.FilterDrive(1.0, 0); v != 0 {
+		t.Errorf("expected forward blocked on stale scan, got v=%f", v)
+	}
+	if !slices.Equal(l.Reasons(), []string{ReasonSensorStale}) {
+		t.Errorf("expected sensor_stale, got %v", l.Reasons())
+	}
+	if v, _, _ := l.FilterDrive(-1.0, 0); v != -1.0 {
+		t.Errorf("reverse should be allowed on stale scan, got v=%f", v)
+	}
+}
+
+func TestLimiter_CallbackOnChange(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := testLimiter(&now)
+
+	var changes [][]string
+	l.SetLimitCallback(func(reasons []string) { changes = append(changes, reasons) })
+
+	l.Update(scanAt(now, Reading{Angle: 0, Distance: 0.8}))
+	l.FilterDrive(1.0, 0)
+	l.FilterDrive(0.9, 0)
+	l.Update(scanAt(now, Reading{Angle: 0, Distance: 5}))
+	l.FilterDrive(1.0, 0)
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %v", changes)
+	}
+	if len(changes[1]) != 0 {
+		t.Errorf("expected cleared reasons, got %v", changes[1])
+	}
+}
+
+func TestLimiter_ObstacleStopOnScanUpdate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := testLimiter(&now)
+	stops := 0
+	l.SetStopFunc(func() { stops++ })
+
+	l.Update(scanAt(now, Reading{Angle: 0, Distance: 5}))
+	l.FilterDrive(0.5, 0)
+
+	// An obstacle appearing halts the robot without a new drive command
+	l.Update(scanAt(now, Reading{Angle: 0, Distance: 0.2}))
+	if stops != 1 {
+		t.Fatalf("expected immediate stop on scan update, got %d", stops)
+	}
+	if !slices.Equal(l.Reasons(), []string{ReasonObstacleStop}) {
+		t.Errorf("expected obstacle_stop, got %v", l.Reasons())
+	}
+
+	// Further commands stay blocked without halting again
+	if v, _, _ := l.FilterDrive(0.5, 0); v != 0 {
+		t.Errorf("expected forward blocked, got v=%f", v)
+	}
+	if stops != 1 {
+		t.Errorf("expected a single stop per obstacle, got %d", stops)
+	}
+
+	// Stopping does not clear the reason while the obstacle is still there
+	l.FilterDrive(0, 0)
+	if !slices.Equal(l.Reasons(), []string{ReasonObstacleStop}) {
+		t.Errorf("expected obstacle_stop kept while blocked, got %v", l.Reasons())
+	}
+
+	l.Update(scanAt(now, Reading{Angle: 0, Distance: 5}))
+	if len(l.Reasons()) != 0 {
+		t.Errorf("expected reasons cleared by a clear scan, got %v", l.Reasons())
+	}
+}
+
+func TestScanFromLaser(t *testing.T) {
+	now := time.Now()
+	scan := ScanFromLaser(-0.5, 0.5, []float64{1, 2, 3}, now)
+
+	if len(scan.Readings) != 3 || scan.Readings[2].Angle != 0.5 || scan.Readings[1].Distance != 2 {
+		t.Errorf("unexpected scan: %+v", scan)
+	}
+}
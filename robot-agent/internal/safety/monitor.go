@@ -10,11 +10,16 @@ import (
 type Trigger string
 
 const (
-	TriggerEStop         Trigger = "e_stop"
-	TriggerControlLoss   Trigger = "control_loss"
-	TriggerInvalidCmds   Trigger = "invalid_commands"
-	TriggerTokenExpired  Trigger = "token_expired"
-	TriggerRevoked       Trigger = "revoked"
+	TriggerEStop          Trigger = "e_stop"
+	TriggerControlLoss    Trigger = "control_loss"
+	TriggerInvalidCmds    Trigger = "invalid_commands"
+	TriggerTokenExpired   Trigger = "token_expired"
+	TriggerRevoked        Trigger = "revoked"
+	TriggerGeofence       Trigger = "geofence_breach"
+	TriggerShutdown       Trigger = "shutdown"
+	TriggerSessionEnded   Trigger = "session_ended"
+	TriggerSignalingLost  Trigger = "signaling_lost"
+	TriggerConnectionLost Trigger = "connection_lost"
 )
 
 // SafeStopCallback is the signature for safe-stop callbacks.
@@ -39,6 +44,9 @@ type Monitor struct {
 
 	// lastTransition stores the result of the most recent transition.
 	lastTransition TransitionResult
+
+	// triggerCounts counts the safe-stops each trigger fired; never reset.
+	triggerCounts map[Trigger]uint64
 }
 
 // NewMonitor creates a new safety monitor.
@@ -54,6 +62,7 @@ func NewMonitor(
 		invalidCmdTimeWindow: invalidCmdTimeWindow,
 		safeStopFn:           safeStopFn,
 		lastControlTime:      time.Now(),
+		triggerCounts:        make(map[Trigger]uint64),
 	}
 }
 
@@ -64,6 +73,34 @@ func (m *Monitor) LastTransition() TransitionResult {
 	return m.lastTransition
 }
 
+// TriggerCounts returns how many safe-stops each trigger has fired since
+// the monitor was created. Triggers that never fired are absent.
+func (m *Monitor) TriggerCounts() map[Trigger]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make(map[Trigger]uint64, len(m.triggerCounts))
+	for t, n := range m.triggerCounts {
+		counts[t] = n
+	}
+	return counts
+}
+
+// IsStopped returns true while a safe-stop is in effect.
+func (m *Monitor) IsStopped() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stopped
+}
+
+// InControlLoss returns true while the recoverable control-loss stop is in
+// effect.
+func (m *Monitor) InControlLoss() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inControlLoss
+}
+
 // OnValidControl should be called when a valid control message is received.
 // If in control loss state, this allows recovery.
 func (m *Monitor) OnValidControl() {
@@ -112,7 +149,6 @@ func (m *Monitor) OnEStop() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.inControlLoss = false // E-Stop is non-recoverable, clear any control loss state
 	m.triggerSafeStop(TriggerEStop)
 }
 
@@ -121,7 +157,6 @@ func (m *Monitor) OnTokenExpired() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.inControlLoss = false // Non-recoverable
 	m.triggerSafeStop(TriggerTokenExpired)
 }
 
@@ -130,10 +165,61 @@ func (m *Monitor) OnRevoked() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.inControlLoss = false // Non-recoverable
 	m.triggerSafeStop(TriggerRevoked)
 }
 
+// OnGeofenceBreach should be called when the robot is found outside its geofence.
+func (m *Monitor) OnGeofenceBreach() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.triggerSafeStop(TriggerGeofence)
+}
+
+// OnSessionEnded should be called when the console ends the session normally.
+func (m *Monitor) OnSessionEnded() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.triggerSafeStop(TriggerSessionEnded)
+}
+
+// OnSignalingLost should be called when the gateway link has been down for
+// too long: revocations could no longer reach the robot.
+func (m *Monitor) OnSignalingLost() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.triggerSafeStop(TriggerSignalingLost)
+}
+
+// OnConnectionLost should be called when a lost peer connection is given
+// up on. It escalates any control-loss ramp to a full stop.
+func (m *Monitor) OnConnectionLost() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.triggerSafeStop(TriggerConnectionLost)
+}
+
+// OnShutdown should be called when the agent is shutting down.
+func (m *Monitor) OnShutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.triggerSafeStop(TriggerShutdown)
+}
+
+// OnTransportLost should be called when the peer connection drops. It
+// enters the recoverable control-loss stop at once instead of waiting for
+// the control-loss timeout; control resuming after reconnection recovers.
+func (m *Monitor) OnTransportLost() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.triggerSafeStop(TriggerControlLoss)
+}
+
 // CheckControlLoss checks if control loss timeout has been exceeded.
 // Should be called periodically (e.g., every 100ms).
 func (m *Monitor) CheckControlLoss() {
@@ -146,7 +232,6 @@ func (m *Monitor) CheckControlLoss() {
 
 	elapsed := time.Since(m.lastControlTime)
 	if elapsed > m.controlLossTimeout {
-		m.inControlLoss = true
 		m.triggerSafeStop(TriggerControlLoss)
 	}
 }
@@ -165,11 +250,15 @@ func (m *Monitor) Reset() {
 
 // triggerSafeStop triggers safe-stop synchronously (must be called with lock held).
 // The callback is called directly to ensure <100ms transition time under load.
+// A recoverable control-loss stop only ramps down, so a non-recoverable
+// trigger escalates out of it to a full stop; any other stop is final.
 func (m *Monitor) triggerSafeStop(trigger Trigger) {
-	if m.stopped {
+	if m.stopped && (!m.inControlLoss || trigger.IsRecoverable()) {
 		return
 	}
 	m.stopped = true
+	m.inControlLoss = trigger.IsRecoverable()
+	m.triggerCounts[trigger]++
 
 	if m.safeStopFn != nil {
 		// Call synchronously - no goroutine to guarantee timing
@@ -116,3 +116,30 @@ func TestMonitor_NonRecoverableTriggers(t *testing.T) {
 		})
 	}
 }
+
+func TestMonitor_EscalatesFromControlLoss(t *testing.T) {
+	var triggers []Trigger
+	m := NewMonitor(time.Hour, 10, 0, func(trig Trigger) TransitionResult {
+		triggers = append(triggers, trig)
+		return TransitionResult{Trigger: trig, Timestamp: time.Now()}
+	})
+
+	m.OnTransportLost()
+	m.OnTransportLost() // Still in the same control-loss stop
+	m.OnRevoked()       // Must not be swallowed by the control-loss latch
+	m.OnShutdown()      // Already fully stopped
+
+	want := []Trigger{TriggerControlLoss, TriggerRevoked}
+	if len(triggers) != len(want) || triggers[0] != want[0] || triggers[1] != want[1] {
+		t.Fatalf("expected triggers %v, got %v", want, triggers)
+	}
+	if m.InControlLoss() {
+		t.Error("expected the escalated stop to be non-recoverable")
+	}
+
+	// Valid control no longer recovers
+	m.OnValidControl()
+	if !m.IsStopped() {
+		t.Error("expected escalated stop to persist")
+	}
+}
@@ -153,6 +153,36 @@ func TestMonitor_OnRevoked(t *testing.T) {
 	}
 }
 
+func TestMonitor_OnShutdown(t *testing.T) {
+	var triggered Trigger
+
+	m := NewMonitor(500*time.Millisecond, 10, 0, func(trig Trigger) TransitionResult {
+		triggered = trig
+		return TransitionResult{Trigger: trig, Timestamp: time.Now()}
+	})
+
+	m.OnShutdown()
+
+	if triggered != TriggerShutdown {
+		t.Errorf("expected TriggerShutdown, got %s", triggered)
+	}
+}
+
+func TestMonitor_OnSessionEnded(t *testing.T) {
+	var triggered Trigger
+
+	m := NewMonitor(500*time.Millisecond, 10, 0, func(trig Trigger) TransitionResult {
+		triggered = trig
+		return TransitionResult{Trigger: trig, Timestamp: time.Now()}
+	})
+
+	m.OnSessionEnded()
+
+	if triggered != TriggerSessionEnded {
+		t.Errorf("expected TriggerSessionEnded, got %s", triggered)
+	}
+}
+
 func TestMonitor_OnlyTriggersOnce(t *testing.T) {
 	triggerCount := 0
 	var mu sync.Mutex
@@ -218,3 +248,104 @@ func TestMonitor_NoCallbackIfNil(t *testing.T) {
 	m.OnValidControl()
 	m.CheckControlLoss()
 }
+
+func TestMonitor_GeofenceBreach(t *testing.T) {
+	var triggered []Trigger
+	m := NewMonitor(500*time.Millisecond, 10, 0, func(trig Trigger) TransitionResult {
+		triggered = append(triggered, trig)
+		return TransitionResult{Trigger: trig, Timestamp: time.Now()}
+	})
+
+	m.OnGeofenceBreach()
+	m.OnValidControl()
+	m.OnGeofenceBreach()
+
+	if len(triggered) != 1 || triggered[0] != TriggerGeofence {
+		t.Errorf("expected single non-recoverable geofence trigger, got %v", triggered)
+	}
+}
+
+func TestMonitor_OnTransportLost_Recoverable(t *testing.T) {
+	var triggered Trigger
+
+	m := NewMonitor(500*time.Millisecond, 10, 0, func(trig Trigger) TransitionResult {
+		triggered = trig
+		return TransitionResult{Trigger: trig, Timestamp: time.Now()}
+	})
+
+	m.OnTransportLost()
+	if triggered != TriggerControlLoss {
+		t.Fatalf("expected TriggerControlLoss, got %s", triggered)
+	}
+
+	// Control resuming after reconnection clears the stop
+	m.OnValidControl()
+	triggered = ""
+	m.OnEStop()
+	if triggered != TriggerEStop {
+		t.Errorf("expected monitor to recover and trigger e-stop, got %q", triggered)
+	}
+}
+
+func TestMonitor_OnTransportLost_KeepsNonRecoverableStop(t *testing.T) {
+	m := NewMonitor(500*time.Millisecond, 10, 0, func(trig Trigger) TransitionResult {
+		return TransitionResult{Trigger: trig, Timestamp: time.Now()}
+	})
+
+	m.OnEStop()
+	m.OnTransportLost()
+	m.OnValidControl()
+
+	if got := m.LastTransition().Trigger; got != TriggerEStop {
+		t.Errorf("expected e-stop to remain latched, got %s", got)
+	}
+	m.OnInvalidCommand()
+	if got := m.LastTransition().Trigger; got != TriggerEStop {
+		t.Errorf("e-stop must not be cleared by transport recovery, got %s", got)
+	}
+}
+
+func TestMonitor_OnSignalingLost(t *testing.T) {
+	var triggered Trigger
+
+	m := NewMonitor(500*time.Millisecond, 10, 0, func(trig Trigger) TransitionResult {
+		triggered = trig
+		return TransitionResult{Trigger: trig, Timestamp: time.Now()}
+	})
+
+	m.OnSignalingLost()
+
+	if triggered != TriggerSignalingLost {
+		t.Errorf("expected TriggerSignalingLost, got %s", triggered)
+	}
+	if TriggerSignalingLost.Priority() != PrioritySecurity || TriggerSignalingLost.IsRecoverable() {
+		t.Error("signaling loss must be a non-recoverable security trigger")
+	}
+}
+
+func TestMonitor_TriggerCounts(t *testing.T) {
+	m := NewMonitor(time.Hour, 10, 0, func(trig Trigger) TransitionResult {
+		return TransitionResult{Trigger: trig, Timestamp: time.Now()}
+	})
+
+	m.OnEStop()
+	m.OnRevoked() // Already stopped: not a new safe-stop
+	if !m.IsStopped() || m.InControlLoss() {
+		t.Error("expected non-recoverable stop")
+	}
+	m.Reset()
+	m.OnTransportLost()
+	if !m.InControlLoss() {
+		t.Error("expected control loss")
+	}
+	m.Reset()
+	m.OnEStop()
+
+	counts := m.TriggerCounts()
+	if counts[TriggerEStop] != 2 || counts[TriggerControlLoss] != 1 {
+		t.Errorf("unexpected trigger counts: %v", counts)
+	}
+	if _, ok := counts[TriggerRevoked]; ok {
+		t.Errorf("expected no revoked count, got %v", counts)
+	}
+}
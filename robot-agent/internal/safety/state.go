@@ -25,6 +25,7 @@ const (
 	EventInvalidThreshold Event = "invalid_threshold"
 	EventTokenExpired     Event = "token_expired"
 	EventRevoked          Event = "revoked"
+	EventGeofenceBreach   Event = "geofence_breach"
 	EventReset            Event = "reset"
 )
 
@@ -121,7 +122,7 @@ func (sm *StateMachine) isValidTransition(state State, event Event) bool {
 	case StateActive:
 		switch event {
 		case EventEStop, EventControlLoss, EventInvalidThreshold,
-			EventTokenExpired, EventRevoked:
+			EventTokenExpired, EventRevoked, EventGeofenceBreach:
 			return true
 		}
 		return false
@@ -145,7 +146,7 @@ func (sm *StateMachine) getNextState(state State, event Event) State {
 	case StateActive:
 		switch event {
 		case EventEStop, EventControlLoss, EventInvalidThreshold,
-			EventTokenExpired, EventRevoked:
+			EventTokenExpired, EventRevoked, EventGeofenceBreach:
 			return StateSafeStop
 		}
 
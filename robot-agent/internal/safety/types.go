@@ -11,11 +11,15 @@ const (
 	// PriorityCritical is for human-initiated emergencies (E-Stop).
 	PriorityCritical TriggerPriority = 1
 
-	// PrioritySecurity is for security-related triggers (revocation, expiry).
+	// PrioritySecurity is for security-related triggers (revocation, expiry,
+	// loss of the revocation channel).
 	PrioritySecurity TriggerPriority = 2
 
 	// PriorityOperational is for operational triggers (control loss, invalid cmds).
 	PriorityOperational TriggerPriority = 3
+
+	// PriorityRoutine is for planned session ends (operator hang-up).
+	PriorityRoutine TriggerPriority = 4
 )
 
 // Priority returns the priority level for a trigger.
@@ -23,8 +27,10 @@ func (t Trigger) Priority() TriggerPriority {
 	switch t {
 	case TriggerEStop:
 		return PriorityCritical
-	case TriggerRevoked, TriggerTokenExpired:
+	case TriggerRevoked, TriggerTokenExpired, TriggerSignalingLost:
 		return PrioritySecurity
+	case TriggerSessionEnded:
+		return PriorityRoutine
 	default:
 		return PriorityOperational
 	}
@@ -15,6 +15,9 @@ func TestTriggerPriority(t *testing.T) {
 		{TriggerTokenExpired, PrioritySecurity},
 		{TriggerControlLoss, PriorityOperational},
 		{TriggerInvalidCmds, PriorityOperational},
+		{TriggerGeofence, PriorityOperational},
+		{TriggerShutdown, PriorityOperational},
+		{TriggerSessionEnded, PriorityRoutine},
 	}
 
 	for _, tt := range tests {
@@ -34,6 +37,9 @@ func TestTriggerPriority_CriticalHighest(t *testing.T) {
 	if PrioritySecurity >= PriorityOperational {
 		t.Error("Security priority should be numerically lower than operational")
 	}
+	if PriorityOperational >= PriorityRoutine {
+		t.Error("Operational priority should be numerically lower than routine")
+	}
 }
 
 func TestTriggerIsRecoverable(t *testing.T) {
@@ -46,6 +52,9 @@ func TestTriggerIsRecoverable(t *testing.T) {
 		{TriggerTokenExpired, false},
 		{TriggerControlLoss, true},
 		{TriggerInvalidCmds, false},
+		{TriggerGeofence, false},
+		{TriggerShutdown, false},
+		{TriggerSessionEnded, false},
 	}
 
 	for _, tt := range tests {
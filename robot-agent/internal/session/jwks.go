@@ -52,6 +52,11 @@ func NewJWKSFetcher(url string, cacheTTL time.Duration) *JWKSFetcher {
 	}
 }
 
+// SetHTTPClient sets the HTTP client used to fetch the JWKS (e.g. for mTLS).
+func (f *JWKSFetcher) SetHTTPClient(client *http.Client) {
+	f.httpClient = client
+}
+
 // GetPublicKey returns the public key for the given key ID.
 func (f *JWKSFetcher) GetPublicKey(kid string) (ed25519.PublicKey, error) {
 	f.mu.RLock()
@@ -76,6 +81,15 @@ func (f *JWKSFetcher) GetPublicKey(kid string) (ed25519.PublicKey, error) {
 	return key, nil
 }
 
+// CachedPublicKey returns the key for kid from the last successful fetch,
+// without fetching.
+func (f *JWKSFetcher) CachedPublicKey(kid string) (ed25519.PublicKey, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	key, ok := f.cache[kid]
+	return key, ok
+}
+
 // Refresh fetches the latest JWKS from the server.
 func (f *JWKSFetcher) Refresh() error {
 	resp, err := f.httpClient.Get(f.url)
@@ -97,6 +111,14 @@ func (f *JWKSFetcher) Refresh() error {
 	return nil
 }
 
+// LastFetch returns when the JWKS was last fetched successfully, or the
+// zero time if it never was.
+func (f *JWKSFetcher) LastFetch() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.lastFetch
+}
+
 // updateCache parses JWKs and updates the cache.
 func (f *JWKSFetcher) updateCache(jwks jwksResponse) {
 	newCache := make(map[string]ed25519.PublicKey)
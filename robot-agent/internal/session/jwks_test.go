@@ -206,3 +206,27 @@ func TestJWKSFetcher_Refresh(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, pub2, gotPub2)
 }
+
+func TestJWKSFetcher_LastFetch(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(createTestJWKS(t, pub, "key-1")))
+	}))
+	defer server.Close()
+
+	fetcher := NewJWKSFetcher(server.URL, time.Minute)
+	assert.Error(t, fetcher.Refresh())
+	assert.True(t, fetcher.LastFetch().IsZero(), "failed fetch must not count as fresh")
+
+	fail = false
+	before := time.Now()
+	require.NoError(t, fetcher.Refresh())
+	assert.False(t, fetcher.LastFetch().Before(before))
+}
@@ -32,6 +32,7 @@ type Info struct {
 	RobotID     string
 	Scope       []string
 	ExpiresAt   time.Time
+	MaxSpeed    float64
 }
 
 // Manager handles session lifecycle.
@@ -59,6 +60,25 @@ func NewManager(robotID string, validator *TokenValidator) *Manager {
 	}
 }
 
+// SetValidator replaces the token validator, e.g. when the gateway signing
+// key becomes available or rotates. Tokens validated with the previous
+// validator are no longer served from the cache.
+func (m *Manager) SetValidator(v *TokenValidator) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validator = v
+	if m.tokenCache != nil {
+		m.tokenCache = NewTokenCache(DefaultTokenCacheTTL)
+	}
+}
+
+// Validator returns the current token validator, or nil if none is set.
+func (m *Manager) Validator() *TokenValidator {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.validator
+}
+
 // SetStateChangeCallback sets a callback for state transitions.
 func (m *Manager) SetStateChangeCallback(fn func(State)) {
 	m.mu.Lock()
@@ -96,7 +116,20 @@ func (m *Manager) ValidateToken(sessionID, token string) (*Info, error) {
 	if m.state == StateTerminated {
 		return nil, ErrSessionTerminated
 	}
+	return m.validateLocked(sessionID, token)
+}
+
+// ValidateNewSession validates the token of an offer for a new session.
+// Unlike ValidateToken it does not require a previous session to have been
+// reset first, so an offer can be rejected before any state is touched.
+func (m *Manager) ValidateNewSession(sessionID, token string) (*Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.validateLocked(sessionID, token)
+}
 
+// validateLocked validates token for sessionID (caller must hold mu).
+func (m *Manager) validateLocked(sessionID, token string) (*Info, error) {
 	if m.validator == nil {
 		return nil, ErrInvalidToken
 	}
@@ -131,6 +164,7 @@ func (m *Manager) claimsToInfo(claims *TokenClaims) *Info {
 		RobotID:     m.robotID,
 		Scope:       claims.Scope,
 		ExpiresAt:   claims.ExpiresAt,
+		MaxSpeed:    claims.MaxSpeed,
 	}
 }
 
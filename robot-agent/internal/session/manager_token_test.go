@@ -468,3 +468,62 @@ func BenchmarkManager_ValidateToken_Cached(b *testing.B) {
 		mgr.ValidateToken(sessionID, tokenStr)
 	}
 }
+
+func TestManager_ValidateNewSession_AfterTerminate(t *testing.T) {
+	pub, priv := testKeyPair(t)
+	robotID := "robot-001"
+
+	validator := NewTokenValidator(pub, robotID, 30*time.Second)
+	mgr := NewManager(robotID, validator)
+	require.NoError(t, mgr.Activate(&Info{SessionID: "session-old"}))
+	mgr.Terminate()
+
+	token := createTestToken(t, priv, jwt.MapClaims{
+		"jti":   "token-new-session",
+		"sub":   "did:key:operator",
+		"aud":   robotID,
+		"sid":   "session-new",
+		"scope": []any{"teleop:control"},
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(1 * time.Hour).Unix(),
+	})
+
+	// A new offer is validated before the terminated state is reset
+	info, err := mgr.ValidateNewSession("session-new", token)
+	require.NoError(t, err)
+	assert.Equal(t, "session-new", info.SessionID)
+	assert.Equal(t, StateTerminated, mgr.State(), "validation must not change state")
+
+	_, err = mgr.ValidateNewSession("session-new", "forged")
+	assert.Error(t, err)
+}
+
+func TestManager_SetValidator(t *testing.T) {
+	pub, priv := testKeyPair(t)
+	robotID := "robot-001"
+	mgr := NewManager(robotID, nil)
+
+	token := createTestToken(t, priv, jwt.MapClaims{
+		"jti":   "token-late-key",
+		"sub":   "did:key:operator",
+		"aud":   robotID,
+		"sid":   "session-123",
+		"scope": []any{"teleop:control"},
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(1 * time.Hour).Unix(),
+	})
+
+	// No signing key at startup
+	_, err := mgr.ValidateToken("session-123", token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	mgr.SetValidator(NewTokenValidator(pub, robotID, 30*time.Second))
+	_, err = mgr.ValidateToken("session-123", token)
+	require.NoError(t, err)
+
+	// After rotation, tokens cached under the old key are re-validated
+	rotated, _ := testKeyPair(t)
+	mgr.SetValidator(NewTokenValidator(rotated, robotID, 30*time.Second))
+	_, err = mgr.ValidateToken("session-123", token)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
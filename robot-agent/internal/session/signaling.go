@@ -4,35 +4,68 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"math/rand/v2"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/identity"
+)
+
+// Reconnect and keepalive defaults.
+const (
+	defaultInitialBackoff    = 1 * time.Second
+	defaultMaxBackoff        = 30 * time.Second
+	defaultKeepaliveInterval = 10 * time.Second
+	keepaliveMisses          = 3 // Pings without any reply before the connection is dropped
+	joinTokenTTL             = time.Minute
 )
 
-// SignalingClient manages WebSocket connection to Gateway.
+// SignalingClient manages WebSocket connection to Gateway. After the first
+// connection it reconnects on its own until closed.
 type SignalingClient struct {
 	mu      sync.Mutex
 	url     string
 	robotID string
 	logger  *zap.Logger
 
-	conn    *websocket.Conn
-	handler SignalingHandler
-	stopCh  chan struct{}
-	done    chan struct{}
+	conn          *websocket.Conn
+	writer        *connWriter
+	dialer        *websocket.Dialer
+	identity      *identity.Identity
+	handler       SignalingHandler
+	activeSession func() string
+	onConnChange  func(connected bool)
+
+	iceMu       sync.Mutex
+	ice         iceSequencer
+	iceBatching bool
+
+	initialBackoff    time.Duration
+	maxBackoff        time.Duration
+	keepaliveInterval time.Duration
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	doneOnce sync.Once
 }
 
 // NewSignalingClient creates a new signaling client.
 func NewSignalingClient(url, robotID string, logger *zap.Logger) *SignalingClient {
 	return &SignalingClient{
-		url:     url,
-		robotID: robotID,
-		logger:  logger,
-		stopCh:  make(chan struct{}),
-		done:    make(chan struct{}),
+		url:               url,
+		robotID:           robotID,
+		logger:            logger,
+		dialer:            websocket.DefaultDialer,
+		initialBackoff:    defaultInitialBackoff,
+		maxBackoff:        defaultMaxBackoff,
+		keepaliveInterval: defaultKeepaliveInterval,
+		stopCh:            make(chan struct{}),
+		done:              make(chan struct{}),
 	}
 }
 
@@ -43,7 +76,67 @@ func (c *SignalingClient) SetHandler(h SignalingHandler) {
 	c.handler = h
 }
 
-// Connect establishes WebSocket connection with retry.
+// SetDialer sets the WebSocket dialer (e.g. for mTLS). Must be called
+// before Connect.
+func (c *SignalingClient) SetDialer(d *websocket.Dialer) {
+	c.dialer = d
+}
+
+// SetIdentity makes the client prove the robot's identity to the gateway
+// with a signed join token in the WebSocket upgrade request.
+func (c *SignalingClient) SetIdentity(id *identity.Identity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.identity = id
+}
+
+// SetActiveSessionFunc sets the source of the session ID carried in join
+// messages, so a reconnect rejoins the session in progress.
+func (c *SignalingClient) SetActiveSessionFunc(fn func() string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.activeSession = fn
+}
+
+// SetConnectionCallback sets a callback invoked when the gateway
+// connection is established or lost.
+func (c *SignalingClient) SetConnectionCallback(fn func(connected bool)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onConnChange = fn
+}
+
+// SetBackoff sets the reconnect backoff bounds. Must be called before Connect.
+func (c *SignalingClient) SetBackoff(initial, maxBackoff time.Duration) {
+	c.initialBackoff = initial
+	c.maxBackoff = maxBackoff
+}
+
+// SetKeepaliveInterval sets how often pings are sent (0 disables
+// keepalive). The connection is dropped when nothing arrives for
+// keepaliveMisses intervals. Must be called before Connect.
+func (c *SignalingClient) SetKeepaliveInterval(d time.Duration) {
+	c.keepaliveInterval = d
+}
+
+// SetICEBatching makes the client send local candidates gathered within a
+// short window as one ice message with a "candidates" array. It is off by
+// default since the gateway and console must understand that field. Must
+// be called before any candidate is sent.
+func (c *SignalingClient) SetICEBatching(enabled bool) {
+	c.iceBatching = enabled
+}
+
+// Connected reports whether the gateway connection is currently up.
+func (c *SignalingClient) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Connect establishes WebSocket connection with retry. Once connected, the
+// client keeps reconnecting after connection loss until ctx ends or Close
+// is called.
 func (c *SignalingClient) Connect(ctx context.Context) error {
 	c.mu.Lock()
 	if c.conn != nil {
@@ -52,77 +145,185 @@ func (c *SignalingClient) Connect(ctx context.Context) error {
 	}
 	c.mu.Unlock()
 
-	return c.connectWithRetry(ctx)
+	conn, err := c.connectWithRetry(ctx)
+	if err != nil {
+		return err
+	}
+
+	go c.serve(ctx, conn)
+	return nil
 }
 
-func (c *SignalingClient) connectWithRetry(ctx context.Context) error {
-	backoff := 1 * time.Second
-	maxBackoff := 30 * time.Second
+// serve reads from conn and reconnects whenever the connection drops.
+func (c *SignalingClient) serve(ctx context.Context, conn *websocket.Conn) {
+	defer c.doneOnce.Do(func() { close(c.done) })
+
+	for {
+		c.readLoop(conn)
+
+		if !c.dropConn(conn) {
+			return // Closed
+		}
+		c.notifyConnection(false)
+
+		var err error
+		if conn, err = c.connectWithRetry(ctx); err != nil {
+			return
+		}
+	}
+}
+
+func (c *SignalingClient) connectWithRetry(ctx context.Context) (*websocket.Conn, error) {
+	backoff := c.initialBackoff
 
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return nil, ctx.Err()
 		case <-c.stopCh:
-			return ErrConnClosed
+			return nil, ErrConnClosed
 		default:
 		}
 
 		c.logger.Info("connecting to gateway", zap.String("url", c.url))
 
-		header := http.Header{}
-		conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.url, header)
+		conn, err := c.dial(ctx)
 		if err != nil {
+			wait := jitter(backoff)
 			c.logger.Warn("connection failed, retrying",
 				zap.Error(err),
-				zap.Duration("backoff", backoff))
+				zap.Duration("backoff", wait))
 
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
+				return nil, ctx.Err()
 			case <-c.stopCh:
-				return ErrConnClosed
-			case <-time.After(backoff):
+				return nil, ErrConnClosed
+			case <-time.After(wait):
 			}
 
-			backoff = min(backoff*2, maxBackoff)
+			backoff = min(backoff*2, c.maxBackoff)
 			continue
 		}
 
-		c.mu.Lock()
-		c.conn = conn
+		c.logger.Info("connected to gateway")
+		c.notifyConnection(true)
+		return conn, nil
+	}
+}
+
+// dial opens a connection and joins, rejoining any active session.
+func (c *SignalingClient) dial(ctx context.Context) (*websocket.Conn, error) {
+	header, err := c.authHeader()
+	if err != nil {
+		return nil, err
+	}
+	conn, _, err := c.dialer.DialContext(ctx, c.url, header)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	select {
+	case <-c.stopCh:
 		c.mu.Unlock()
+		conn.Close()
+		return nil, ErrConnClosed
+	default:
+	}
+	c.conn = conn
+	c.writer = newConnWriter(conn, c.logger)
+	c.mu.Unlock()
 
-		c.logger.Info("connected to gateway")
+	if err := c.sendJoin(); err != nil {
+		c.dropConn(conn)
+		return nil, err
+	}
+	return conn, nil
+}
 
-		// Send join message
-		if err := c.sendJoin(); err != nil {
-			c.logger.Error("failed to send join", zap.Error(err))
-			conn.Close()
-			continue
-		}
+// authHeader returns upgrade headers carrying a fresh join token, so a
+// captured token cannot be replayed after it expires.
+func (c *SignalingClient) authHeader() (http.Header, error) {
+	header := http.Header{}
 
-		// Start read loop
-		go c.readLoop()
+	c.mu.Lock()
+	id := c.identity
+	c.mu.Unlock()
+	if id == nil {
+		return header, nil
+	}
 
-		return nil
+	token, err := id.JoinToken(c.robotID, c.url, joinTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	header.Set("Authorization", "Bearer "+token)
+	return header, nil
+}
+
+// jitter spreads reconnects of many robots after a gateway restart by
+// waiting between half and all of the backoff.
+func jitter(backoff time.Duration) time.Duration {
+	half := backoff / 2
+	return half + rand.N(half+1)
+}
+
+// dropConn closes conn and reports whether the client should reconnect.
+func (c *SignalingClient) dropConn(conn *websocket.Conn) bool {
+	c.mu.Lock()
+	var writer *connWriter
+	if c.conn == conn {
+		writer = c.writer
+		c.conn, c.writer = nil, nil
+	}
+	c.mu.Unlock()
+	conn.Close()
+	if writer != nil {
+		writer.close()
+	}
+
+	select {
+	case <-c.stopCh:
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *SignalingClient) notifyConnection(connected bool) {
+	c.mu.Lock()
+	fn := c.onConnChange
+	c.mu.Unlock()
+
+	if fn != nil {
+		fn(connected)
 	}
 }
 
 func (c *SignalingClient) sendJoin() error {
+	c.mu.Lock()
+	activeSession := c.activeSession
+	c.mu.Unlock()
+
 	msg := SignalMessage{
 		Type:    SignalJoin,
 		RobotID: c.robotID,
 	}
+	if activeSession != nil {
+		msg.SessionID = activeSession()
+	}
 	return c.send(msg)
 }
 
+// send queues msg for the connection's writer. It does not wait for the
+// write; a failed write drops the connection.
 func (c *SignalingClient) send(msg SignalMessage) error {
 	c.mu.Lock()
-	conn := c.conn
+	writer := c.writer
 	c.mu.Unlock()
 
-	if conn == nil {
+	if writer == nil {
 		return ErrNotConnected
 	}
 
@@ -130,20 +331,26 @@ func (c *SignalingClient) send(msg SignalMessage) error {
 	if err != nil {
 		return err
 	}
-
-	return conn.WriteMessage(websocket.TextMessage, data)
+	return writer.enqueue(data)
 }
 
-func (c *SignalingClient) readLoop() {
-	defer func() {
-		close(c.done)
-	}()
-
-	c.mu.Lock()
-	conn := c.conn
-	c.mu.Unlock()
+// readLoop handles messages from conn until it fails or goes silent.
+func (c *SignalingClient) readLoop(conn *websocket.Conn) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	timeout := c.keepaliveInterval * keepaliveMisses
+	if c.keepaliveInterval > 0 {
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(timeout))
+		})
+		go c.keepalive(conn, stop)
+	}
 
 	for {
+		if timeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(timeout))
+		}
 		_, data, err := conn.ReadMessage()
 		if err != nil {
 			c.logger.Warn("read error", zap.Error(err))
@@ -160,6 +367,26 @@ func (c *SignalingClient) readLoop() {
 	}
 }
 
+// keepalive sends WebSocket ping frames so a silently dead connection is
+// detected by the read deadline. Control frames need no support in the
+// gateway's message protocol. WriteControl may be called concurrently
+// with the connection's writer.
+func (c *SignalingClient) keepalive(conn *websocket.Conn, stop chan struct{}) {
+	ticker := time.NewTicker(c.keepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				c.logger.Debug("keepalive ping failed", zap.Error(err))
+			}
+		}
+	}
+}
+
 func (c *SignalingClient) handleMessage(msg SignalMessage) {
 	c.mu.Lock()
 	handler := c.handler
@@ -176,9 +403,14 @@ func (c *SignalingClient) handleMessage(msg SignalMessage) {
 	case SignalAnswer:
 		handler.OnAnswer(msg.SessionID, msg.Payload)
 	case SignalICE:
-		handler.OnICE(msg.SessionID, msg.Payload)
+		if len(msg.Candidates) == 0 {
+			handler.OnICE(msg.SessionID, msg.Payload)
+		}
+		for _, candidate := range msg.Candidates {
+			handler.OnICE(msg.SessionID, candidate)
+		}
 	case SignalBye:
-		handler.OnBye(msg.SessionID)
+		handler.OnBye(msg.SessionID, msg.Reason)
 	case SignalRevoked:
 		handler.OnRevoked(msg.SessionID, msg.Reason)
 	case SignalError:
@@ -186,40 +418,71 @@ func (c *SignalingClient) handleMessage(msg SignalMessage) {
 	}
 }
 
-// SendAnswer sends an SDP answer to the gateway.
+// SendAnswer sends an SDP answer to the gateway, followed by any local
+// candidates gathered before it.
 func (c *SignalingClient) SendAnswer(sessionID string, sdp []byte) error {
-	return c.send(SignalMessage{
+	if err := c.send(SignalMessage{
 		Type:      SignalAnswer,
 		SessionID: sessionID,
 		Payload:   sdp,
-	})
+	}); err != nil {
+		return err
+	}
+	c.releaseICE(sessionID)
+	return nil
 }
 
-// SendICE sends an ICE candidate to the gateway.
+// SendICE queues a local ICE candidate for the gateway. Candidates are held
+// until the session's answer is sent, and batched over a short window if
+// enabled; delivery failures are logged.
 func (c *SignalingClient) SendICE(sessionID string, candidate []byte) error {
+	if !c.Connected() {
+		return ErrNotConnected
+	}
+	c.queueICE(sessionID, candidate)
+	return nil
+}
+
+// SendError reports a failed request for sessionID to the gateway.
+func (c *SignalingClient) SendError(sessionID, message string) error {
 	return c.send(SignalMessage{
-		Type:      SignalICE,
+		Type:      SignalError,
 		SessionID: sessionID,
-		Payload:   candidate,
+		Error:     message,
 	})
 }
 
-// Close closes the signaling connection.
+// SendBye tells the gateway the robot is ending the session.
+func (c *SignalingClient) SendBye(sessionID, reason string) error {
+	return c.send(SignalMessage{
+		Type:      SignalBye,
+		SessionID: sessionID,
+		Reason:    reason,
+	})
+}
+
+// Close delivers already queued messages, such as a final bye, then
+// closes the signaling connection and stops reconnecting.
 func (c *SignalingClient) Close() error {
-	close(c.stopCh)
+	c.stopOnce.Do(func() { close(c.stopCh) })
+	c.stopICEBatch()
 
 	c.mu.Lock()
-	conn := c.conn
-	c.conn = nil
+	conn, writer := c.conn, c.writer
+	c.conn, c.writer = nil, nil
 	c.mu.Unlock()
 
+	if writer != nil {
+		writer.close()
+	}
 	if conn != nil {
 		return conn.Close()
 	}
 	return nil
 }
 
-// Done returns a channel that closes when the connection ends.
+// Done returns a channel that closes when the client stops for good:
+// after Close, or when the Connect context ends.
 func (c *SignalingClient) Done() <-chan struct{} {
 	return c.done
 }
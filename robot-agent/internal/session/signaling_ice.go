@@ -0,0 +1,128 @@
+package session
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	iceBatchWindow = 20 * time.Millisecond // How long to gather candidates into one message
+	maxICEBatch    = 16
+)
+
+// iceSequencer holds local candidates until the answer they belong to is
+// queued, since the gateway must relay the answer first, and optionally
+// batches them to cut per-candidate messages during gathering.
+type iceSequencer struct {
+	sessionID string
+	answered  bool
+	pending   []json.RawMessage
+	timer     *time.Timer
+}
+
+// ExpectAnswer holds local candidates for sessionID until its answer is
+// sent. Call it once an offer is accepted, including renegotiations and ICE
+// restarts, and before the peer connection starts gathering. Candidates
+// held for another session are discarded.
+func (c *SignalingClient) ExpectAnswer(sessionID string) {
+	c.iceMu.Lock()
+	defer c.iceMu.Unlock()
+
+	c.stopICEBatchLocked()
+	c.ice = iceSequencer{sessionID: sessionID}
+}
+
+// releaseICE sends candidates held for sessionID now that its answer is
+// queued ahead of them.
+func (c *SignalingClient) releaseICE(sessionID string) {
+	c.iceMu.Lock()
+	if c.ice.sessionID != sessionID {
+		c.ice = iceSequencer{sessionID: sessionID}
+	}
+	c.ice.answered = true
+	batch := c.takeICEBatchLocked()
+	c.iceMu.Unlock()
+
+	c.sendICEBatch(sessionID, batch)
+}
+
+// queueICE adds a local candidate to the current batch.
+func (c *SignalingClient) queueICE(sessionID string, candidate []byte) {
+	c.iceMu.Lock()
+	if c.ice.sessionID != sessionID {
+		// No offer seen for this session: nothing to wait for
+		c.stopICEBatchLocked()
+		c.ice = iceSequencer{sessionID: sessionID, answered: true}
+	}
+	c.ice.pending = append(c.ice.pending, json.RawMessage(candidate))
+
+	var batch []json.RawMessage
+	switch {
+	case !c.ice.answered:
+	case !c.iceBatching || len(c.ice.pending) >= maxICEBatch:
+		batch = c.takeICEBatchLocked()
+	case c.ice.timer == nil:
+		c.ice.timer = time.AfterFunc(iceBatchWindow, func() { c.flushICE(sessionID) })
+	}
+	c.iceMu.Unlock()
+
+	c.sendICEBatch(sessionID, batch)
+}
+
+func (c *SignalingClient) flushICE(sessionID string) {
+	c.iceMu.Lock()
+	var batch []json.RawMessage
+	if c.ice.sessionID == sessionID && c.ice.answered {
+		batch = c.takeICEBatchLocked()
+	}
+	c.iceMu.Unlock()
+
+	c.sendICEBatch(sessionID, batch)
+}
+
+func (c *SignalingClient) takeICEBatchLocked() []json.RawMessage {
+	c.stopICEBatchLocked()
+	batch := c.ice.pending
+	c.ice.pending = nil
+	return batch
+}
+
+func (c *SignalingClient) stopICEBatch() {
+	c.iceMu.Lock()
+	defer c.iceMu.Unlock()
+	c.stopICEBatchLocked()
+}
+
+func (c *SignalingClient) stopICEBatchLocked() {
+	if c.ice.timer != nil {
+		c.ice.timer.Stop()
+		c.ice.timer = nil
+	}
+}
+
+// sendICEBatch sends candidates as one ice message if batching is enabled,
+// or one message each otherwise. A single candidate always uses the
+// payload field.
+func (c *SignalingClient) sendICEBatch(sessionID string, batch []json.RawMessage) {
+	if len(batch) == 0 {
+		return
+	}
+	if !c.iceBatching || len(batch) == 1 {
+		for _, candidate := range batch {
+			c.sendICE(SignalMessage{Type: SignalICE, SessionID: sessionID, Payload: candidate}, 1)
+		}
+		return
+	}
+	c.sendICE(SignalMessage{Type: SignalICE, SessionID: sessionID, Candidates: batch}, len(batch))
+}
+
+func (c *SignalingClient) sendICE(msg SignalMessage, count int) {
+	if err := c.send(msg); err != nil {
+		c.logger.Warn("failed to send ICE candidates",
+			zap.String("session_id", msg.SessionID),
+			zap.Int("count", count),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,237 @@
+package session
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/datapilot/chainkvm/robot-agent/internal/identity"
+)
+
+// testGateway is a local signaling server that hands each accepted
+// connection to serveConn.
+type testGateway struct {
+	server  *httptest.Server
+	conns   chan *websocket.Conn
+	headers chan http.Header
+}
+
+func newTestGateway(t *testing.T) *testGateway {
+	t.Helper()
+	g := &testGateway{
+		conns:   make(chan *websocket.Conn, 8),
+		headers: make(chan http.Header, 8),
+	}
+	upgrader := websocket.Upgrader{}
+	g.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.headers <- r.Header.Clone()
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		g.conns <- conn
+	}))
+	t.Cleanup(g.server.Close)
+	return g
+}
+
+func (g *testGateway) url() string {
+	return "ws" + strings.TrimPrefix(g.server.URL, "http")
+}
+
+// accept waits for the next client connection.
+func (g *testGateway) accept(t *testing.T) *websocket.Conn {
+	t.Helper()
+	select {
+	case conn := <-g.conns:
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for connection")
+		return nil
+	}
+}
+
+func readSignal(t *testing.T, conn *websocket.Conn) SignalMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg SignalMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return msg
+}
+
+func newTestClient(t *testing.T, g *testGateway, keepalive time.Duration) (*SignalingClient, chan bool) {
+	t.Helper()
+	return newIdentifiedTestClient(t, g, keepalive, nil)
+}
+
+func newIdentifiedTestClient(t *testing.T, g *testGateway, keepalive time.Duration, id *identity.Identity) (*SignalingClient, chan bool) {
+	t.Helper()
+	c := NewSignalingClient(g.url(), "robot-1", zap.NewNop())
+	c.SetIdentity(id)
+	c.SetBackoff(10*time.Millisecond, 20*time.Millisecond)
+	c.SetKeepaliveInterval(keepalive)
+	c.SetActiveSessionFunc(func() string { return "ses_1" })
+
+	events := make(chan bool, 8)
+	c.SetConnectionCallback(func(connected bool) { events <- connected })
+
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, events
+}
+
+func expectConnection(t *testing.T, events chan bool, want bool) {
+	t.Helper()
+	select {
+	case got := <-events:
+		if got != want {
+			t.Fatalf("connection callback = %v, want %v", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for connection callback %v", want)
+	}
+}
+
+func TestSignalingClient_ReconnectsAndRejoins(t *testing.T) {
+	g := newTestGateway(t)
+	c, events := newTestClient(t, g, 0)
+
+	first := g.accept(t)
+	if msg := readSignal(t, first); msg.Type != SignalJoin || msg.SessionID != "ses_1" {
+		t.Fatalf("unexpected join: %+v", msg)
+	}
+	expectConnection(t, events, true)
+
+	// Gateway restart
+	first.Close()
+	expectConnection(t, events, false)
+
+	second := g.accept(t)
+	msg := readSignal(t, second)
+	if msg.Type != SignalJoin || msg.RobotID != "robot-1" || msg.SessionID != "ses_1" {
+		t.Fatalf("expected rejoin of active session, got %+v", msg)
+	}
+	expectConnection(t, events, true)
+
+	if !c.Connected() {
+		t.Error("expected client to report connected")
+	}
+	select {
+	case <-c.Done():
+		t.Fatal("Done must not close on connection loss")
+	default:
+	}
+
+	c.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Done to close after Close")
+	}
+}
+
+func TestSignalingClient_KeepaliveDropsSilentConnection(t *testing.T) {
+	g := newTestGateway(t)
+	_, events := newTestClient(t, g, 20*time.Millisecond)
+
+	// Gateway receives ping frames but never answers them
+	silent := g.accept(t)
+	pings := make(chan struct{}, 8)
+	silent.SetPingHandler(func(string) error {
+		pings <- struct{}{}
+		return nil
+	})
+	readSignal(t, silent)
+	go func() {
+		for {
+			if _, _, err := silent.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-pings:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected keepalive ping frame")
+	}
+	expectConnection(t, events, true)
+	expectConnection(t, events, false)
+
+	if msg := readSignal(t, g.accept(t)); msg.Type != SignalJoin {
+		t.Fatalf("expected reconnect join, got %+v", msg)
+	}
+}
+
+func TestSignalingClient_KeepaliveAnsweredByPong(t *testing.T) {
+	g := newTestGateway(t)
+	_, events := newTestClient(t, g, 20*time.Millisecond)
+
+	// The default ping handler answers with pong frames while reading
+	conn := g.accept(t)
+	readSignal(t, conn)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	expectConnection(t, events, true)
+
+	select {
+	case connected := <-events:
+		t.Fatalf("unexpected connection change to %v while pongs arrive", connected)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestSignalingClient_AuthenticatesEachConnection(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := identity.New(priv)
+
+	g := newTestGateway(t)
+	newIdentifiedTestClient(t, g, 0, id)
+
+	var tokens []string
+	for i := 0; i < 2; i++ {
+		conn := g.accept(t)
+		readSignal(t, conn)
+		header := <-g.headers
+
+		signed := strings.TrimPrefix(header.Get("Authorization"), "Bearer ")
+		claims := &jwt.RegisteredClaims{}
+		if _, err := jwt.ParseWithClaims(signed, claims, func(*jwt.Token) (any, error) {
+			return identity.PublicKeyFromDID(id.DID())
+		}, jwt.WithValidMethods([]string{"EdDSA"}), jwt.WithAudience(g.url())); err != nil {
+			t.Fatalf("connection %d: invalid join token: %v", i, err)
+		}
+		if claims.Issuer != id.DID() || claims.Subject != "robot-1" {
+			t.Errorf("connection %d: unexpected claims %+v", i, claims)
+		}
+		tokens = append(tokens, claims.ID)
+
+		conn.Close() // Force a reconnect
+	}
+
+	if tokens[0] == tokens[1] {
+		t.Error("expected a fresh join token per connection")
+	}
+}
@@ -51,7 +51,7 @@ type MockSignalingHandler struct {
 	OfferCalls   []struct{ SessionID, Token string; SDP []byte }
 	AnswerCalls  []struct{ SessionID string; SDP []byte }
 	ICECalls     []struct{ SessionID string; Candidate []byte }
-	ByeCalls     []string
+	ByeCalls     []struct{ SessionID, Reason string }
 	RevokedCalls []struct{ SessionID, Reason string }
 }
 
@@ -77,8 +77,11 @@ func (m *MockSignalingHandler) OnICE(sessionID string, candidate []byte) {
 	}{sessionID, candidate})
 }
 
-func (m *MockSignalingHandler) OnBye(sessionID string) {
-	m.ByeCalls = append(m.ByeCalls, sessionID)
+func (m *MockSignalingHandler) OnBye(sessionID, reason string) {
+	m.ByeCalls = append(m.ByeCalls, struct {
+		SessionID string
+		Reason    string
+	}{sessionID, reason})
 }
 
 func (m *MockSignalingHandler) OnRevoked(sessionID, reason string) {
@@ -21,19 +21,21 @@ const (
 
 // SignalMessage is the signaling protocol message.
 type SignalMessage struct {
-	Type      SignalType      `json:"type"`
-	RobotID   string          `json:"robot_id,omitempty"`
-	SessionID string          `json:"session_id,omitempty"`
-	Token     string          `json:"token,omitempty"`
-	Payload   json.RawMessage `json:"payload,omitempty"`
-	Error     string          `json:"error,omitempty"`
-	Reason    string          `json:"reason,omitempty"`
+	Type       SignalType        `json:"type"`
+	RobotID    string            `json:"robot_id,omitempty"`
+	SessionID  string            `json:"session_id,omitempty"`
+	Token      string            `json:"token,omitempty"`
+	Payload    json.RawMessage   `json:"payload,omitempty"`
+	Candidates []json.RawMessage `json:"candidates,omitempty"` // Batched trickle ICE candidates
+	Error      string            `json:"error,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
 }
 
 // Error definitions for signaling.
 var (
 	ErrNotConnected = errors.New("not connected to gateway")
 	ErrConnClosed   = errors.New("connection closed")
+	ErrQueueFull    = errors.New("signaling send queue full")
 )
 
 // SignalingHandler handles incoming signaling messages.
@@ -41,6 +43,6 @@ type SignalingHandler interface {
 	OnOffer(sessionID, token string, sdp []byte)
 	OnAnswer(sessionID string, sdp []byte)
 	OnICE(sessionID string, candidate []byte)
-	OnBye(sessionID string)
+	OnBye(sessionID, reason string)
 	OnRevoked(sessionID, reason string)
 }
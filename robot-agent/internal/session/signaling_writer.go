@@ -0,0 +1,101 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	outboundQueueSize = 64
+	writeTimeout      = 5 * time.Second
+)
+
+// connWriter is the only goroutine writing to a connection, as
+// gorilla/websocket requires. Messages are written in the order queued.
+type connWriter struct {
+	conn   *websocket.Conn
+	logger *zap.Logger
+	queue  chan []byte
+	stop   chan struct{}
+	once   sync.Once
+	done   chan struct{}
+}
+
+func newConnWriter(conn *websocket.Conn, logger *zap.Logger) *connWriter {
+	w := &connWriter{
+		conn:   conn,
+		logger: logger,
+		queue:  make(chan []byte, outboundQueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// enqueue queues data without blocking the caller.
+func (w *connWriter) enqueue(data []byte) error {
+	select {
+	case <-w.stop:
+		return ErrNotConnected
+	default:
+	}
+
+	select {
+	case w.queue <- data:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// close stops the writer after it flushes what is already queued, and
+// waits for it. Write deadlines bound the wait.
+func (w *connWriter) close() {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+}
+
+func (w *connWriter) run() {
+	defer close(w.done)
+
+	for {
+		select {
+		case data := <-w.queue:
+			if !w.write(data) {
+				return
+			}
+		case <-w.stop:
+			w.flush()
+			return
+		}
+	}
+}
+
+func (w *connWriter) flush() {
+	for {
+		select {
+		case data := <-w.queue:
+			if !w.write(data) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// write sends one message. A failed write closes the connection so the
+// read loop notices and reconnects.
+func (w *connWriter) write(data []byte) bool {
+	w.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := w.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		w.logger.Warn("write error", zap.Error(err))
+		w.conn.Close()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,217 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// chanHandler forwards offers and remote candidates to channels.
+type chanHandler struct {
+	offers chan string
+	ice    chan string
+}
+
+func newChanHandler() *chanHandler {
+	return &chanHandler{offers: make(chan string, 8), ice: make(chan string, 8)}
+}
+
+func (h *chanHandler) OnOffer(sessionID, token string, sdp []byte) { h.offers <- sessionID }
+func (h *chanHandler) OnAnswer(sessionID string, sdp []byte)       {}
+func (h *chanHandler) OnICE(sessionID string, candidate []byte)    { h.ice <- string(candidate) }
+func (h *chanHandler) OnBye(sessionID, reason string)              {}
+func (h *chanHandler) OnRevoked(sessionID, reason string)          {}
+
+func writeSignal(t *testing.T, conn *websocket.Conn, msg SignalMessage) {
+	t.Helper()
+	data, _ := json.Marshal(msg)
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// readCandidates returns the candidates carried by an ice message.
+func readCandidates(t *testing.T, conn *websocket.Conn) []string {
+	t.Helper()
+	msg := readSignal(t, conn)
+	if msg.Type != SignalICE {
+		t.Fatalf("expected ice message, got %+v", msg)
+	}
+	if len(msg.Candidates) == 0 {
+		return []string{string(msg.Payload)}
+	}
+	var out []string
+	for _, c := range msg.Candidates {
+		out = append(out, string(c))
+	}
+	return out
+}
+
+func TestSignalingClient_ConcurrentSends(t *testing.T) {
+	g := newTestGateway(t)
+	c, _ := newTestClient(t, g, 0)
+	conn := g.accept(t)
+	readSignal(t, conn)
+
+	const senders, perSender = 4, 10
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perSender; j++ {
+				if err := c.SendError(fmt.Sprintf("ses_%d", i), "test"); err != nil {
+					t.Errorf("send: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < senders*perSender; i++ {
+		if msg := readSignal(t, conn); msg.Type != SignalError {
+			t.Fatalf("unexpected message %+v", msg)
+		}
+	}
+}
+
+func TestSignalingClient_AnswerBeforeCandidates(t *testing.T) {
+	g := newTestGateway(t)
+	c, _ := newTestClient(t, g, 0)
+	handler := newChanHandler()
+	c.SetHandler(handler)
+	conn := g.accept(t)
+	readSignal(t, conn)
+
+	writeSignal(t, conn, SignalMessage{Type: SignalOffer, SessionID: "ses_1", Payload: json.RawMessage(`{}`)})
+	<-handler.offers
+	c.ExpectAnswer("ses_1")
+
+	// Gathering starts before the answer is sent
+	for i := 0; i < 3; i++ {
+		if err := c.SendICE("ses_1", []byte(fmt.Sprintf(`{"candidate":"c%d"}`, i))); err != nil {
+			t.Fatalf("send ice: %v", err)
+		}
+	}
+	time.Sleep(2 * iceBatchWindow)
+	if err := c.SendAnswer("ses_1", []byte(`{"type":"answer"}`)); err != nil {
+		t.Fatalf("send answer: %v", err)
+	}
+
+	if msg := readSignal(t, conn); msg.Type != SignalAnswer {
+		t.Fatalf("expected answer first, got %+v", msg)
+	}
+	for i := 0; i < 3; i++ {
+		want := fmt.Sprintf(`{"candidate":"c%d"}`, i)
+		if got := readCandidates(t, conn); len(got) != 1 || got[0] != want {
+			t.Errorf("expected candidate %s alone after the answer, got %v", want, got)
+		}
+	}
+}
+
+func TestSignalingClient_RejectedOfferKeepsHeldCandidates(t *testing.T) {
+	g := newTestGateway(t)
+	c, _ := newTestClient(t, g, 0)
+	handler := newChanHandler()
+	c.SetHandler(handler)
+	conn := g.accept(t)
+	readSignal(t, conn)
+
+	c.ExpectAnswer("ses_1")
+	if err := c.SendICE("ses_1", []byte(`"c0"`)); err != nil {
+		t.Fatalf("send ice: %v", err)
+	}
+
+	// An offer for another session that the agent rejects must not
+	// discard candidates held for the live one
+	writeSignal(t, conn, SignalMessage{Type: SignalOffer, SessionID: "ses_2", Payload: json.RawMessage(`{}`)})
+	<-handler.offers
+
+	if err := c.SendAnswer("ses_1", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	readSignal(t, conn)
+	if got := readCandidates(t, conn); len(got) != 1 || got[0] != `"c0"` {
+		t.Errorf("expected held candidate after the answer, got %v", got)
+	}
+}
+
+func TestSignalingClient_BatchesCandidates(t *testing.T) {
+	g := newTestGateway(t)
+	c, _ := newTestClient(t, g, 0)
+	c.SetICEBatching(true)
+	conn := g.accept(t)
+	readSignal(t, conn)
+
+	if err := c.SendAnswer("ses_1", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	readSignal(t, conn)
+
+	for i := 0; i < 5; i++ {
+		c.SendICE("ses_1", []byte(fmt.Sprintf(`"c%d"`, i)))
+	}
+	if got := readCandidates(t, conn); len(got) != 5 {
+		t.Errorf("expected one batch of 5 candidates, got %v", got)
+	}
+}
+
+func TestSignalingClient_ReceivesCandidateBatch(t *testing.T) {
+	g := newTestGateway(t)
+	c, _ := newTestClient(t, g, 0)
+	handler := newChanHandler()
+	c.SetHandler(handler)
+	conn := g.accept(t)
+	readSignal(t, conn)
+
+	writeSignal(t, conn, SignalMessage{
+		Type:       SignalICE,
+		SessionID:  "ses_1",
+		Candidates: []json.RawMessage{json.RawMessage(`"a"`), json.RawMessage(`"b"`)},
+	})
+	for _, want := range []string{`"a"`, `"b"`} {
+		select {
+		case got := <-handler.ice:
+			if got != want {
+				t.Errorf("candidate = %s, want %s", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for candidate")
+		}
+	}
+}
+
+func TestSignalingClient_CloseFlushesQueuedMessages(t *testing.T) {
+	g := newTestGateway(t)
+	c, _ := newTestClient(t, g, 0)
+	conn := g.accept(t)
+	readSignal(t, conn)
+
+	if err := c.SendBye("ses_1", "shutdown"); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	if msg := readSignal(t, conn); msg.Type != SignalBye {
+		t.Errorf("expected bye to be delivered before close, got %+v", msg)
+	}
+	if err := c.SendBye("ses_1", "shutdown"); err != ErrNotConnected {
+		t.Errorf("expected ErrNotConnected after close, got %v", err)
+	}
+}
+
+func TestConnWriter_QueueFull(t *testing.T) {
+	// Not started, so nothing drains the queue
+	w := &connWriter{queue: make(chan []byte, 1), stop: make(chan struct{})}
+
+	if err := w.enqueue([]byte("a")); err != nil {
+		t.Fatalf("first enqueue: %v", err)
+	}
+	if err := w.enqueue([]byte("b")); err != ErrQueueFull {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+}
@@ -27,6 +27,7 @@ type TokenClaims struct {
 	Scope     []string
 	Nonce     string
 	ExpiresAt time.Time
+	MaxSpeed  float64 // Optional drive speed fraction limit (0 = unlimited)
 }
 
 // TokenValidator validates Ed25519-signed JWTs.
@@ -45,6 +46,11 @@ func NewTokenValidator(publicKey ed25519.PublicKey, robotID string, clockSkew ti
 	}
 }
 
+// PublicKey returns the key signatures are verified with.
+func (v *TokenValidator) PublicKey() ed25519.PublicKey {
+	return v.publicKey
+}
+
 // Validate parses and validates a JWT token.
 func (v *TokenValidator) Validate(tokenString, expectedSessionID string) (*TokenClaims, error) {
 	if tokenString == "" {
@@ -103,6 +109,7 @@ func (v *TokenValidator) extractClaims(claims jwt.MapClaims, expectedSessionID s
 	jti, _ := claims["jti"].(string)
 	sub, _ := claims["sub"].(string)
 	nonce, _ := claims["nonce"].(string)
+	maxSpeed, _ := claims["max_speed"].(float64)
 
 	var expiresAt time.Time
 	if exp, ok := claims["exp"].(float64); ok {
@@ -116,6 +123,7 @@ func (v *TokenValidator) extractClaims(claims jwt.MapClaims, expectedSessionID s
 		Scope:     v.extractScope(claims),
 		Nonce:     nonce,
 		ExpiresAt: expiresAt,
+		MaxSpeed:  maxSpeed,
 	}, nil
 }
 
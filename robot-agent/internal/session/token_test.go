@@ -220,3 +220,20 @@ func TestTokenValidator_EmptyToken(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestTokenValidator_MaxSpeedClaim(t *testing.T) {
+	pub, priv := testKeyPair(t)
+	validator := NewTokenValidator(pub, "robot-001", 30*time.Second)
+
+	token := signTestToken(t, priv, jwt.MapClaims{
+		"aud":       "robot-001",
+		"sid":       "session-123",
+		"max_speed": 0.4,
+		"exp":       time.Now().Add(1 * time.Hour).Unix(),
+	})
+
+	result, err := validator.Validate(token, "session-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, 0.4, result.MaxSpeed)
+}
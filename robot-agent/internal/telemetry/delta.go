@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"math"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// Minimum changes that are worth sending before the keep-alive interval.
+const (
+	batteryPercentDelta = 1.0  // Percent
+	batteryVoltageDelta = 0.1  // Volts
+	poseDelta           = 0.02 // Meters
+	headingDelta        = 0.02 // Radians
+	velocityDelta       = 0.01 // m/s or rad/s
+	temperatureDelta    = 1.0  // Degrees C
+	loadDelta           = 5.0  // Percent
+	rttDelta            = 5.0  // Milliseconds
+	signalDelta         = 3.0  // dBm
+	qualityDelta        = 0.05
+)
+
+// changed reports whether cur differs significantly from prev.
+func changed(prev, cur *protocol.TelemetryMessage) bool {
+	switch {
+	case cur.Battery != nil:
+		a, b := prev.Battery, cur.Battery
+		return a == nil || a.Charging != b.Charging ||
+			exceeds(a.Percent, b.Percent, batteryPercentDelta) ||
+			exceeds(a.Voltage, b.Voltage, batteryVoltageDelta)
+	case cur.Odometry != nil:
+		a, b := prev.Odometry, cur.Odometry
+		return a == nil || math.Hypot(b.X-a.X, b.Y-a.Y) >= poseDelta ||
+			exceeds(a.Theta, b.Theta, headingDelta) ||
+			exceeds(a.V, b.V, velocityDelta) ||
+			exceeds(a.W, b.W, velocityDelta)
+	case cur.Temperatures != nil:
+		if len(prev.Temperatures) != len(cur.Temperatures) {
+			return true
+		}
+		for name, t := range cur.Temperatures {
+			old, ok := prev.Temperatures[name]
+			if !ok || exceeds(old, t, temperatureDelta) {
+				return true
+			}
+		}
+		return false
+	case cur.System != nil:
+		a, b := prev.System, cur.System
+		return a == nil || exceeds(a.CPUPercent, b.CPUPercent, loadDelta) ||
+			exceeds(a.MemoryPercent, b.MemoryPercent, loadDelta)
+	case cur.Link != nil:
+		a, b := prev.Link, cur.Link
+		return a == nil || exceeds(a.RTTMs, b.RTTMs, rttDelta) ||
+			exceeds(a.SignalDBm, b.SignalDBm, signalDelta) ||
+			exceeds(a.Quality, b.Quality, qualityDelta)
+	}
+	return true
+}
+
+// exceeds reports whether a and b differ by at least delta.
+func exceeds(a, b, delta float64) bool {
+	return math.Abs(b-a) >= delta
+}
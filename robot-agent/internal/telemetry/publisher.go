@@ -0,0 +1,222 @@
+// Package telemetry publishes robot telemetry to the console over the DataChannel.
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+// pollInterval is how often streams are checked for due samples.
+const pollInterval = 50 * time.Millisecond
+
+// Optional interfaces a RobotAPI backend may implement to provide telemetry.
+type (
+	BatterySource interface {
+		Battery() (protocol.BatteryTelemetry, error)
+	}
+	OdometrySource interface {
+		Odometry() (protocol.OdometryTelemetry, error)
+	}
+	ThermalSource interface {
+		Temperatures() (map[string]float64, error)
+	}
+	SystemSource interface {
+		System() (protocol.SystemTelemetry, error)
+	}
+	LinkSource interface {
+		Link() (protocol.LinkTelemetry, error)
+	}
+)
+
+// Sampler fills the payload of a telemetry message.
+type Sampler func(msg *protocol.TelemetryMessage) error
+
+// ScopeChecker checks if a scope is allowed for the current session.
+type ScopeChecker interface {
+	HasScope(scope string) bool
+}
+
+// SendFunc sends an encoded message to the console.
+type SendFunc func(data []byte) error
+
+// Config holds telemetry publishing parameters.
+type Config struct {
+	Rates     map[string]time.Duration // Kind -> sample interval; zero disables the kind
+	KeepAlive time.Duration            // Unchanged samples are resent at least this often
+}
+
+// stream is the publishing state of one telemetry kind.
+type stream struct {
+	kind     string
+	interval time.Duration
+	sample   Sampler
+	last     *protocol.TelemetryMessage
+	lastSent time.Time
+	nextDue  time.Time
+}
+
+// Publisher samples registered sources at their configured rates and sends
+// telemetry messages, suppressing samples that have not changed significantly.
+// Messages are only sent while the session holds the teleop:view scope.
+type Publisher struct {
+	mu      sync.Mutex
+	cfg     Config
+	now     func() time.Time
+	send    SendFunc
+	scopes  ScopeChecker
+	streams []*stream
+}
+
+// NewPublisher creates a telemetry publisher.
+func NewPublisher(cfg Config, send SendFunc, scopes ScopeChecker) *Publisher {
+	return newPublisherWithClock(cfg, send, scopes, time.Now)
+}
+
+func newPublisherWithClock(cfg Config, send SendFunc, scopes ScopeChecker, now func() time.Time) *Publisher {
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = 5 * time.Second
+	}
+	return &Publisher{cfg: cfg, now: now, send: send, scopes: scopes}
+}
+
+// Register adds a sampler for a telemetry kind. It returns false if the
+// kind has no configured rate or already has a sampler.
+func (p *Publisher) Register(kind string, sample Sampler) bool {
+	interval := p.cfg.Rates[kind]
+	if interval <= 0 {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.streams {
+		if s.kind == kind {
+			return false
+		}
+	}
+	p.streams = append(p.streams, &stream{kind: kind, interval: interval, sample: sample})
+	return true
+}
+
+// RegisterBackend registers a sampler for every telemetry interface the
+// backend implements and returns the newly registered kinds.
+func (p *Publisher) RegisterBackend(backend any) []string {
+	var kinds []string
+	add := func(kind string, sample Sampler) {
+		if p.Register(kind, sample) {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	if src, ok := backend.(BatterySource); ok {
+		add(protocol.TelemetryBattery, func(msg *protocol.TelemetryMessage) error {
+			b, err := src.Battery()
+			msg.Battery = &b
+			return err
+		})
+	}
+	if src, ok := backend.(OdometrySource); ok {
+		add(protocol.TelemetryOdometry, func(msg *protocol.TelemetryMessage) error {
+			o, err := src.Odometry()
+			msg.Odometry = &o
+			return err
+		})
+	}
+	if src, ok := backend.(ThermalSource); ok {
+		add(protocol.TelemetryThermal, func(msg *protocol.TelemetryMessage) error {
+			temps, err := src.Temperatures()
+			msg.Temperatures = temps
+			return err
+		})
+	}
+	if src, ok := backend.(SystemSource); ok {
+		add(protocol.TelemetrySystem, func(msg *protocol.TelemetryMessage) error {
+			s, err := src.System()
+			msg.System = &s
+			return err
+		})
+	}
+	if src, ok := backend.(LinkSource); ok {
+		add(protocol.TelemetryLink, func(msg *protocol.TelemetryMessage) error {
+			l, err := src.Link()
+			msg.Link = &l
+			return err
+		})
+	}
+	return kinds
+}
+
+// Reset forgets previously sent samples so the next poll sends full state
+// (e.g., when a new session connects).
+func (p *Publisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, s := range p.streams {
+		s.last = nil
+		s.lastSent = time.Time{}
+		s.nextDue = time.Time{}
+	}
+}
+
+// Run polls streams until ctx is cancelled.
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll()
+		}
+	}
+}
+
+// poll samples every due stream and sends changed or keep-alive samples.
+func (p *Publisher) poll() {
+	if p.scopes == nil || !p.scopes.HasScope(protocol.ScopeView) {
+		return
+	}
+
+	p.mu.Lock()
+	now := p.now()
+	var out [][]byte
+	for _, s := range p.streams {
+		if now.Before(s.nextDue) {
+			continue
+		}
+		s.nextDue = now.Add(s.interval)
+
+		msg := &protocol.TelemetryMessage{Type: protocol.TypeTelemetry, Kind: s.kind}
+		if err := s.sample(msg); err != nil {
+			continue
+		}
+		if s.last != nil && !changed(s.last, msg) && now.Sub(s.lastSent) < p.cfg.KeepAlive {
+			continue
+		}
+
+		msg.T = now.UnixMilli()
+		data, err := json.Marshal(msg)
+		if err != nil {
+			continue
+		}
+		s.last = msg
+		s.lastSent = now
+		out = append(out, data)
+	}
+	send := p.send
+	p.mu.Unlock()
+
+	// Send outside the lock so a slow channel cannot block registration
+	for _, data := range out {
+		if send(data) != nil {
+			return
+		}
+	}
+}
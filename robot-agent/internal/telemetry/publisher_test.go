@@ -0,0 +1,226 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/datapilot/chainkvm/robot-agent/pkg/protocol"
+)
+
+type mockScopes struct {
+	scopes []string
+}
+
+func (m *mockScopes) HasScope(scope string) bool {
+	return slices.Contains(m.scopes, scope)
+}
+
+type mockBackend struct {
+	battery    protocol.BatteryTelemetry
+	batteryErr error
+	system     protocol.SystemTelemetry
+}
+
+func (m *mockBackend) Battery() (protocol.BatteryTelemetry, error) {
+	return m.battery, m.batteryErr
+}
+
+func (m *mockBackend) System() (protocol.SystemTelemetry, error) {
+	return m.system, nil
+}
+
+type testHarness struct {
+	pub     *Publisher
+	backend *mockBackend
+	scopes  *mockScopes
+	now     time.Time
+	sent    []protocol.TelemetryMessage
+}
+
+func newTestHarness(t *testing.T) *testHarness {
+	t.Helper()
+	h := &testHarness{
+		backend: &mockBackend{battery: protocol.BatteryTelemetry{Percent: 80}},
+		scopes:  &mockScopes{scopes: []string{protocol.ScopeView}},
+		now:     time.Unix(1700000000, 0),
+	}
+	send := func(data []byte) error {
+		var msg protocol.TelemetryMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("invalid telemetry JSON: %v", err)
+		}
+		h.sent = append(h.sent, msg)
+		return nil
+	}
+	h.pub = newPublisherWithClock(Config{
+		Rates: map[string]time.Duration{
+			protocol.TelemetryBattery: time.Second,
+			protocol.TelemetrySystem:  100 * time.Millisecond,
+		},
+		KeepAlive: 5 * time.Second,
+	}, send, h.scopes, func() time.Time { return h.now })
+	return h
+}
+
+func (h *testHarness) advance(d time.Duration) {
+	h.now = h.now.Add(d)
+	h.pub.poll()
+}
+
+func (h *testHarness) count(kind string) int {
+	n := 0
+	for _, msg := range h.sent {
+		if msg.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPublisher_RegisterBackend(t *testing.T) {
+	h := newTestHarness(t)
+
+	kinds := h.pub.RegisterBackend(h.backend)
+	if !slices.Equal(kinds, []string{protocol.TelemetryBattery, protocol.TelemetrySystem}) {
+		t.Errorf("unexpected kinds: %v", kinds)
+	}
+
+	// Already registered kinds are not replaced
+	if h.pub.Register(protocol.TelemetryBattery, func(*protocol.TelemetryMessage) error { return nil }) {
+		t.Error("expected duplicate registration to be rejected")
+	}
+	// Kinds without a configured rate are disabled
+	if h.pub.Register(protocol.TelemetryThermal, func(*protocol.TelemetryMessage) error { return nil }) {
+		t.Error("expected kind without rate to be rejected")
+	}
+}
+
+func TestPublisher_SendsInitialState(t *testing.T) {
+	h := newTestHarness(t)
+	h.pub.RegisterBackend(h.backend)
+
+	h.advance(0)
+
+	if len(h.sent) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(h.sent))
+	}
+	msg := h.sent[0]
+	if msg.Type != protocol.TypeTelemetry || msg.Kind != protocol.TelemetryBattery {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if msg.Battery == nil || msg.Battery.Percent != 80 {
+		t.Errorf("unexpected battery payload: %+v", msg.Battery)
+	}
+	if msg.T != h.now.UnixMilli() {
+		t.Errorf("expected timestamp %d, got %d", h.now.UnixMilli(), msg.T)
+	}
+}
+
+func TestPublisher_DeltaSuppression(t *testing.T) {
+	h := newTestHarness(t)
+	h.pub.RegisterBackend(h.backend)
+	h.advance(0)
+
+	// Small change is suppressed
+	h.backend.system.CPUPercent = 2
+	h.advance(100 * time.Millisecond)
+	if got := h.count(protocol.TelemetrySystem); got != 1 {
+		t.Errorf("expected small change suppressed, got %d system messages", got)
+	}
+
+	// Significant change is sent
+	h.backend.system.CPUPercent = 40
+	h.advance(100 * time.Millisecond)
+	if got := h.count(protocol.TelemetrySystem); got != 2 {
+		t.Errorf("expected significant change sent, got %d system messages", got)
+	}
+
+	// State change is always significant
+	h.backend.battery.Charging = true
+	h.advance(time.Second)
+	if got := h.count(protocol.TelemetryBattery); got != 2 {
+		t.Errorf("expected charging change sent, got %d battery messages", got)
+	}
+}
+
+func TestPublisher_RespectsRate(t *testing.T) {
+	h := newTestHarness(t)
+	h.pub.RegisterBackend(h.backend)
+	h.advance(0)
+
+	h.backend.battery.Percent = 50
+	h.advance(500 * time.Millisecond)
+	if got := h.count(protocol.TelemetryBattery); got != 1 {
+		t.Errorf("expected battery not sampled before its interval, got %d", got)
+	}
+
+	h.advance(500 * time.Millisecond)
+	if got := h.count(protocol.TelemetryBattery); got != 2 {
+		t.Errorf("expected battery sampled after its interval, got %d", got)
+	}
+}
+
+func TestPublisher_KeepAlive(t *testing.T) {
+	h := newTestHarness(t)
+	h.pub.RegisterBackend(h.backend)
+	h.advance(0)
+
+	for range 4 {
+		h.advance(time.Second)
+	}
+	if got := h.count(protocol.TelemetryBattery); got != 1 {
+		t.Errorf("expected unchanged battery suppressed, got %d", got)
+	}
+
+	h.advance(time.Second)
+	if got := h.count(protocol.TelemetryBattery); got != 2 {
+		t.Errorf("expected keep-alive after 5s, got %d", got)
+	}
+}
+
+func TestPublisher_RequiresViewScope(t *testing.T) {
+	h := newTestHarness(t)
+	h.pub.RegisterBackend(h.backend)
+	h.scopes.scopes = []string{protocol.ScopeControl}
+
+	h.advance(0)
+	if len(h.sent) != 0 {
+		t.Errorf("expected no telemetry without view scope, got %d", len(h.sent))
+	}
+
+	h.scopes.scopes = []string{protocol.ScopeView}
+	h.advance(time.Second)
+	if len(h.sent) != 2 {
+		t.Errorf("expected telemetry once view scope granted, got %d", len(h.sent))
+	}
+}
+
+func TestPublisher_SkipsFailedSamples(t *testing.T) {
+	h := newTestHarness(t)
+	h.pub.RegisterBackend(h.backend)
+	h.backend.batteryErr = errors.New("battery offline")
+
+	h.advance(0)
+	if got := h.count(protocol.TelemetryBattery); got != 0 {
+		t.Errorf("expected failed sample skipped, got %d", got)
+	}
+	if got := h.count(protocol.TelemetrySystem); got != 1 {
+		t.Errorf("expected other kinds unaffected, got %d", got)
+	}
+}
+
+func TestPublisher_ResetResendsState(t *testing.T) {
+	h := newTestHarness(t)
+	h.pub.RegisterBackend(h.backend)
+	h.advance(0)
+
+	h.pub.Reset()
+	h.advance(10 * time.Millisecond)
+
+	if len(h.sent) != 4 {
+		t.Errorf("expected full state resent after reset, got %d messages", len(h.sent))
+	}
+}
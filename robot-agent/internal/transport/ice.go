@@ -0,0 +1,145 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/pion/ice/v2"
+	"github.com/pion/interceptor"
+	"github.com/pion/webrtc/v3"
+)
+
+// ErrNoCandidatePair indicates ICE has not selected a candidate pair yet.
+var ErrNoCandidatePair = errors.New("no selected candidate pair")
+
+// Candidate pair types, from most to least direct path.
+const (
+	CandidatePairHost  = "host"
+	CandidatePairSrflx = "srflx"
+	CandidatePairRelay = "relay"
+)
+
+// CandidatePair describes the ICE candidate pair a connection is using.
+type CandidatePair struct {
+	Local    webrtc.ICECandidateType
+	Remote   webrtc.ICECandidateType
+	Protocol webrtc.ICEProtocol
+}
+
+// Type classifies the media path: relay if either side goes through TURN,
+// srflx if either side was discovered behind NAT, host otherwise.
+func (p CandidatePair) Type() string {
+	switch {
+	case p.Local == webrtc.ICECandidateTypeRelay || p.Remote == webrtc.ICECandidateTypeRelay:
+		return CandidatePairRelay
+	case p.Local == webrtc.ICECandidateTypeSrflx || p.Remote == webrtc.ICECandidateTypeSrflx,
+		p.Local == webrtc.ICECandidateTypePrflx || p.Remote == webrtc.ICECandidateTypePrflx:
+		return CandidatePairSrflx
+	default:
+		return CandidatePairHost
+	}
+}
+
+// newAPI builds a pion API with the default codecs and interceptors, like
+// webrtc.NewPeerConnection, plus the configured candidate policy and the
+// interceptors feeding media.
+func (c ICEConfig) newAPI(media *mediaStats) (*webrtc.API, error) {
+	se, err := c.settingEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &webrtc.MediaEngine{}
+	if err := m.RegisterDefaultCodecs(); err != nil {
+		return nil, err
+	}
+	i := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(m, i); err != nil {
+		return nil, err
+	}
+	if err := media.register(i); err != nil {
+		return nil, err
+	}
+
+	return webrtc.NewAPI(
+		webrtc.WithMediaEngine(m),
+		webrtc.WithInterceptorRegistry(i),
+		webrtc.WithSettingEngine(se),
+	), nil
+}
+
+// settingEngine restricts candidate gathering to the configured network
+// types, interfaces and UDP ports, and advertises NAT 1:1 addresses or
+// mDNS names.
+func (c ICEConfig) settingEngine() (webrtc.SettingEngine, error) {
+	var se webrtc.SettingEngine
+
+	// Browsers hide their host addresses behind mDNS names, so LAN peers
+	// are only reachable if those are resolved
+	if c.MDNSHostCandidates {
+		if len(c.NAT1To1IPs) > 0 {
+			return se, ice.ErrMulticastDNSWithNAT1To1IPMapping
+		}
+		se.SetICEMulticastDNSMode(ice.MulticastDNSModeQueryAndGather)
+	} else {
+		se.SetICEMulticastDNSMode(ice.MulticastDNSModeQueryOnly)
+	}
+
+	if len(c.NetworkTypes) > 0 {
+		types := make([]webrtc.NetworkType, 0, len(c.NetworkTypes))
+		for _, name := range c.NetworkTypes {
+			t, err := webrtc.NewNetworkType(name)
+			if err != nil {
+				return se, fmt.Errorf("ICE network type %q: %w", name, err)
+			}
+			types = append(types, t)
+		}
+		se.SetNetworkTypes(types)
+	}
+
+	if len(c.Interfaces) > 0 {
+		allowed := slices.Clone(c.Interfaces)
+		se.SetInterfaceFilter(func(name string) bool {
+			return slices.Contains(allowed, name)
+		})
+	}
+
+	if c.UDPPortMin != 0 || c.UDPPortMax != 0 {
+		if err := se.SetEphemeralUDPPortRange(c.UDPPortMin, c.UDPPortMax); err != nil {
+			return se, fmt.Errorf("ICE UDP port range %d-%d: %w", c.UDPPortMin, c.UDPPortMax, err)
+		}
+	}
+
+	if len(c.NAT1To1IPs) > 0 {
+		se.SetNAT1To1IPs(c.NAT1To1IPs, webrtc.ICECandidateTypeHost)
+	}
+
+	return se, nil
+}
+
+// SelectedCandidatePair returns the candidate pair ICE selected for the
+// current connection.
+func (w *WebRTC) SelectedCandidatePair() (CandidatePair, error) {
+	w.mu.Lock()
+	pc := w.pc
+	w.mu.Unlock()
+
+	if pc == nil {
+		return CandidatePair{}, ErrNoPeerConnection
+	}
+
+	pair, err := pc.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+	if err != nil {
+		return CandidatePair{}, err
+	}
+	if pair == nil || pair.Local == nil || pair.Remote == nil {
+		return CandidatePair{}, ErrNoCandidatePair
+	}
+
+	return CandidatePair{
+		Local:    pair.Local.Typ,
+		Remote:   pair.Remote.Typ,
+		Protocol: pair.Local.Protocol,
+	}, nil
+}
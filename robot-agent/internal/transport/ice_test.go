@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"go.uber.org/zap"
+)
+
+func TestCandidatePair_Type(t *testing.T) {
+	tests := []struct {
+		local, remote webrtc.ICECandidateType
+		want          string
+	}{
+		{webrtc.ICECandidateTypeHost, webrtc.ICECandidateTypeHost, CandidatePairHost},
+		{webrtc.ICECandidateTypeHost, webrtc.ICECandidateTypeSrflx, CandidatePairSrflx},
+		{webrtc.ICECandidateTypePrflx, webrtc.ICECandidateTypeHost, CandidatePairSrflx},
+		{webrtc.ICECandidateTypeSrflx, webrtc.ICECandidateTypeRelay, CandidatePairRelay},
+		{webrtc.ICECandidateTypeRelay, webrtc.ICECandidateTypeHost, CandidatePairRelay},
+	}
+
+	for _, tt := range tests {
+		if got := (CandidatePair{Local: tt.local, Remote: tt.remote}).Type(); got != tt.want {
+			t.Errorf("%s/%s: got %s, want %s", tt.local, tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestICEConfig_SettingEngineRejectsInvalidPolicy(t *testing.T) {
+	tests := map[string]ICEConfig{
+		"network type": {LANOnly: true, NetworkTypes: []string{"sctp"}},
+		"port range":   {LANOnly: true, UDPPortMin: 50000, UDPPortMax: 40000},
+		"mdns nat":     {LANOnly: true, MDNSHostCandidates: true, NAT1To1IPs: []string{"203.0.113.7"}},
+	}
+
+	for name, cfg := range tests {
+		if _, err := cfg.settingEngine(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		w := NewWebRTC(cfg, zap.NewNop())
+		if err := w.CreatePeerConnection(); err == nil {
+			t.Errorf("%s: expected CreatePeerConnection to fail", name)
+		}
+	}
+}
+
+func TestWebRTC_SelectedCandidatePair(t *testing.T) {
+	w := NewWebRTC(ICEConfig{LANOnly: true, NetworkTypes: []string{"udp4"}, UDPPortMin: 40000, UDPPortMax: 40100}, zap.NewNop())
+	if _, err := w.SelectedCandidatePair(); err != ErrNoPeerConnection {
+		t.Fatalf("expected ErrNoPeerConnection, got %v", err)
+	}
+	if err := w.CreatePeerConnection(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.SelectedCandidatePair(); err != ErrNoCandidatePair {
+		t.Fatalf("expected ErrNoCandidatePair before connecting, got %v", err)
+	}
+
+	console := connectTestConsole(t, w, nil)
+	defer console.Close()
+
+	pair, err := w.SelectedCandidatePair()
+	if err != nil {
+		t.Fatalf("selected pair: %v", err)
+	}
+	if pair.Type() != CandidatePairHost || pair.Protocol != webrtc.ICEProtocolUDP {
+		t.Errorf("expected a host UDP pair, got %+v", pair)
+	}
+}
+
+func TestWebRTC_RequiresICEServersUnlessLANOnly(t *testing.T) {
+	w := NewWebRTC(ICEConfig{}, zap.NewNop())
+	if err := w.CreatePeerConnection(); err != ErrNoICEServers {
+		t.Fatalf("expected ErrNoICEServers, got %v", err)
+	}
+
+	w = NewWebRTC(ICEConfig{LANOnly: true, MDNSHostCandidates: true}, zap.NewNop())
+	if err := w.CreatePeerConnection(); err != nil {
+		t.Fatalf("LAN-only: %v", err)
+	}
+	defer w.Close()
+	if servers := w.pc.GetConfiguration().ICEServers; len(servers) != 0 {
+		t.Errorf("LAN-only must not contact ICE servers, got %+v", servers)
+	}
+}
+
+// connectTestConsole negotiates w with a plain peer connection playing the
+// console, which offers the control data channel plus whatever setup adds,
+// and waits for w to connect.
+func connectTestConsole(t *testing.T, w *WebRTC, setup func(*webrtc.PeerConnection)) *webrtc.PeerConnection {
+	t.Helper()
+	console, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := console.CreateDataChannel("control", nil); err != nil {
+		t.Fatal(err)
+	}
+	if setup != nil {
+		setup(console)
+	}
+
+	connected := make(chan struct{})
+	var once sync.Once
+	w.SetStateCallback(func(s webrtc.PeerConnectionState) {
+		if s == webrtc.PeerConnectionStateConnected {
+			once.Do(func() { close(connected) })
+		}
+	})
+	w.SetICECallback(func(data []byte) {
+		var c ICECandidate
+		if err := json.Unmarshal(data, &c); err == nil {
+			console.AddICECandidate(webrtc.ICECandidateInit{Candidate: c.Candidate, SDPMid: &c.SDPMid, SDPMLineIndex: &c.SDPMLineIndex})
+		}
+	})
+
+	offer, err := console.CreateOffer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gathered := webrtc.GatheringCompletePromise(console)
+	if err := console.SetLocalDescription(offer); err != nil {
+		t.Fatal(err)
+	}
+	<-gathered
+
+	offerData, _ := json.Marshal(console.LocalDescription())
+	answerData, err := w.HandleOffer(offerData)
+	if err != nil {
+		t.Fatalf("handle offer: %v", err)
+	}
+	var answer webrtc.SessionDescription
+	if err := json.Unmarshal(answerData, &answer); err != nil {
+		t.Fatal(err)
+	}
+	if err := console.SetRemoteDescription(answer); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-connected:
+	case <-time.After(10 * time.Second):
+		console.Close()
+		t.Skip("peer connection did not establish; no usable host interface")
+	}
+	return console
+}
@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/stats"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+// Stats is a snapshot of the current peer connection's counters. Counters
+// are cumulative for the connection; rates are left to the caller.
+type Stats struct {
+	Timestamp          time.Time
+	BytesSent          uint64        // Outbound RTP payload bytes, all streams
+	PacketsSent        uint64        // Outbound RTP packets, all streams
+	FramesSent         uint64        // Outbound video frames
+	FractionLost       float64       // Latest remote-reported loss, worst stream
+	Jitter             time.Duration // Latest remote-reported jitter, worst stream
+	NACKCount          uint32        // NACKs received from the peer
+	PLICount           uint32        // PLIs received from the peer
+	RoundTripTime      time.Duration // Selected candidate pair RTT
+	SCTPBufferedAmount uint64        // Data channel bytes queued for send
+}
+
+// mediaStats collects RTP counters for one peer connection. pion's
+// GetStats reports transport and candidate pair stats but no RTP streams,
+// so those come from the stats interceptor instead.
+type mediaStats struct {
+	mu     sync.Mutex
+	getter stats.Getter
+	frames atomic.Uint64
+}
+
+// register adds the interceptors feeding m to an API's registry.
+func (m *mediaStats) register(i *interceptor.Registry) error {
+	f, err := stats.NewInterceptor()
+	if err != nil {
+		return err
+	}
+	f.OnNewPeerConnection(func(_ string, g stats.Getter) {
+		m.mu.Lock()
+		m.getter = g
+		m.mu.Unlock()
+	})
+	i.Add(f)
+	i.Add(&frameCounter{frames: &m.frames})
+	return nil
+}
+
+// addRTP sums the outbound RTP streams of pc into s.
+func (m *mediaStats) addRTP(s *Stats, pc *webrtc.PeerConnection) {
+	s.FramesSent = m.frames.Load()
+
+	m.mu.Lock()
+	getter := m.getter
+	m.mu.Unlock()
+	if getter == nil {
+		return
+	}
+
+	for _, sender := range pc.GetSenders() {
+		for _, enc := range sender.GetParameters().Encodings {
+			st := getter.Get(uint32(enc.SSRC))
+			if st == nil {
+				continue
+			}
+			s.BytesSent += st.OutboundRTPStreamStats.BytesSent
+			s.PacketsSent += st.OutboundRTPStreamStats.PacketsSent
+			s.NACKCount += st.OutboundRTPStreamStats.NACKCount
+			s.PLICount += st.OutboundRTPStreamStats.PLICount
+			s.FractionLost = max(s.FractionLost, st.RemoteInboundRTPStreamStats.FractionLost)
+			s.Jitter = max(s.Jitter, seconds(st.RemoteInboundRTPStreamStats.Jitter))
+		}
+	}
+}
+
+// frameCounter counts outbound video frames by the RTP marker bit, which
+// is set on the last packet of each frame.
+type frameCounter struct {
+	interceptor.NoOp
+	frames *atomic.Uint64
+}
+
+// NewInterceptor implements interceptor.Factory.
+func (f *frameCounter) NewInterceptor(string) (interceptor.Interceptor, error) {
+	return f, nil
+}
+
+// BindLocalStream counts frames on outbound video streams.
+func (f *frameCounter) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	if !strings.HasPrefix(info.MimeType, "video/") {
+		return writer
+	}
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, a interceptor.Attributes) (int, error) {
+		if header.Marker {
+			f.frames.Add(1)
+		}
+		return writer.Write(header, payload, a)
+	})
+}
+
+// Stats reads the current peer connection's stats: the selected candidate
+// pair RTT from GetStats, outbound RTP counters and the data channel
+// buffer.
+func (w *WebRTC) Stats() (Stats, error) {
+	w.mu.Lock()
+	pc, dc, media := w.pc, w.dc, w.media
+	w.mu.Unlock()
+
+	if pc == nil {
+		return Stats{}, ErrNoPeerConnection
+	}
+
+	s := Stats{Timestamp: time.Now()}
+	for _, v := range pc.GetStats() {
+		pair, ok := v.(webrtc.ICECandidatePairStats)
+		if ok && pair.Nominated && pair.State == webrtc.StatsICECandidatePairStateSucceeded {
+			s.RoundTripTime = seconds(pair.CurrentRoundTripTime)
+		}
+	}
+	if media != nil {
+		media.addRTP(&s, pc)
+	}
+	if dc != nil {
+		s.SCTPBufferedAmount = dc.BufferedAmount()
+	}
+	return s, nil
+}
+
+// seconds converts a stats value in seconds to a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
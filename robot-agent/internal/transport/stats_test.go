@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"go.uber.org/zap"
+)
+
+func TestWebRTC_StatsRequiresPeerConnection(t *testing.T) {
+	w := NewWebRTC(ICEConfig{LANOnly: true}, zap.NewNop())
+	if _, err := w.Stats(); err != ErrNoPeerConnection {
+		t.Fatalf("expected ErrNoPeerConnection, got %v", err)
+	}
+}
+
+func TestWebRTC_StatsCountsOutboundVideo(t *testing.T) {
+	w := NewWebRTC(ICEConfig{LANOnly: true, NetworkTypes: []string{"udp4"}}, zap.NewNop())
+	if err := w.CreatePeerConnection(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, "video", "robot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.pc.AddTrack(track); err != nil {
+		t.Fatal(err)
+	}
+
+	console := connectTestConsole(t, w, func(console *webrtc.PeerConnection) {
+		if _, err := console.AddTransceiverFromKind(webrtc.RTPCodecTypeVideo, webrtc.RTPTransceiverInit{
+			Direction: webrtc.RTPTransceiverDirectionRecvonly,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	defer console.Close()
+
+	// Senders bind once DTLS completes, so keep sending until frames show up
+	deadline := time.Now().Add(5 * time.Second)
+	var s Stats
+	for time.Now().Before(deadline) {
+		if err := track.WriteSample(media.Sample{Data: make([]byte, 1200), Duration: 33 * time.Millisecond}); err != nil {
+			t.Fatalf("write sample: %v", err)
+		}
+		if s, err = w.Stats(); err != nil {
+			t.Fatalf("stats: %v", err)
+		}
+		if s.FramesSent >= 5 && s.RoundTripTime > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if s.FramesSent < 5 || s.PacketsSent < s.FramesSent || s.BytesSent == 0 {
+		t.Errorf("expected outbound video counters, got %+v", s)
+	}
+	if s.RoundTripTime <= 0 {
+		t.Errorf("expected a selected pair RTT, got %v", s.RoundTripTime)
+	}
+}
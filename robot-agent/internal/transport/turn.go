@@ -0,0 +1,211 @@
+package transport
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TURN credential errors.
+var (
+	ErrTURNFetchFailed = errors.New("failed to fetch TURN credentials")
+)
+
+const (
+	turnRetryMin = 5 * time.Second
+	turnRetryMax = time.Minute
+)
+
+// TURNCredentials are credentials for the TURN servers. URLs overrides the
+// configured TURN server list when set.
+type TURNCredentials struct {
+	Username string
+	Password string
+	URLs     []string
+	Expires  time.Time // Zero for long-term credentials
+}
+
+// TURNCredentialProvider supplies TURN credentials for a new peer
+// connection.
+type TURNCredentialProvider interface {
+	TURNCredentials(ctx context.Context) (TURNCredentials, error)
+}
+
+// refreshAt returns when credentials issued for ttl should be replaced,
+// leaving a fifth of their lifetime as margin for sessions starting late.
+func refreshAt(expires time.Time, ttl time.Duration) time.Time {
+	return expires.Add(-ttl / 5)
+}
+
+// SharedSecretTURN derives time-limited credentials with the coturn REST
+// API scheme (use-auth-secret): username "<expiry>:<user>", password
+// base64(HMAC-SHA1(secret, username)).
+type SharedSecretTURN struct {
+	secret []byte
+	user   string
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	current TURNCredentials
+	now     func() time.Time
+}
+
+// NewSharedSecretTURN creates a provider deriving credentials for user.
+func NewSharedSecretTURN(secret, user string, ttl time.Duration) *SharedSecretTURN {
+	return &SharedSecretTURN{
+		secret: []byte(secret),
+		user:   user,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// TURNCredentials returns the current credentials, deriving new ones when
+// they near expiry.
+func (s *SharedSecretTURN) TURNCredentials(context.Context) (TURNCredentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !s.current.Expires.IsZero() && now.Before(refreshAt(s.current.Expires, s.ttl)) {
+		return s.current, nil
+	}
+
+	expires := now.Add(s.ttl).Truncate(time.Second)
+	username := fmt.Sprintf("%d:%s", expires.Unix(), s.user)
+	mac := hmac.New(sha1.New, s.secret)
+	mac.Write([]byte(username))
+
+	s.current = TURNCredentials{
+		Username: username,
+		Password: base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+		Expires:  expires,
+	}
+	return s.current, nil
+}
+
+// turnRESTResponse is the coturn REST API credential response.
+type turnRESTResponse struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	TTL      int      `json:"ttl"` // Seconds
+	URIs     []string `json:"uris"`
+}
+
+// GatewayTURN fetches time-limited credentials from the gateway and keeps
+// them refreshed ahead of expiry, so sessions do not wait on a fetch.
+type GatewayTURN struct {
+	url    string
+	client *http.Client
+	auth   func() (string, error)
+
+	mu      sync.Mutex
+	current TURNCredentials
+	ttl     time.Duration
+}
+
+// NewGatewayTURN creates a provider fetching from url. auth, if set,
+// returns a bearer token for each request.
+func NewGatewayTURN(url string, client *http.Client, auth func() (string, error)) *GatewayTURN {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &GatewayTURN{url: url, client: client, auth: auth}
+}
+
+// TURNCredentials returns cached credentials, fetching if they are missing
+// or due for refresh.
+func (g *GatewayTURN) TURNCredentials(ctx context.Context) (TURNCredentials, error) {
+	g.mu.Lock()
+	current, ttl := g.current, g.ttl
+	g.mu.Unlock()
+
+	if !current.Expires.IsZero() && time.Now().Before(refreshAt(current.Expires, ttl)) {
+		return current, nil
+	}
+	return g.Refresh(ctx)
+}
+
+// Refresh fetches new credentials from the gateway.
+func (g *GatewayTURN) Refresh(ctx context.Context) (TURNCredentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.url, nil)
+	if err != nil {
+		return TURNCredentials{}, err
+	}
+	if g.auth != nil {
+		token, err := g.auth()
+		if err != nil {
+			return TURNCredentials{}, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return TURNCredentials{}, errors.Join(ErrTURNFetchFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return TURNCredentials{}, fmt.Errorf("%w: status %d", ErrTURNFetchFailed, resp.StatusCode)
+	}
+
+	var body turnRESTResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return TURNCredentials{}, errors.Join(ErrTURNFetchFailed, err)
+	}
+	if body.Username == "" || body.Password == "" || body.TTL <= 0 {
+		return TURNCredentials{}, fmt.Errorf("%w: missing username, password or ttl", ErrTURNFetchFailed)
+	}
+
+	ttl := time.Duration(body.TTL) * time.Second
+	creds := TURNCredentials{
+		Username: body.Username,
+		Password: body.Password,
+		URLs:     body.URIs,
+		Expires:  time.Now().Add(ttl),
+	}
+
+	g.mu.Lock()
+	g.current, g.ttl = creds, ttl
+	g.mu.Unlock()
+	return creds, nil
+}
+
+// Run refreshes credentials before they expire until ctx is done.
+// onError is called for failed refreshes, which are retried with backoff.
+func (g *GatewayTURN) Run(ctx context.Context, onError func(error)) {
+	retry := turnRetryMin
+	for {
+		var wait time.Duration
+		creds, err := g.Refresh(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if onError != nil {
+				onError(err)
+			}
+			wait = retry
+			retry = min(retry*2, turnRetryMax)
+		} else {
+			g.mu.Lock()
+			wait = time.Until(refreshAt(creds.Expires, g.ttl))
+			g.mu.Unlock()
+			retry = turnRetryMin
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package transport
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestSharedSecretTURN_DerivesCoturnCredentials(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewSharedSecretTURN("secret", "robot-1", time.Hour)
+	s.now = func() time.Time { return now }
+
+	creds, err := s.TURNCredentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.Username != "1700003600:robot-1" {
+		t.Errorf("username = %q", creds.Username)
+	}
+	mac := hmac.New(sha1.New, []byte("secret"))
+	mac.Write([]byte(creds.Username))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); creds.Password != want {
+		t.Errorf("password = %q, want %q", creds.Password, want)
+	}
+}
+
+func TestSharedSecretTURN_RefreshesBeforeExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewSharedSecretTURN("secret", "robot-1", time.Hour)
+	s.now = func() time.Time { return now }
+
+	first, _ := s.TURNCredentials(context.Background())
+
+	now = now.Add(30 * time.Minute)
+	if again, _ := s.TURNCredentials(context.Background()); again.Username != first.Username {
+		t.Error("expected cached credentials well before expiry")
+	}
+
+	// Within the last fifth of the lifetime
+	now = now.Add(20 * time.Minute)
+	renewed, _ := s.TURNCredentials(context.Background())
+	if renewed.Username == first.Username || !renewed.Expires.After(first.Expires) {
+		t.Errorf("expected renewed credentials, got %+v", renewed)
+	}
+}
+
+func TestGatewayTURN_FetchesAndCaches(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer robot-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"username":"1700003600:robot-1","password":"pw","ttl":3600,"uris":["turn:turn.example:3478"]}`))
+	}))
+	defer server.Close()
+
+	g := NewGatewayTURN(server.URL, nil, func() (string, error) { return "robot-token", nil })
+
+	creds, err := g.TURNCredentials(context.Background())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if creds.Username != "1700003600:robot-1" || creds.Password != "pw" || creds.URLs[0] != "turn:turn.example:3478" {
+		t.Errorf("unexpected credentials %+v", creds)
+	}
+	if time.Until(creds.Expires) < 59*time.Minute {
+		t.Errorf("expected expiry from ttl, got %v", creds.Expires)
+	}
+
+	if _, err := g.TURNCredentials(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected cached credentials, got %d requests", n)
+	}
+}
+
+func TestGatewayTURN_FetchErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/no-ttl":
+			w.Write([]byte(`{"username":"u","password":"p"}`))
+		case "/no-password":
+			w.Write([]byte(`{"username":"u","password":"","ttl":600}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/denied", "/no-ttl", "/no-password"} {
+		g := NewGatewayTURN(server.URL+path, nil, nil)
+		if _, err := g.TURNCredentials(context.Background()); !errors.Is(err, ErrTURNFetchFailed) {
+			t.Errorf("%s: expected ErrTURNFetchFailed, got %v", path, err)
+		}
+	}
+}
+
+type failingTURN struct{}
+
+// countingTURN counts credential requests.
+type countingTURN struct {
+	calls atomic.Int32
+}
+
+func (c *countingTURN) TURNCredentials(context.Context) (TURNCredentials, error) {
+	c.calls.Add(1)
+	return TURNCredentials{Username: "robot", Password: "pw"}, nil
+}
+
+func (failingTURN) TURNCredentials(context.Context) (TURNCredentials, error) {
+	return TURNCredentials{}, ErrTURNFetchFailed
+}
+
+func TestWebRTC_TURNServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   ICEConfig
+		wantTURN bool
+		wantUser string
+	}{
+		{"none", ICEConfig{}, false, ""},
+		{"no credentials", ICEConfig{TURNServers: []string{"turn:a:3478"}}, false, ""},
+		{"long-term", ICEConfig{TURNServers: []string{"turn:a:3478"}, TURNUsername: "robot", TURNPassword: "pw"}, true, "robot"},
+		{"provider", ICEConfig{TURNServers: []string{"turn:a:3478"}, TURNCredentials: NewSharedSecretTURN("s", "robot-1", time.Hour)}, true, ":robot-1"},
+		{"provider failure", ICEConfig{TURNServers: []string{"turn:a:3478"}, TURNCredentials: failingTURN{}}, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWebRTC(tt.config, zap.NewNop())
+			server, ok := w.turnServer()
+			if ok != tt.wantTURN {
+				t.Fatalf("turn = %v, want %v", ok, tt.wantTURN)
+			}
+			if ok && !strings.HasSuffix(server.Username, tt.wantUser) {
+				t.Errorf("username = %q, want suffix %q", server.Username, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestWebRTC_CreatePeerConnectionWithTURN(t *testing.T) {
+	w := NewWebRTC(ICEConfig{
+		TURNServers:     []string{"turn:127.0.0.1:3478"},
+		TURNCredentials: NewSharedSecretTURN("secret", "robot-1", time.Hour),
+	}, zap.NewNop())
+
+	if err := w.CreatePeerConnection(); err != nil {
+		t.Fatalf("create peer connection: %v", err)
+	}
+	w.Close()
+}
+
+func TestWebRTC_ExistingPeerConnectionSkipsCredentialFetch(t *testing.T) {
+	creds := &countingTURN{}
+	w := NewWebRTC(ICEConfig{
+		TURNServers:     []string{"turn:127.0.0.1:3478"},
+		TURNCredentials: creds,
+	}, zap.NewNop())
+
+	if err := w.CreatePeerConnection(); err != nil {
+		t.Fatalf("create peer connection: %v", err)
+	}
+	defer w.Close()
+
+	if err := w.CreatePeerConnection(); !errors.Is(err, ErrPeerConnectionExists) {
+		t.Fatalf("expected ErrPeerConnectionExists, got %v", err)
+	}
+	if n := creds.calls.Load(); n != 1 {
+		t.Errorf("expected credentials fetched once, got %d", n)
+	}
+}
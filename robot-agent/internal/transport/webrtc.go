@@ -2,9 +2,11 @@
 package transport
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/pion/webrtc/v3"
 	"go.uber.org/zap"
@@ -12,8 +14,10 @@ import (
 
 // Error definitions.
 var (
-	ErrNoPeerConnection = errors.New("no peer connection")
-	ErrNoDataChannel    = errors.New("no data channel")
+	ErrNoPeerConnection     = errors.New("no peer connection")
+	ErrNoDataChannel        = errors.New("no data channel")
+	ErrPeerConnectionExists = errors.New("peer connection already exists")
+	ErrNoICEServers         = errors.New("no STUN or TURN servers configured and LAN-only mode is off")
 )
 
 // DataChannelHandler processes incoming DataChannel messages.
@@ -31,8 +35,39 @@ type ICECandidate struct {
 type ICEConfig struct {
 	STUNServers []string
 	TURNServers []string
+
+	// Long-term TURN credentials, used when TURNCredentials is nil
+	TURNUsername string
+	TURNPassword string
+
+	// TURNCredentials supplies time-limited credentials per peer connection
+	TURNCredentials TURNCredentialProvider
+
+	// Candidate policy, applied through the pion SettingEngine
+	TransportPolicy webrtc.ICETransportPolicy // Relay hides the robot's addresses from the peer
+	NetworkTypes    []string                  // e.g. "udp4", "tcp4"; empty allows all
+	Interfaces      []string                  // Interfaces to gather on; empty allows all
+	UDPPortMin      uint16                    // Ephemeral UDP port range (0-0 = any port)
+	UDPPortMax      uint16
+	NAT1To1IPs      []string // Public IPs advertised in place of host addresses
+
+	// LANOnly gathers host candidates only and contacts no STUN or TURN
+	// server, for offline and air-gapped networks
+	LANOnly bool
+	// MDNSHostCandidates advertises host candidates as .local names
+	// instead of IP addresses. Remote mDNS candidates are always resolved.
+	MDNSHostCandidates bool
+}
+
+// hasServers reports whether any STUN or TURN source is configured.
+func (c ICEConfig) hasServers() bool {
+	return len(c.STUNServers) > 0 || len(c.TURNServers) > 0 || c.TURNCredentials != nil
 }
 
+// turnFetchTimeout bounds how long a new peer connection waits for TURN
+// credentials before continuing without TURN.
+const turnFetchTimeout = 5 * time.Second
+
 // WebRTC manages WebRTC peer connection.
 type WebRTC struct {
 	mu     sync.Mutex
@@ -41,6 +76,7 @@ type WebRTC struct {
 	config ICEConfig
 	pc     *webrtc.PeerConnection
 	dc     *webrtc.DataChannel
+	media  *mediaStats
 
 	onICE          func(candidate []byte)
 	onDataMessage  DataChannelHandler
@@ -76,38 +112,64 @@ func (w *WebRTC) SetStateCallback(fn func(webrtc.PeerConnectionState)) {
 	w.onStateChange = fn
 }
 
-// CreatePeerConnection initializes a new WebRTC peer connection.
+// CreatePeerConnection initializes a new WebRTC peer connection. The
+// previous connection must be closed first so it is never orphaned.
 func (w *WebRTC) CreatePeerConnection() error {
+	if !w.config.LANOnly && !w.config.hasServers() {
+		return ErrNoICEServers
+	}
+
+	// Refuse before fetching credentials a refused connection would not use
+	w.mu.Lock()
+	exists := w.pc != nil
+	w.mu.Unlock()
+	if exists {
+		return ErrPeerConnectionExists
+	}
+
+	// Resolve credentials before locking: a fetch may take a while
+	var turnServer webrtc.ICEServer
+	var hasTURN bool
+	if !w.config.LANOnly {
+		turnServer, hasTURN = w.turnServer()
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if w.pc != nil {
+		return ErrPeerConnectionExists
+	}
+
 	// Build ICE server list
 	iceServers := []webrtc.ICEServer{}
 
-	if len(w.config.STUNServers) > 0 {
+	if len(w.config.STUNServers) > 0 && !w.config.LANOnly {
 		iceServers = append(iceServers, webrtc.ICEServer{
 			URLs: w.config.STUNServers,
 		})
 	}
 
-	if len(w.config.TURNServers) > 0 {
-		iceServers = append(iceServers, webrtc.ICEServer{
-			URLs: w.config.TURNServers,
-		})
+	if hasTURN {
+		iceServers = append(iceServers, turnServer)
 	}
 
-	// Default STUN if none configured
-	if len(iceServers) == 0 {
-		iceServers = append(iceServers, webrtc.ICEServer{
-			URLs: []string{"stun:stun.l.google.com:19302"},
-		})
+	if w.config.TransportPolicy == webrtc.ICETransportPolicyRelay && !hasTURN {
+		w.logger.Warn("relay-only ICE policy without a TURN server; no candidates will be gathered")
 	}
 
 	config := webrtc.Configuration{
-		ICEServers: iceServers,
+		ICEServers:         iceServers,
+		ICETransportPolicy: w.config.TransportPolicy,
 	}
 
-	pc, err := webrtc.NewPeerConnection(config)
+	media := &mediaStats{}
+	api, err := w.config.newAPI(media)
+	if err != nil {
+		return err
+	}
+
+	pc, err := api.NewPeerConnection(config)
 	if err != nil {
 		return err
 	}
@@ -165,9 +227,46 @@ func (w *WebRTC) CreatePeerConnection() error {
 	})
 
 	w.pc = pc
+	w.media = media
 	return nil
 }
 
+// turnServer returns the TURN server entry for a new peer connection with
+// current credentials. Without usable credentials TURN is skipped, since
+// coturn rejects unauthenticated allocations.
+func (w *WebRTC) turnServer() (webrtc.ICEServer, bool) {
+	server := webrtc.ICEServer{
+		URLs:       w.config.TURNServers,
+		Username:   w.config.TURNUsername,
+		Credential: w.config.TURNPassword,
+	}
+
+	if w.config.TURNCredentials != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), turnFetchTimeout)
+		defer cancel()
+
+		creds, err := w.config.TURNCredentials.TURNCredentials(ctx)
+		if err != nil {
+			w.logger.Warn("TURN credentials unavailable, continuing without TURN", zap.Error(err))
+			return webrtc.ICEServer{}, false
+		}
+		server.Username, server.Credential = creds.Username, creds.Password
+		if len(creds.URLs) > 0 {
+			server.URLs = creds.URLs
+		}
+	}
+
+	if len(server.URLs) == 0 {
+		return webrtc.ICEServer{}, false
+	}
+	if server.Username == "" {
+		w.logger.Warn("TURN servers configured without credentials, skipping TURN",
+			zap.Strings("urls", server.URLs))
+		return webrtc.ICEServer{}, false
+	}
+	return server, true
+}
+
 // HandleOffer processes an SDP offer and generates an answer.
 func (w *WebRTC) HandleOffer(sdpData []byte) ([]byte, error) {
 	w.mu.Lock()
@@ -244,6 +343,7 @@ func (w *WebRTC) Close() error {
 		err := w.pc.Close()
 		w.pc = nil
 		w.dc = nil
+		w.media = nil
 		return err
 	}
 	return nil
@@ -11,16 +11,22 @@ const (
 	TypeAuthErr MessageType = "auth_err"
 
 	// Control
-	TypeDrive    MessageType = "drive"
-	TypeKVMKey   MessageType = "kvm_key"
-	TypeKVMMouse MessageType = "kvm_mouse"
-	TypeEStop    MessageType = "e_stop"
+	TypeDrive        MessageType = "drive"
+	TypeKVMKey       MessageType = "kvm_key"
+	TypeKVMMouse     MessageType = "kvm_mouse"
+	TypeKVMMouseMode MessageType = "kvm_mouse_mode"
+	TypeKVMType      MessageType = "kvm_type"
+	TypeEStop        MessageType = "e_stop"
+	TypeMode         MessageType = "mode"
 
 	// Measurement
 	TypePing           MessageType = "ping"
 	TypePong           MessageType = "pong"
 	TypeFrameTimestamp MessageType = "frame_timestamp"
 
+	// Telemetry
+	TypeTelemetry MessageType = "telemetry"
+
 	// Response
 	TypeAck   MessageType = "ack"
 	TypeError MessageType = "error"
@@ -44,8 +50,20 @@ type AuthOKMessage struct {
 	Type      MessageType `json:"type"`
 	SessionID string      `json:"session_id"`
 	RobotID   string      `json:"robot_id"`
-	Scope     []string    `json:"scope"`
-	ExpiresAt int64       `json:"expires_at"`
+	Scope        []string      `json:"scope"`
+	ExpiresAt    int64         `json:"expires_at"`
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+}
+
+// Capabilities describes what the robot supports.
+type Capabilities struct {
+	Commands      []MessageType `json:"commands"`                  // Accepted control message types
+	MaxLinearMps  float64       `json:"max_linear_mps,omitempty"`  // Physical speed at v = 1
+	MaxAngularRps float64       `json:"max_angular_rps,omitempty"` // Physical turn rate at w = 1
+	MaxSpeed      float64       `json:"max_speed,omitempty"`       // Speed fraction allowed this session
+	Modes         []string      `json:"modes,omitempty"`           // Selectable modes (e.g., stand, sit, walk)
+	Mode          string        `json:"mode,omitempty"`            // Current mode
+	MouseModes    []string      `json:"mouse_modes,omitempty"`     // Supported pointer modes
 }
 
 // AuthErrMessage indicates authentication failure.
@@ -67,9 +85,15 @@ const (
 // DriveMessage commands mobile base velocity.
 type DriveMessage struct {
 	Type MessageType `json:"type"`
-	V    float64     `json:"v"` // Linear velocity [-1, 1]
-	W    float64     `json:"w"` // Angular velocity [-1, 1]
-	T    int64       `json:"t"` // Timestamp (ms)
+	V    float64     `json:"v"`             // Linear velocity [-1, 1]
+	W    float64     `json:"w"`             // Angular velocity [-1, 1]
+	T    int64       `json:"t"`             // Timestamp (ms)
+	Seq  uint64      `json:"seq,omitempty"` // Per-stream sequence number (0 = unsequenced)
+
+	// Deadman: the command lapses to zero velocity unless refreshed within
+	// ValidMS, or within the agent's deadman window when Hold is set.
+	Hold    bool `json:"hold,omitempty"`
+	ValidMS int  `json:"valid_ms,omitempty"`
 }
 
 // KVMKeyMessage sends keyboard input.
@@ -79,6 +103,7 @@ type KVMKeyMessage struct {
 	Action    string      `json:"action"` // "down" or "up"
 	Modifiers []string    `json:"modifiers,omitempty"`
 	T         int64       `json:"t"`
+	Seq       uint64      `json:"seq,omitempty"`
 }
 
 // KVMMouseMessage sends mouse input.
@@ -86,9 +111,34 @@ type KVMMouseMessage struct {
 	Type    MessageType `json:"type"`
 	DX      int         `json:"dx"`
 	DY      int         `json:"dy"`
-	Buttons int         `json:"buttons"` // Bitmask
+	X       *float64    `json:"x,omitempty"` // Absolute mode: [0, 1] across the video frame
+	Y       *float64    `json:"y,omitempty"` // Absolute mode: [0, 1] down the video frame
+	Buttons int         `json:"buttons"`     // Bitmask
 	Scroll  int         `json:"scroll,omitempty"`
 	T       int64       `json:"t"`
+	Seq     uint64      `json:"seq,omitempty"`
+}
+
+// KVMMouseModeMessage selects the pointer mode for the session.
+type KVMMouseModeMessage struct {
+	Type MessageType `json:"type"`
+	Mode string      `json:"mode"` // "relative" or "absolute"
+	T    int64       `json:"t"`
+}
+
+// Mouse modes.
+const (
+	MouseModeRelative = "relative"
+	MouseModeAbsolute = "absolute"
+)
+
+// KVMTypeMessage types a UTF-8 string on the target host.
+type KVMTypeMessage struct {
+	Type   MessageType `json:"type"`
+	Text   string      `json:"text"`
+	Layout string      `json:"layout,omitempty"` // Host keyboard layout (default "us")
+	T      int64       `json:"t"`
+	Seq    uint64      `json:"seq,omitempty"`
 }
 
 // EStopMessage triggers emergency stop.
@@ -97,6 +147,13 @@ type EStopMessage struct {
 	T    int64       `json:"t"`
 }
 
+// ModeMessage switches the robot mode (e.g., gait).
+type ModeMessage struct {
+	Type MessageType `json:"type"`
+	Mode string      `json:"mode"`
+	T    int64       `json:"t"`
+}
+
 // PingMessage measures RTT.
 type PingMessage struct {
 	Type  MessageType `json:"type"`
@@ -152,9 +209,62 @@ type StateMessage struct {
 	Type         MessageType `json:"type"`
 	RobotState   string      `json:"robot_state"`
 	SessionState string      `json:"session_state"`
+	LimitReasons []string    `json:"limit_reasons,omitempty"` // Active speed limits (e.g., obstacle_slow)
 	T            int64       `json:"t"`
 }
 
+// Telemetry kinds.
+const (
+	TelemetryBattery  = "battery"
+	TelemetryOdometry = "odometry"
+	TelemetryThermal  = "thermal"
+	TelemetrySystem   = "system"
+	TelemetryLink     = "link"
+)
+
+// TelemetryMessage reports robot telemetry. Exactly one payload field is set,
+// matching Kind.
+type TelemetryMessage struct {
+	Type         MessageType        `json:"type"`
+	Kind         string             `json:"kind"`
+	Battery      *BatteryTelemetry  `json:"battery,omitempty"`
+	Odometry     *OdometryTelemetry `json:"odometry,omitempty"`
+	Temperatures map[string]float64 `json:"temperatures,omitempty"` // Joint/motor name -> degrees C
+	System       *SystemTelemetry   `json:"system,omitempty"`
+	Link         *LinkTelemetry     `json:"link,omitempty"`
+	T            int64              `json:"t"`
+}
+
+// BatteryTelemetry reports battery status.
+type BatteryTelemetry struct {
+	Percent  float64 `json:"percent"`
+	Voltage  float64 `json:"voltage,omitempty"`
+	Current  float64 `json:"current,omitempty"` // Amps, negative while discharging
+	Charging bool    `json:"charging"`
+}
+
+// OdometryTelemetry reports robot pose and measured velocity.
+type OdometryTelemetry struct {
+	X     float64 `json:"x"`     // Meters
+	Y     float64 `json:"y"`     // Meters
+	Theta float64 `json:"theta"` // Radians
+	V     float64 `json:"v"`     // Linear velocity (m/s)
+	W     float64 `json:"w"`     // Angular velocity (rad/s)
+}
+
+// SystemTelemetry reports robot computer load.
+type SystemTelemetry struct {
+	CPUPercent    float64 `json:"cpu_percent"`
+	MemoryPercent float64 `json:"memory_percent,omitempty"`
+}
+
+// LinkTelemetry reports network link quality.
+type LinkTelemetry struct {
+	RTTMs     float64 `json:"rtt_ms,omitempty"`     // Control channel RTT
+	SignalDBm float64 `json:"signal_dbm,omitempty"` // Wireless signal strength
+	Quality   float64 `json:"quality,omitempty"`    // Backend-specific link quality [0, 1]
+}
+
 // Robot states.
 const (
 	RobotStateIdle           = "idle"
@@ -168,4 +278,7 @@ const (
 	ScopeView    = "teleop:view"
 	ScopeControl = "teleop:control"
 	ScopeEStop   = "teleop:estop"
+	ScopeMode    = "teleop:mode"
+
+	ScopeKVMPrivileged = "teleop:kvm_privileged"
 )